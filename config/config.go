@@ -0,0 +1,595 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the service. Load fills it from, in increasing
+// order of precedence: the defaults below, a YAML file, environment variables
+// and command line flags.
+type Config struct {
+	Server ServerConfig `yaml:"server"`
+	Mongo  MongoConfig  `yaml:"mongo"`
+	JWT    JWTConfig    `yaml:"jwt"`
+	CORS   CORSConfig   `yaml:"cors"`
+	Log    LogConfig    `yaml:"log"`
+	Tax    TaxConfig    `yaml:"tax"`
+
+	ServiceCharge ServiceChargeConfig `yaml:"service_charge"`
+	Receipt       ReceiptConfig       `yaml:"receipt"`
+	Kitchen       KitchenConfig       `yaml:"kitchen"`
+	Printing      PrintingConfig      `yaml:"printing"`
+	Webhooks      WebhookConfig       `yaml:"webhooks"`
+	Reservations  ReservationConfig   `yaml:"reservations"`
+	Waitlist      WaitlistConfig      `yaml:"waitlist"`
+	Guest         GuestConfig         `yaml:"guest"`
+	Menus         MenuConfig          `yaml:"menus"`
+}
+
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+type MongoConfig struct {
+	URI            string        `yaml:"uri"`
+	Database       string        `yaml:"database"`
+	MinPoolSize    uint64        `yaml:"min_pool_size"`
+	MaxPoolSize    uint64        `yaml:"max_pool_size"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	// QueryTimeout bounds the database work of a single request
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+type JWTConfig struct {
+	Secret          string        `yaml:"secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string      `yaml:"allowed_origins"`
+	AllowedMethods []string      `yaml:"allowed_methods"`
+	AllowedHeaders []string      `yaml:"allowed_headers"`
+	MaxAge         time.Duration `yaml:"max_age"`
+}
+
+// TaxConfig picks the tax rate of an order item by the category of its food.
+// Foods whose category has no rule are taxed with Default.
+type TaxConfig struct {
+	// Inclusive means menu prices already contain the tax
+	Inclusive bool      `yaml:"inclusive"`
+	Default   TaxRate   `yaml:"default"`
+	Rules     []TaxRule `yaml:"rules"`
+}
+
+type TaxRate struct {
+	Name string `yaml:"name"`
+	// Percent is the rate in percent, 12.5 for 12.5%
+	Percent float64 `yaml:"percent"`
+}
+
+type TaxRule struct {
+	// Category is matched case-insensitively against Food.Category
+	Category string `yaml:"category"`
+	TaxRate  `yaml:",inline"`
+	// Inclusive overrides TaxConfig.Inclusive for this category when set
+	Inclusive *bool `yaml:"inclusive"`
+}
+
+// ServiceChargeConfig adds Percent of the subtotal to the bill of tables with
+// at least MinGuests guests. A zero Percent turns the service charge off.
+type ServiceChargeConfig struct {
+	Name      string  `yaml:"name"`
+	Percent   float64 `yaml:"percent"`
+	MinGuests int     `yaml:"min_guests"`
+}
+
+// ReceiptConfig is printed at the top and bottom of every receipt.
+type ReceiptConfig struct {
+	Name      string   `yaml:"name"`
+	Address   []string `yaml:"address"`
+	Phone     string   `yaml:"phone"`
+	TaxNumber string   `yaml:"tax_number"`
+	Footer    []string `yaml:"footer"`
+}
+
+// KitchenConfig splits the kitchen into stations, each preparing some food
+// categories. Without stations the whole kitchen is one station.
+type KitchenConfig struct {
+	Stations []StationConfig `yaml:"stations"`
+}
+
+// StationConfig takes the items of the given food categories. A station
+// without categories takes the items no other station takes. Tickets of a
+// station with a printer are also printed there.
+type StationConfig struct {
+	Name       string   `yaml:"name"`
+	Categories []string `yaml:"categories"`
+	Printer    string   `yaml:"printer"`
+}
+
+// PrintingConfig names the thermal printers and where receipts are printed.
+type PrintingConfig struct {
+	Printers       []PrinterConfig `yaml:"printers"`
+	ReceiptPrinter string          `yaml:"receipt_printer"`
+	// MaxAttempts is how often a job is tried before it is marked FAILED
+	MaxAttempts int           `yaml:"max_attempts"`
+	RetryDelay  time.Duration `yaml:"retry_delay"`
+}
+
+// printer drivers
+const (
+	// PrinterTCP sends to the raw port of a network printer, host:9100
+	PrinterTCP = "tcp"
+	// PrinterFile appends to a file or device such as /dev/usb/lp0
+	PrinterFile = "file"
+)
+
+type PrinterConfig struct {
+	Name    string `yaml:"name"`
+	Driver  string `yaml:"driver"`
+	Address string `yaml:"address"`
+	// Columns is the number of characters on a line, 42 or 48 on 80mm paper
+	Columns int           `yaml:"columns"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// WebhookConfig controls the delivery of webhooks. A failed delivery is tried
+// again after RetryDelay, doubling every time up to MaxRetryDelay, until
+// MaxAttempts is reached and it goes on the dead letter list.
+type WebhookConfig struct {
+	MaxAttempts   int           `yaml:"max_attempts"`
+	RetryDelay    time.Duration `yaml:"retry_delay"`
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay"`
+	// Timeout is how long a receiver has to answer
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ReservationConfig sets how long a booking holds its table: the dining
+// duration plus a buffer to clear and reset the table.
+type ReservationConfig struct {
+	DiningDuration time.Duration `yaml:"dining_duration"`
+	Buffer         time.Duration `yaml:"buffer"`
+	// SlotInterval is the step between the other times offered when the
+	// requested one is full
+	SlotInterval time.Duration `yaml:"slot_interval"`
+	// NoShowGrace is how late a party can be before it can be marked a no-show
+	NoShowGrace time.Duration `yaml:"no_show_grace"`
+}
+
+// WaitlistConfig tunes the wait quoted to walk-ins. Turn times, how long a
+// table is taken from the order to the payment, are learned from the last
+// TurnTimeSamples paid orders; DefaultTurnTime stands in until there are any.
+type WaitlistConfig struct {
+	DefaultTurnTime time.Duration `yaml:"default_turn_time"`
+	TurnTimeSamples int           `yaml:"turn_time_samples"`
+}
+
+// GuestConfig sets up ordering by guests from the code on their table.
+// OrderURL is the guest ordering page the codes link to, the table and its
+// signature are added to it. A guest session lasts SessionTTL.
+type GuestConfig struct {
+	OrderURL   string        `yaml:"order_url"`
+	SessionTTL time.Duration `yaml:"session_ttl"`
+}
+
+// MenuConfig sets the clock menu schedules are read on. Timezone is an IANA
+// name like Europe/Berlin, the local time of the server when empty. Holidays
+// are the dates, 2006-01-02, the restaurant keeps its holiday hours on.
+type MenuConfig struct {
+	Timezone string   `yaml:"timezone"`
+	Holidays []string `yaml:"holidays"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+// log levels, debug also puts gin in debug mode
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:         8000,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		},
+		Mongo: MongoConfig{
+			URI:            "mongodb://localhost:27017",
+			Database:       "restaurant",
+			MinPoolSize:    0,
+			MaxPoolSize:    100,
+			ConnectTimeout: 10 * time.Second,
+			QueryTimeout:   100 * time.Second,
+		},
+		JWT: JWTConfig{
+			AccessTokenTTL:  24 * time.Hour,
+			RefreshTokenTTL: 128 * time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "token"},
+			MaxAge:         12 * time.Hour,
+		},
+		Log: LogConfig{
+			Level: LogInfo,
+		},
+		Receipt: ReceiptConfig{
+			Name:   "Restaurant",
+			Footer: []string{"Thank you for dining with us!"},
+		},
+		Printing: PrintingConfig{
+			MaxAttempts: 3,
+			RetryDelay:  2 * time.Second,
+		},
+		Webhooks: WebhookConfig{
+			MaxAttempts:   8,
+			RetryDelay:    30 * time.Second,
+			MaxRetryDelay: time.Hour,
+			Timeout:       10 * time.Second,
+		},
+		Reservations: ReservationConfig{
+			DiningDuration: 90 * time.Minute,
+			Buffer:         15 * time.Minute,
+			SlotInterval:   15 * time.Minute,
+			NoShowGrace:    15 * time.Minute,
+		},
+		Waitlist: WaitlistConfig{
+			DefaultTurnTime: time.Hour,
+			TurnTimeSamples: 50,
+		},
+		Guest: GuestConfig{
+			OrderURL:   "http://localhost:3000/order",
+			SessionTTL: 3 * time.Hour,
+		},
+	}
+}
+
+// setting is one value that can come from an environment variable and a flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"PORT", "port", "HTTP port to listen on", intValue(func(c *Config) *int { return &c.Server.Port })},
+	{"RESTAURANT_READ_TIMEOUT", "read-timeout", "HTTP read timeout", durationValue(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"RESTAURANT_WRITE_TIMEOUT", "write-timeout", "HTTP write timeout", durationValue(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"RESTAURANT_MONGO_URI", "mongo-uri", "MongoDB connection string", stringValue(func(c *Config) *string { return &c.Mongo.URI })},
+	{"RESTAURANT_MONGO_DATABASE", "mongo-database", "MongoDB database name", stringValue(func(c *Config) *string { return &c.Mongo.Database })},
+	{"RESTAURANT_MONGO_MIN_POOL_SIZE", "mongo-min-pool-size", "minimum MongoDB connection pool size", uintValue(func(c *Config) *uint64 { return &c.Mongo.MinPoolSize })},
+	{"RESTAURANT_MONGO_MAX_POOL_SIZE", "mongo-max-pool-size", "maximum MongoDB connection pool size", uintValue(func(c *Config) *uint64 { return &c.Mongo.MaxPoolSize })},
+	{"RESTAURANT_MONGO_CONNECT_TIMEOUT", "mongo-connect-timeout", "MongoDB connect timeout", durationValue(func(c *Config) *time.Duration { return &c.Mongo.ConnectTimeout })},
+	{"RESTAURANT_MONGO_QUERY_TIMEOUT", "mongo-query-timeout", "time limit for the database work of one request", durationValue(func(c *Config) *time.Duration { return &c.Mongo.QueryTimeout })},
+	{"SECRET_KEY", "jwt-secret", "secret used to sign the JWTs", stringValue(func(c *Config) *string { return &c.JWT.Secret })},
+	{"RESTAURANT_JWT_ACCESS_TTL", "jwt-access-ttl", "lifetime of access tokens", durationValue(func(c *Config) *time.Duration { return &c.JWT.AccessTokenTTL })},
+	{"RESTAURANT_JWT_REFRESH_TTL", "jwt-refresh-ttl", "lifetime of refresh tokens", durationValue(func(c *Config) *time.Duration { return &c.JWT.RefreshTokenTTL })},
+	{"RESTAURANT_CORS_ORIGINS", "cors-origins", "comma separated origins allowed by CORS", listValue(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"RESTAURANT_NAME", "restaurant-name", "name printed on receipts", stringValue(func(c *Config) *string { return &c.Receipt.Name })},
+	{"RESTAURANT_LOG_LEVEL", "log-level", "debug, info, warn or error", stringValue(func(c *Config) *string { return &c.Log.Level })},
+	{"RESTAURANT_TIMEZONE", "timezone", "timezone menu schedules are read in", stringValue(func(c *Config) *string { return &c.Menus.Timezone })},
+	{"RESTAURANT_GUEST_ORDER_URL", "guest-order-url", "guest ordering page the table codes link to", stringValue(func(c *Config) *string { return &c.Guest.OrderURL })},
+}
+
+// Load reads the configuration. args are the command line arguments without the
+// program name; -config (or RESTAURANT_CONFIG) names the YAML file to read.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("restaurant", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("RESTAURANT_CONFIG"), "path to a YAML configuration file")
+	values := map[string]*string{}
+	for _, s := range settings {
+		values[s.flag] = flags.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.apply(&cfg, value); err != nil {
+			return cfg, fmt.Errorf("environment variable %s: %w", s.env, err)
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.apply(&cfg, *values[s.flag]); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %w", s.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return cfg, flagErr
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (cfg Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+		invalid("server.port must be between 1 and 65535, got %d", cfg.Server.Port)
+	}
+	if cfg.Server.ReadTimeout <= 0 || cfg.Server.WriteTimeout <= 0 {
+		invalid("server.read_timeout and server.write_timeout must be positive")
+	}
+
+	if uri, err := url.Parse(cfg.Mongo.URI); err != nil || (uri.Scheme != "mongodb" && uri.Scheme != "mongodb+srv") {
+		invalid("mongo.uri must be a mongodb:// or mongodb+srv:// URI, got %q", cfg.Mongo.URI)
+	}
+	if cfg.Mongo.Database == "" {
+		invalid("mongo.database is required")
+	}
+	if cfg.Mongo.MaxPoolSize == 0 {
+		invalid("mongo.max_pool_size must be at least 1")
+	}
+	if cfg.Mongo.MinPoolSize > cfg.Mongo.MaxPoolSize {
+		invalid("mongo.min_pool_size (%d) is larger than mongo.max_pool_size (%d)", cfg.Mongo.MinPoolSize, cfg.Mongo.MaxPoolSize)
+	}
+	if cfg.Mongo.ConnectTimeout <= 0 || cfg.Mongo.QueryTimeout <= 0 {
+		invalid("mongo.connect_timeout and mongo.query_timeout must be positive")
+	}
+
+	if cfg.JWT.Secret == "" {
+		invalid("jwt.secret is required, set it in the config file or with SECRET_KEY")
+	}
+	if cfg.JWT.AccessTokenTTL <= 0 {
+		invalid("jwt.access_token_ttl must be positive")
+	}
+	if cfg.JWT.RefreshTokenTTL <= cfg.JWT.AccessTokenTTL {
+		invalid("jwt.refresh_token_ttl must be longer than jwt.access_token_ttl")
+	}
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("cors.allowed_origins: %q is not an origin like https://example.com", origin)
+		}
+	}
+
+	validateTaxRate := func(field string, rate TaxRate) {
+		if rate.Percent < 0 || rate.Percent > 100 {
+			invalid("%s.percent must be between 0 and 100, got %v", field, rate.Percent)
+		}
+		if rate.Percent > 0 && rate.Name == "" {
+			invalid("%s.name is required for a non-zero rate", field)
+		}
+	}
+	validateTaxRate("tax.default", cfg.Tax.Default)
+	categories := map[string]bool{}
+	for i, rule := range cfg.Tax.Rules {
+		field := fmt.Sprintf("tax.rules[%d]", i)
+		category := strings.ToLower(rule.Category)
+		if category == "" {
+			invalid("%s.category is required", field)
+		} else if categories[category] {
+			invalid("%s: category %q has more than one rule", field, rule.Category)
+		}
+		categories[category] = true
+		validateTaxRate(field, rule.TaxRate)
+	}
+
+	if cfg.ServiceCharge.Percent < 0 || cfg.ServiceCharge.Percent > 100 {
+		invalid("service_charge.percent must be between 0 and 100, got %v", cfg.ServiceCharge.Percent)
+	}
+	if cfg.ServiceCharge.Percent > 0 && cfg.ServiceCharge.Name == "" {
+		invalid("service_charge.name is required when service_charge.percent is set")
+	}
+	if cfg.ServiceCharge.MinGuests < 0 {
+		invalid("service_charge.min_guests cannot be negative")
+	}
+
+	if strings.TrimSpace(cfg.Receipt.Name) == "" {
+		invalid("receipt.name is required")
+	}
+
+	printers := map[string]bool{}
+	for i, printer := range cfg.Printing.Printers {
+		field := fmt.Sprintf("printing.printers[%d]", i)
+		if printer.Name == "" {
+			invalid("%s.name is required", field)
+		} else if printers[printer.Name] {
+			invalid("%s: printer %q is defined more than once", field, printer.Name)
+		}
+		printers[printer.Name] = true
+		if printer.Driver != PrinterTCP && printer.Driver != PrinterFile {
+			invalid("%s.driver must be %s or %s, got %q", field, PrinterTCP, PrinterFile, printer.Driver)
+		}
+		if printer.Address == "" {
+			invalid("%s.address is required", field)
+		}
+		if printer.Columns < 0 || printer.Timeout < 0 {
+			invalid("%s.columns and %s.timeout cannot be negative", field, field)
+		}
+	}
+	catchAll := 0
+	stations := map[string]bool{}
+	for i, station := range cfg.Kitchen.Stations {
+		field := fmt.Sprintf("kitchen.stations[%d]", i)
+		if station.Name == "" {
+			invalid("%s.name is required", field)
+		} else if stations[station.Name] {
+			invalid("%s: station %q is defined more than once", field, station.Name)
+		}
+		stations[station.Name] = true
+		if station.Printer != "" && !printers[station.Printer] {
+			invalid("%s.printer %q is not one of printing.printers", field, station.Printer)
+		}
+		if len(station.Categories) == 0 {
+			catchAll++
+		}
+	}
+	if catchAll > 1 {
+		invalid("kitchen.stations: only one station can be without categories")
+	}
+	if cfg.Printing.ReceiptPrinter != "" && !printers[cfg.Printing.ReceiptPrinter] {
+		invalid("printing.receipt_printer %q is not one of printing.printers", cfg.Printing.ReceiptPrinter)
+	}
+	if cfg.Printing.MaxAttempts < 1 {
+		invalid("printing.max_attempts must be at least 1")
+	}
+	if cfg.Printing.RetryDelay < 0 {
+		invalid("printing.retry_delay cannot be negative")
+	}
+	if cfg.Webhooks.MaxAttempts < 1 {
+		invalid("webhooks.max_attempts must be at least 1")
+	}
+	if cfg.Webhooks.RetryDelay <= 0 {
+		invalid("webhooks.retry_delay must be positive")
+	}
+	if cfg.Webhooks.MaxRetryDelay < cfg.Webhooks.RetryDelay {
+		invalid("webhooks.max_retry_delay cannot be less than webhooks.retry_delay")
+	}
+	if cfg.Webhooks.Timeout <= 0 {
+		invalid("webhooks.timeout must be positive")
+	}
+	if cfg.Reservations.DiningDuration <= 0 {
+		invalid("reservations.dining_duration must be positive")
+	}
+	if cfg.Reservations.Buffer < 0 {
+		invalid("reservations.buffer cannot be negative")
+	}
+	if cfg.Reservations.SlotInterval <= 0 {
+		invalid("reservations.slot_interval must be positive")
+	}
+	if cfg.Reservations.NoShowGrace < 0 {
+		invalid("reservations.no_show_grace cannot be negative")
+	}
+	if cfg.Waitlist.DefaultTurnTime <= 0 {
+		invalid("waitlist.default_turn_time must be positive")
+	}
+	if cfg.Waitlist.TurnTimeSamples < 1 {
+		invalid("waitlist.turn_time_samples must be at least 1")
+	}
+	if u, err := url.Parse(cfg.Guest.OrderURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalid("guest.order_url must be an http:// or https:// URL, got %q", cfg.Guest.OrderURL)
+	}
+	if cfg.Guest.SessionTTL <= 0 {
+		invalid("guest.session_ttl must be positive")
+	}
+
+	if _, err := time.LoadLocation(cfg.Menus.Timezone); err != nil {
+		invalid("menus.timezone %q is not a known timezone", cfg.Menus.Timezone)
+	}
+	for i, holiday := range cfg.Menus.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday); err != nil {
+			invalid("menus.holidays[%d] must be a date like 2026-12-25, got %q", i, holiday)
+		}
+	}
+
+	switch cfg.Log.Level {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		invalid("log.level must be debug, info, warn or error, got %q", cfg.Log.Level)
+	}
+
+	return errors.Join(errs...)
+}
+
+func stringValue(field func(*Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func intValue(field func(*Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func uintValue(field func(*Config) *uint64) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a positive number", value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func durationValue(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 24h", value)
+		}
+		*field(cfg) = d
+		return nil
+	}
+}
+
+func listValue(field func(*Config) *[]string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(cfg) = list
+		return nil
+	}
+}
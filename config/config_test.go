@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9000
+mongo:
+  uri: mongodb://db.internal:27017
+  database: from_file
+jwt:
+  secret: file-secret
+  access_token_ttl: 1h
+log:
+  level: warn
+`)
+	t.Setenv("RESTAURANT_MONGO_DATABASE", "from_env")
+	t.Setenv("RESTAURANT_CORS_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load([]string{"-config", path, "-port", "9200"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9200, cfg.Server.Port, "flag beats env and file")
+	assert.Equal(t, "from_env", cfg.Mongo.Database, "env beats file")
+	assert.Equal(t, "mongodb://db.internal:27017", cfg.Mongo.URI)
+	assert.Equal(t, time.Hour, cfg.JWT.AccessTokenTTL)
+	assert.Equal(t, 128*time.Hour, cfg.JWT.RefreshTokenTTL, "defaults are kept")
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, LogWarn, cfg.Log.Level)
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := writeConfig(t, "mongo:\n  url: mongodb://localhost\n")
+	t.Setenv("SECRET_KEY", "secret")
+
+	_, err := Load([]string{"-config", path})
+	assert.ErrorContains(t, err, "field url not found")
+}
+
+func TestLoadReportsBadValues(t *testing.T) {
+	t.Setenv("SECRET_KEY", "secret")
+	t.Setenv("RESTAURANT_MONGO_QUERY_TIMEOUT", "soon")
+
+	_, err := Load(nil)
+	assert.ErrorContains(t, err, "RESTAURANT_MONGO_QUERY_TIMEOUT")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.JWT.Secret = ""
+	cfg.Mongo.URI = "localhost:27017"
+	cfg.Mongo.MinPoolSize = 10
+	cfg.Mongo.MaxPoolSize = 5
+	cfg.JWT.RefreshTokenTTL = time.Minute
+	cfg.CORS.AllowedOrigins = []string{"example.com"}
+	cfg.Log.Level = "verbose"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{"jwt.secret", "mongo.uri", "mongo.min_pool_size", "jwt.refresh_token_ttl", "cors.allowed_origins", "log.level"} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestValidateTaxRules(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Tax.Rules = []TaxRule{
+		{Category: "Alcohol", TaxRate: TaxRate{Name: "VAT", Percent: 20}},
+		{Category: "alcohol", TaxRate: TaxRate{Name: "VAT", Percent: 20}},
+		{TaxRate: TaxRate{Percent: 120}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `tax.rules[1]: category "alcohol" has more than one rule`)
+	assert.ErrorContains(t, err, "tax.rules[2].category is required")
+	assert.ErrorContains(t, err, "tax.rules[2].percent must be between 0 and 100")
+	assert.ErrorContains(t, err, "tax.rules[2].name is required")
+}
+
+func TestValidatePrinting(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Printing.Printers = []PrinterConfig{
+		{Name: "kitchen", Driver: PrinterTCP, Address: "127.0.0.1:9100"},
+		{Name: "kitchen", Driver: "usb"},
+	}
+	cfg.Kitchen.Stations = []StationConfig{
+		{Name: "Grill", Printer: "kitchen"},
+		{Name: "Bar", Printer: "bar"},
+	}
+	cfg.Printing.ReceiptPrinter = "counter"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `printing.printers[1]: printer "kitchen" is defined more than once`)
+	assert.ErrorContains(t, err, "printing.printers[1].driver must be tcp or file")
+	assert.ErrorContains(t, err, "printing.printers[1].address is required")
+	assert.ErrorContains(t, err, `kitchen.stations[1].printer "bar"`)
+	assert.ErrorContains(t, err, "only one station can be without categories")
+	assert.ErrorContains(t, err, `printing.receipt_printer "counter"`)
+}
+
+func TestValidateWebhooksAndReservations(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Webhooks.MaxAttempts = 0
+	cfg.Webhooks.MaxRetryDelay = time.Second
+	cfg.Reservations.DiningDuration = 0
+	cfg.Waitlist.TurnTimeSamples = 0
+	cfg.Guest.OrderURL = "/order"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "webhooks.max_attempts must be at least 1")
+	assert.ErrorContains(t, err, "webhooks.max_retry_delay cannot be less than webhooks.retry_delay")
+	assert.ErrorContains(t, err, "reservations.dining_duration must be positive")
+	assert.ErrorContains(t, err, "waitlist.turn_time_samples must be at least 1")
+	assert.ErrorContains(t, err, "guest.order_url must be an http:// or https:// URL")
+}
+
+func TestValidateMenus(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	cfg.Menus.Timezone = "Mars/Olympus"
+	cfg.Menus.Holidays = []string{"2026-12-25", "25.12.2026"}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `menus.timezone "Mars/Olympus" is not a known timezone`)
+	assert.ErrorContains(t, err, "menus.holidays[1] must be a date like 2026-12-25")
+	assert.NotContains(t, err.Error(), "menus.holidays[0]")
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetAdjustments(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		allAdjustments, err := store.Adjustments.List(ctx, c.Query("status"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing adjustments"})
+			return
+		}
+		c.JSON(http.StatusOK, allAdjustments)
+	}
+}
+
+func GetAdjustment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		adjustment, err := store.Adjustments.FindByID(ctx, c.Param("adjustment_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "adjustment not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the adjustment"})
+			return
+		}
+		c.JSON(http.StatusOK, adjustment)
+	}
+}
+
+// CreateAdjustment requests a void or comp of an order item, or a refund on an
+// invoice. Requests by managers are approved straight away, everyone else's
+// wait for a manager.
+func CreateAdjustment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var adjustment models.Adjustment
+		if err := c.BindJSON(&adjustment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(adjustment); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		var status int
+		var msg string
+		if *adjustment.Type == models.AdjustmentRefund {
+			status, msg = prepareRefund(ctx, store, &adjustment)
+		} else {
+			status, msg = prepareItemAdjustment(ctx, store, &adjustment)
+		}
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		adjustment.ID = primitive.NewObjectID()
+		adjustment.Adjustment_id = adjustment.ID.Hex()
+		adjustment.Status = models.AdjustmentPending
+		adjustment.Requested_by = c.GetString("uid")
+		adjustment.Requested_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		adjustment.Decided_by = nil
+		adjustment.Decided_at = nil
+
+		if err := store.Adjustments.Insert(ctx, adjustment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "adjustment was not created"})
+			return
+		}
+
+		if models.IsManager(c.GetString("user_type")) {
+			if status, msg := approveAdjustment(ctx, store, adjustment, c.GetString("uid")); msg != "" {
+				c.JSON(status, gin.H{"error": msg})
+				return
+			}
+		}
+
+		respondWithAdjustment(ctx, c, store, adjustment.Adjustment_id)
+	}
+}
+
+// prepareItemAdjustment checks a void or comp and records the price it reverses.
+func prepareItemAdjustment(ctx context.Context, store *repository.Store, adjustment *models.Adjustment) (int, string) {
+	if adjustment.Order_item_id == nil || adjustment.Invoice_id != nil {
+		return http.StatusBadRequest, "a " + *adjustment.Type + " needs an order_item_id and no invoice_id"
+	}
+	adjustment.Refund_method = nil
+
+	orderItem, err := store.OrderItems.FindByID(ctx, *adjustment.Order_item_id)
+	if err == repository.ErrNotFound {
+		return http.StatusNotFound, "order item not found"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the order item"
+	}
+	adjustment.Order_id = orderItem.Order_id
+
+	order, err := store.Orders.FindByID(ctx, orderItem.Order_id)
+	if err != nil && err != repository.ErrNotFound {
+		return http.StatusInternalServerError, "error while fetching the order"
+	}
+	if status := order.CurrentStatus(); status == models.OrderPaid || status == models.OrderCancelled {
+		return http.StatusConflict, "the order is " + status + ", refund the invoice instead"
+	}
+
+	existing, err := store.Adjustments.FindByOrder(ctx, orderItem.Order_id)
+	if err != nil {
+		return http.StatusInternalServerError, "error while listing adjustments"
+	}
+	for _, other := range existing {
+		if other.Order_item_id != nil && *other.Order_item_id == orderItem.Order_item_id && other.Status != models.AdjustmentRejected {
+			return http.StatusConflict, "the order item already has a " + *other.Type + " that is " + other.Status
+		}
+	}
+
+	summaries, err := ItemsByOrder(ctx, store, orderItem.Order_id)
+	if err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+	for _, item := range summaries[0].Order_items {
+		if item.Order_item_id == orderItem.Order_item_id {
+			amount := item.Amount
+			adjustment.Amount = &amount
+		}
+	}
+	return http.StatusOK, ""
+}
+
+// prepareRefund checks that the refund is not more than what was paid and is
+// not already asked for by other pending refunds.
+func prepareRefund(ctx context.Context, store *repository.Store, adjustment *models.Adjustment) (int, string) {
+	if adjustment.Invoice_id == nil || adjustment.Order_item_id != nil {
+		return http.StatusBadRequest, "a REFUND needs an invoice_id and no order_item_id"
+	}
+	if adjustment.Refund_method == nil {
+		return http.StatusBadRequest, "a REFUND needs a refund_method"
+	}
+
+	invoice, err := store.Invoices.FindByID(ctx, *adjustment.Invoice_id)
+	if err == repository.ErrNotFound {
+		return http.StatusNotFound, "invoice not found"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the invoice"
+	}
+	adjustment.Order_id = invoice.Order_id
+
+	existing, err := store.Adjustments.FindByOrder(ctx, invoice.Order_id)
+	if err != nil {
+		return http.StatusInternalServerError, "error while listing adjustments"
+	}
+	refundable := invoice.Amount_paid
+	for _, other := range existing {
+		if *other.Type == models.AdjustmentRefund && other.Status == models.AdjustmentPending {
+			refundable -= *other.Amount
+		}
+	}
+	refundable = toFixed(refundable, 2)
+
+	if adjustment.Amount == nil {
+		adjustment.Amount = &refundable
+	}
+	amount := toFixed(*adjustment.Amount, 2)
+	adjustment.Amount = &amount
+	if amount <= 0 || amount > refundable {
+		return http.StatusBadRequest, fmt.Sprintf("at most %.2f of this invoice can be refunded", refundable)
+	}
+	return http.StatusOK, ""
+}
+
+// approveAdjustment approves a pending adjustment. A refund also writes the
+// reversing payment; when that fails the adjustment goes back to pending.
+func approveAdjustment(ctx context.Context, store *repository.Store, adjustment models.Adjustment, userId string) (int, string) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	err := store.Adjustments.Decide(ctx, adjustment.Adjustment_id, models.AdjustmentApproved, userId, now)
+	if err == repository.ErrConflict {
+		return http.StatusConflict, "the adjustment was already decided"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "error while approving the adjustment"
+	}
+	if *adjustment.Type != models.AdjustmentRefund {
+		if err := settleInvoices(ctx, store, adjustment.Order_id, now); err != nil {
+			return http.StatusInternalServerError, "the adjustment was approved but the invoice status was not updated"
+		}
+		return http.StatusOK, ""
+	}
+
+	status, msg := writeRefund(ctx, store, adjustment, userId, now)
+	if msg != "" {
+		store.Adjustments.Update(ctx, adjustment.Adjustment_id, bson.D{
+			{Key: "status", Value: models.AdjustmentPending},
+			{Key: "decided_by", Value: nil},
+			{Key: "decided_at", Value: nil},
+		})
+	}
+	return status, msg
+}
+
+// settleInvoices brings the payment status of the invoices of the order in
+// line with what is due once a void or comp took an item off the bill.
+func settleInvoices(ctx context.Context, store *repository.Store, orderId string, now time.Time) error {
+	invoices, err := store.Invoices.FindByOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	for _, invoice := range invoices {
+		for {
+			current, _, state, err := invoicePayments(ctx, store, invoice.Invoice_id)
+			if err != nil {
+				return err
+			}
+			status := paymentStatus(current.Amount_paid, state.Payment_due)
+			if status == state.Payment_status {
+				break
+			}
+			// a payment recorded in the meantime is read again
+			err = store.Invoices.SetPaymentStatus(ctx, current.Invoice_id, current.Amount_paid, status, now)
+			if err == repository.ErrConflict {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			current.Payment_status = &status
+			current.Updated_at = now
+			Events.Publish(events.TopicInvoices, events.InvoiceUpdated, current)
+			break
+		}
+	}
+	return nil
+}
+
+func writeRefund(ctx context.Context, store *repository.Store, adjustment models.Adjustment, userId string, now time.Time) (int, string) {
+	// the approved refund already comes off the payment due
+	invoice, _, state, err := invoicePayments(ctx, store, *adjustment.Invoice_id)
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the invoice"
+	}
+	if *adjustment.Amount > toFixed(invoice.Amount_paid, 2) {
+		return http.StatusConflict, fmt.Sprintf("only %.2f was paid on the invoice", invoice.Amount_paid)
+	}
+
+	order, err := store.Orders.FindByID(ctx, invoice.Order_id)
+	if err != nil && err != repository.ErrNotFound {
+		return http.StatusInternalServerError, "error while fetching the order"
+	}
+
+	refund := -*adjustment.Amount
+	payment := models.Payment{
+		ID:            primitive.NewObjectID(),
+		Invoice_id:    invoice.Invoice_id,
+		Method:        adjustment.Refund_method,
+		Amount:        &refund,
+		Server_id:     orderServer(order),
+		Adjustment_id: &adjustment.Adjustment_id,
+		Created_by:    userId,
+		Created_at:    now,
+	}
+	payment.Payment_id = payment.ID.Hex()
+
+	status := paymentStatus(invoice.Amount_paid+refund, state.Payment_due)
+	err = store.Invoices.AddPayment(ctx, payment, invoice.Amount_paid, status)
+	if err == repository.ErrConflict {
+		return http.StatusConflict, "a payment was recorded on the invoice at the same time, please retry"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "refund was not recorded"
+	}
+	Events.Publish(events.TopicInvoices, events.InvoiceRefunded, gin.H{
+		"invoice_id":     invoice.Invoice_id,
+		"order_id":       invoice.Order_id,
+		"payment":        payment,
+		"payment_status": status,
+	})
+	return http.StatusOK, ""
+}
+
+func ApproveAdjustment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		adjustment, err := store.Adjustments.FindByID(ctx, c.Param("adjustment_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "adjustment not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the adjustment"})
+			return
+		}
+
+		if status, msg := approveAdjustment(ctx, store, adjustment, c.GetString("uid")); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		respondWithAdjustment(ctx, c, store, adjustment.Adjustment_id)
+	}
+}
+
+func RejectAdjustment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		adjustmentId := c.Param("adjustment_id")
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := store.Adjustments.Decide(ctx, adjustmentId, models.AdjustmentRejected, c.GetString("uid"), now)
+		if err == repository.ErrConflict {
+			if _, findErr := store.Adjustments.FindByID(ctx, adjustmentId); findErr == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "adjustment not found"})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "the adjustment was already decided"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while rejecting the adjustment"})
+			return
+		}
+		respondWithAdjustment(ctx, c, store, adjustmentId)
+	}
+}
+
+func respondWithAdjustment(ctx context.Context, c *gin.Context, store *repository.Store, adjustmentId string) {
+	adjustment, err := store.Adjustments.FindByID(ctx, adjustmentId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the adjustment"})
+		return
+	}
+	c.JSON(http.StatusOK, adjustment)
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoidAndCompNeedApproval(t *testing.T) {
+	store := repository.NewMemoryStore()
+	server := setupOrderRouter(store, models.RoleServer)
+	manager := setupOrderRouter(store, models.RoleManager)
+
+	steak := createTestFood(t, server, "Steak", "Mains", 25)
+	salad := createTestFood(t, server, "Salad", "Starters", 9)
+	soup := createTestFood(t, server, "Soup", "Starters", 6)
+	orderId := createTestOrder(t, server, createTestTable(t, server, 2, 4), steak, salad, soup)
+
+	w := performRequest(server, "GET", "/orderItems-order/"+orderId, nil)
+	items := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0].Order_items
+
+	// a server can only ask for a void
+	w = performRequest(server, "POST", "/adjustments", gin.H{
+		"type": models.AdjustmentVoid, "order_item_id": items[0].Order_item_id, "reason_code": models.ReasonKitchenError,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	void := decode[models.Adjustment](t, w.Body.Bytes())
+	assert.Equal(t, models.AdjustmentPending, void.Status)
+	assert.Equal(t, 25.0, *void.Amount)
+
+	w = performRequest(server, "GET", "/orderItems-order/"+orderId, nil)
+	assert.Equal(t, 40.0, decode[[]OrderItemsSummary](t, w.Body.Bytes())[0].Payment_due)
+
+	// the same item cannot be adjusted twice
+	w = performRequest(server, "POST", "/adjustments", gin.H{
+		"type": models.AdjustmentComp, "order_item_id": items[0].Order_item_id, "reason_code": models.ReasonQuality,
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(manager, "POST", "/adjustments/"+void.Adjustment_id+"/approve", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	approved := decode[models.Adjustment](t, w.Body.Bytes())
+	assert.Equal(t, models.AdjustmentApproved, approved.Status)
+	assert.Equal(t, "test-user", *approved.Decided_by)
+
+	w = performRequest(manager, "POST", "/adjustments/"+void.Adjustment_id+"/reject", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// a manager's comp is approved straight away
+	w = performRequest(manager, "POST", "/adjustments", gin.H{
+		"type": models.AdjustmentComp, "order_item_id": items[1].Order_item_id, "reason_code": models.ReasonHospitality,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.AdjustmentApproved, decode[models.Adjustment](t, w.Body.Bytes()).Status)
+
+	w = performRequest(server, "GET", "/orderItems-order/"+orderId, nil)
+	summary := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0]
+	assert.Equal(t, 6.0, summary.Payment_due)
+	assert.Equal(t, 34.0, summary.Adjustment_total)
+	assert.Len(t, summary.Adjustments, 2)
+	// the voided and comped items stay on the order
+	assert.Len(t, summary.Order_items, 3)
+
+	w = performRequest(manager, "GET", "/reports/adjustments", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decode[struct {
+		Totals  map[string]float64     `json:"totals"`
+		Reasons []AdjustmentReportLine `json:"reasons"`
+	}](t, w.Body.Bytes())
+	assert.Equal(t, 9.0, report.Totals[models.AdjustmentComp])
+	assert.Equal(t, 25.0, report.Totals[models.AdjustmentVoid])
+	assert.Equal(t, []AdjustmentReportLine{
+		{Type: models.AdjustmentComp, Reason_code: models.ReasonHospitality, Count: 1, Amount: 9},
+		{Type: models.AdjustmentVoid, Reason_code: models.ReasonKitchenError, Count: 1, Amount: 25},
+	}, report.Reasons)
+}
+
+func TestRefundWritesReversingPayment(t *testing.T) {
+	store := repository.NewMemoryStore()
+	cashier := setupOrderRouter(store, models.RoleCashier)
+	manager := setupOrderRouter(store, models.RoleManager)
+
+	steak := createTestFood(t, manager, "Steak", "Mains", 25)
+	orderId := createTestOrder(t, manager, createTestTable(t, manager, 2, 4), steak)
+	w := performRequest(manager, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(cashier, "POST", "/invoices/"+invoiceId+"/payments", gin.H{"method": "CARD"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	refund := gin.H{
+		"type": models.AdjustmentRefund, "invoice_id": invoiceId, "amount": 10,
+		"refund_method": "CARD", "reason_code": models.ReasonLongWait,
+	}
+	w = performRequest(cashier, "POST", "/adjustments", refund)
+	assert.Equal(t, http.StatusOK, w.Code)
+	adjustmentId := decode[models.Adjustment](t, w.Body.Bytes()).Adjustment_id
+
+	// the pending refund holds back what can still be refunded
+	refund["amount"] = 20
+	w = performRequest(cashier, "POST", "/adjustments", refund)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(manager, "POST", "/adjustments/"+adjustmentId+"/approve", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(cashier, "GET", "/invoices/"+invoiceId, nil)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Len(t, view.Payments, 2)
+	// the card payment is left as it was and the refund reverses part of it
+	assert.Equal(t, 25.0, *view.Payments[0].Amount)
+	assert.Equal(t, -10.0, *view.Payments[1].Amount)
+	assert.Equal(t, adjustmentId, *view.Payments[1].Adjustment_id)
+	assert.Equal(t, 15.0, view.Amount_paid)
+	assert.Equal(t, 0.0, view.Balance_due)
+	assert.Equal(t, models.PaymentPaid, *view.Payment_status)
+}
+
+func TestApprovedVoidSettlesInvoice(t *testing.T) {
+	store := repository.NewMemoryStore()
+	manager := setupOrderRouter(store, models.RoleManager)
+
+	steak := createTestFood(t, manager, "Steak", "Mains", 25)
+	salad := createTestFood(t, manager, "Salad", "Starters", 9)
+	orderId := createTestOrder(t, manager, createTestTable(t, manager, 2, 4), steak, salad)
+	w := performRequest(manager, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(manager, "POST", "/invoices/"+invoiceId+"/payments", gin.H{"method": "CARD", "amount": 9})
+	assert.Equal(t, models.PaymentPartiallyPaid, decode[gin.H](t, w.Body.Bytes())["payment_status"])
+
+	// the steak comes off the bill, what was paid already covers the salad
+	w = performRequest(manager, "GET", "/orderItems-order/"+orderId, nil)
+	items := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0].Order_items
+	w = performRequest(manager, "POST", "/adjustments", gin.H{
+		"type": models.AdjustmentVoid, "order_item_id": items[0].Order_item_id, "reason_code": models.ReasonKitchenError,
+	})
+	assert.Equal(t, models.AdjustmentApproved, decode[models.Adjustment](t, w.Body.Bytes()).Status)
+
+	w = performRequest(manager, "GET", "/invoices/"+invoiceId, nil)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, 0.0, view.Balance_due)
+	assert.Equal(t, models.PaymentPaid, *view.Payment_status)
+}
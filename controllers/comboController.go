@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetCombos(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		allCombos, err := store.Combos.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing combos"})
+			return
+		}
+		c.JSON(http.StatusOK, allCombos)
+	}
+}
+
+func GetCombo(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		comboId := c.Param("combo_id")
+
+		combo, err := store.Combos.FindByID(ctx, comboId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "combo not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the combo"})
+			return
+		}
+		c.JSON(http.StatusOK, combo)
+	}
+}
+
+func CreateCombo(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var combo models.Combo
+		if err := c.BindJSON(&combo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if status, msg := prepareCombo(ctx, store, &combo); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		combo.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		combo.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		combo.ID = primitive.NewObjectID()
+		combo.Combo_id = combo.ID.Hex()
+
+		if err := store.Combos.Insert(ctx, combo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "combo was not created"})
+			return
+		}
+		c.JSON(http.StatusOK, combo)
+	}
+}
+
+// UpdateCombo changes the name, price or slots of a combo. Combos already
+// ordered keep the price and names they were ordered with.
+func UpdateCombo(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		comboId := c.Param("combo_id")
+
+		combo, err := store.Combos.FindByID(ctx, comboId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "combo not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the combo"})
+			return
+		}
+
+		// the request is decoded over the stored combo, so absent fields keep their value
+		if err := c.BindJSON(&combo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if status, msg := prepareCombo(ctx, store, &combo); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		combo.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj := primitive.D{
+			{Key: "name", Value: combo.Name},
+			{Key: "price", Value: combo.Price},
+			{Key: "slots", Value: combo.Slots},
+			{Key: "updated_at", Value: combo.Updated_at},
+		}
+
+		err = store.Combos.Update(ctx, comboId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "combo not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "combo update failed"})
+			return
+		}
+
+		updatedCombo, err := store.Combos.FindByID(ctx, comboId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the combo"})
+			return
+		}
+		c.JSON(http.StatusOK, updatedCombo)
+	}
+}
+
+// prepareCombo validates a combo, rounds its price and checks the foods its
+// slots offer exist. On failure it returns the HTTP status and why.
+func prepareCombo(ctx context.Context, store *repository.Store, combo *models.Combo) (int, string) {
+	if validationErr := validate.Struct(combo); validationErr != nil {
+		return http.StatusBadRequest, validationErr.Error()
+	}
+	slots, msg := helpers.PrepareComboSlots(combo.Slots)
+	if msg != "" {
+		return http.StatusBadRequest, msg
+	}
+	combo.Slots = slots
+	price := toFixed(*combo.Price, 2)
+	combo.Price = &price
+
+	var foodIds []string
+	for _, slot := range combo.Slots {
+		foodIds = append(foodIds, slot.Food_ids...)
+	}
+	foodsById, err := foodsByID(ctx, store, foodIds)
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the foods of the combo"
+	}
+	for _, foodId := range foodIds {
+		if _, ok := foodsById[foodId]; !ok {
+			return http.StatusBadRequest, fmt.Sprintf("food %s was not found", foodId)
+		}
+	}
+	return 0, ""
+}
+
+// expandCombo turns an ordered combo into an order item for each of its
+// slots, the form the kitchen cooks it in. The combo price is shared over the
+// items in proportion to their menu prices, so each is taxed by the category
+// of its food, and the modifiers picked are charged on top. On failure it
+// returns the field at fault and why.
+func expandCombo(combo models.Combo, ordered models.ComboOrder, foodsById map[string]models.Food) ([]models.OrderItem, string, string) {
+	choices := map[string]models.ComboChoice{}
+	for _, choice := range ordered.Choices {
+		if _, ok := choices[choice.Slot_id]; ok {
+			return nil, "Choices", fmt.Sprintf("slot %s is picked twice", choice.Slot_id)
+		}
+		choices[choice.Slot_id] = choice
+	}
+	for slotId := range choices {
+		found := false
+		for _, slot := range combo.Slots {
+			found = found || slot.Slot_id == slotId
+		}
+		if !found {
+			return nil, "Choices", fmt.Sprintf("the combo has no slot %s", slotId)
+		}
+	}
+
+	lineId := primitive.NewObjectID().Hex()
+	items := make([]models.OrderItem, 0, len(combo.Slots))
+	weights := make([]float64, 0, len(combo.Slots))
+	for _, slot := range combo.Slots {
+		choice := choices[slot.Slot_id]
+		if choice.Food_id == nil {
+			foodId, fixed := helpers.FixedFood(slot)
+			if !fixed {
+				return nil, "Choices", fmt.Sprintf("pick a food for %q", slot.Name)
+			}
+			choice.Food_id = &foodId
+		}
+		food, found := foodsById[*choice.Food_id]
+		if !found || !helpers.SlotOffers(slot, food) {
+			return nil, "Choices", fmt.Sprintf("food %s is not offered for %q", *choice.Food_id, slot.Name)
+		}
+
+		item := models.OrderItem{
+			Food_id:   choice.Food_id,
+			Quantity:  ordered.Quantity,
+			Variant:   choice.Variant,
+			Modifiers: choice.Modifiers,
+			Combo: &models.OrderItemCombo{
+				Combo_id:   combo.Combo_id,
+				Combo_name: *combo.Name,
+				Line_id:    lineId,
+				Slot_name:  slot.Name,
+			},
+		}
+		if field, msg := priceOrderItem(food, &item); msg != "" {
+			return nil, field, fmt.Sprintf("%q: %s", slot.Name, msg)
+		}
+		items = append(items, item)
+		weights = append(weights, *item.Unit_price-helpers.ModifierTotal(item.Modifiers))
+	}
+
+	for i, share := range helpers.AllocateTotal(*combo.Price, weights) {
+		unitPrice := toFixed(share+helpers.ModifierTotal(items[i].Modifiers), 2)
+		items[i].Unit_price = &unitPrice
+	}
+	return items, "", ""
+}
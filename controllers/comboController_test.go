@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCombo(t *testing.T) {
+	helpers.ConfigureTax(config.TaxConfig{Default: config.TaxRate{Name: "GST", Percent: 10}})
+	defer helpers.ConfigureTax(config.TaxConfig{})
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupOrderRouter(store, models.RoleServer)
+	router.POST("/combos", CreateCombo(store))
+	tableId := createTestTable(t, router, 2, 4)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	fries := createTestFood(t, router, "Fries", "Sides", 4)
+	cola := createTestFood(t, router, "Cola", "Drinks", 3)
+
+	w := performRequest(router, "POST", "/combos", gin.H{"name": "Burger meal", "price": 14, "slots": []gin.H{
+		{"name": "Main", "food_ids": []string{"missing"}},
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the foods of a slot must exist")
+
+	w = performRequest(router, "POST", "/combos", gin.H{"name": "Burger meal", "price": 14, "slots": []gin.H{
+		{"name": "Main", "food_ids": []string{burger}},
+		{"name": "Side", "food_ids": []string{fries}},
+		{"name": "Drink", "category": "drinks"},
+	}})
+	require.Equal(t, http.StatusOK, w.Code)
+	combo := decode[models.Combo](t, w.Body.Bytes())
+	drink := combo.Slots[2].Slot_id
+
+	// the drink is the guest's choice, and only drinks are offered
+	w = performRequest(router, "POST", "/orderItems", gin.H{"table_id": tableId, "combos": []gin.H{
+		{"combo_id": combo.Combo_id, "quantity": 2},
+	}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	detail := decode[struct {
+		Detail []gin.H `json:"detail"`
+	}](t, w.Body.Bytes()).Detail
+	require.Len(t, detail, 1)
+	assert.Equal(t, 0.0, detail[0]["combo_index"])
+	w = performRequest(router, "POST", "/orderItems", gin.H{"table_id": tableId, "combos": []gin.H{
+		{"combo_id": combo.Combo_id, "quantity": 2, "choices": []gin.H{{"slot_id": drink, "food_id": fries}}},
+	}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id":    tableId,
+		"order_items": []gin.H{{"food_id": cola, "quantity": 1}},
+		"combos": []gin.H{
+			{"combo_id": combo.Combo_id, "quantity": 2, "choices": []gin.H{{"slot_id": drink, "food_id": cola}}},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	orderId := decode[gin.H](t, w.Body.Bytes())["order_id"].(string)
+
+	// the combo price is shared over its items by their menu prices
+	w = performRequest(router, "GET", "/orderItems-order/"+orderId, nil)
+	summary := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0]
+	require.Len(t, summary.Order_items, 4)
+	var prices []float64
+	for _, item := range summary.Order_items[1:] {
+		require.NotNil(t, item.Combo)
+		assert.Equal(t, "Burger meal", item.Combo.Combo_name)
+		assert.Equal(t, 2, item.Quantity)
+		prices = append(prices, item.Price)
+	}
+	assert.Equal(t, []float64{8.24, 3.29, 2.47}, prices)
+	assert.Equal(t, 31.0, summary.Items_total)
+	assert.Equal(t, 3.1, summary.Tax_total)
+	assert.Equal(t, 34.1, summary.Payment_due)
+
+	// the kitchen cooks the items of the combo
+	tickets, _ := store.Tickets.FindByOrder(context.Background(), orderId)
+	require.Len(t, tickets, 2)
+	assert.Len(t, tickets[0].Items, 2)
+	assert.Len(t, tickets[1].Items, 2)
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetCoupons(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		allCoupons, err := store.Coupons.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing coupons"})
+			return
+		}
+		c.JSON(http.StatusOK, allCoupons)
+	}
+}
+
+func GetCoupon(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		couponId := c.Param("coupon_id")
+
+		coupon, err := store.Coupons.FindByID(ctx, couponId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the coupon"})
+			return
+		}
+		c.JSON(http.StatusOK, coupon)
+	}
+}
+
+func CreateCoupon(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var coupon models.Coupon
+		if err := c.BindJSON(&coupon); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if coupon.Code != nil {
+			code := normalizeCouponCode(*coupon.Code)
+			coupon.Code = &code
+		}
+
+		if validationErr := validate.Struct(coupon); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if msg := couponRuleError(coupon); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		_, err := store.Coupons.FindByCode(ctx, *coupon.Code)
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "a coupon with this code already exists"})
+			return
+		}
+		if err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while checking the coupon code"})
+			return
+		}
+
+		coupon.Used_count = 0
+		coupon.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		coupon.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		coupon.ID = primitive.NewObjectID()
+		coupon.Coupon_id = coupon.ID.Hex()
+
+		if err := store.Coupons.Insert(ctx, coupon); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon was not created"})
+			return
+		}
+		c.JSON(http.StatusOK, coupon)
+	}
+}
+
+// UpdateCoupon changes the terms of a coupon. The code, type and usage count
+// cannot be changed, a different deal gets a new coupon.
+func UpdateCoupon(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		couponId := c.Param("coupon_id")
+
+		coupon, err := store.Coupons.FindByID(ctx, couponId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the coupon"})
+			return
+		}
+
+		// the request is decoded over the stored coupon, so absent fields keep their value
+		code, couponType := coupon.Code, coupon.Type
+		if err := c.BindJSON(&coupon); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		coupon.Code, coupon.Type = code, couponType
+
+		if validationErr := validate.Struct(coupon); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if msg := couponRuleError(coupon); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		coupon.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj := primitive.D{
+			{Key: "value", Value: coupon.Value},
+			{Key: "category", Value: coupon.Category},
+			{Key: "buy_quantity", Value: coupon.Buy_quantity},
+			{Key: "get_quantity", Value: coupon.Get_quantity},
+			{Key: "min_spend", Value: coupon.Min_spend},
+			{Key: "valid_from", Value: coupon.Valid_from},
+			{Key: "valid_until", Value: coupon.Valid_until},
+			{Key: "max_uses", Value: coupon.Max_uses},
+			{Key: "updated_at", Value: coupon.Updated_at},
+		}
+
+		err = store.Coupons.Update(ctx, couponId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon update failed"})
+			return
+		}
+
+		updatedCoupon, err := store.Coupons.FindByID(ctx, couponId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the coupon"})
+			return
+		}
+		c.JSON(http.StatusOK, updatedCoupon)
+	}
+}
+
+// ApplyCoupon puts a coupon code on an order. An order has at most one coupon,
+// and applying it counts as one use of the coupon.
+func ApplyCoupon(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		orderId := c.Param("order_id")
+
+		var request struct {
+			Code string `json:"code" validate:"required"`
+		}
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		if status := order.CurrentStatus(); status == models.OrderPaid || status == models.OrderCancelled {
+			c.JSON(http.StatusConflict, gin.H{"error": "cannot apply a coupon to an order that is " + status})
+			return
+		}
+		if order.Coupon_id != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "order already has a coupon, remove it first"})
+			return
+		}
+
+		coupon, err := store.Coupons.FindByCode(ctx, normalizeCouponCode(request.Code))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the coupon"})
+			return
+		}
+		if !coupon.ValidAt(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "coupon is not valid at this time"})
+			return
+		}
+		if coupon.UsedUp() {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon usage limit reached"})
+			return
+		}
+
+		summaries, err := ItemsByOrder(ctx, store, orderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// voided and comped items cost nothing, as on the bill
+		var lines []helpers.DiscountableLine
+		var itemsTotal float64
+		if len(summaries) > 0 {
+			for _, item := range summaries[0].Order_items {
+				amount := item.Amount
+				if item.Adjustment != nil {
+					amount = 0
+				}
+				lines = append(lines, helpers.DiscountableLine{Category: item.Category, Amount: amount, Quantity: item.Quantity})
+				itemsTotal += amount
+			}
+		}
+		if coupon.Min_spend != nil && toFixed(itemsTotal, 2) < *coupon.Min_spend {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("coupon needs a minimum spend of %.2f", *coupon.Min_spend)})
+			return
+		}
+		if _, discount := helpers.CalculateDiscount(coupon, lines); discount.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "coupon does not apply to any item of the order"})
+			return
+		}
+
+		err = store.Orders.SetCoupon(ctx, orderId, nil, &coupon.Coupon_id)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "order already has a coupon, remove it first"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon was not applied"})
+			return
+		}
+
+		if err := store.Coupons.Redeem(ctx, coupon.Coupon_id); err != nil {
+			store.Orders.SetCoupon(ctx, orderId, &coupon.Coupon_id, nil)
+			if err == repository.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "coupon usage limit reached"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon was not applied"})
+			return
+		}
+
+		respondWithOrderSummary(ctx, c, store, orderId)
+	}
+}
+
+// RemoveCoupon takes the coupon off an order and gives its use back.
+func RemoveCoupon(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		orderId := c.Param("order_id")
+
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		if order.Coupon_id == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order has no coupon"})
+			return
+		}
+		if order.CurrentStatus() == models.OrderPaid {
+			c.JSON(http.StatusConflict, gin.H{"error": "cannot remove the coupon of a paid order"})
+			return
+		}
+
+		err = store.Orders.SetCoupon(ctx, orderId, order.Coupon_id, nil)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the coupon of the order was changed, try again"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon was not removed"})
+			return
+		}
+		if err := store.Coupons.Release(ctx, *order.Coupon_id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "coupon use was not given back"})
+			return
+		}
+
+		respondWithOrderSummary(ctx, c, store, orderId)
+	}
+}
+
+func respondWithOrderSummary(ctx context.Context, c *gin.Context, store *repository.Store, orderId string) {
+	summaries, err := ItemsByOrder(ctx, store, orderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(summaries) == 0 {
+		c.JSON(http.StatusOK, OrderItemsSummary{Discounts: []helpers.DiscountLine{}, Taxes: []helpers.TaxLine{}})
+		return
+	}
+	c.JSON(http.StatusOK, summaries[0])
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponRuleError checks what the validate tags cannot: the fields each coupon
+// type needs and the validity window.
+func couponRuleError(coupon models.Coupon) string {
+	switch *coupon.Type {
+	case models.CouponPercentage:
+		if coupon.Value == nil || *coupon.Value > 100 {
+			return "a PERCENTAGE coupon needs a value between 0 and 100"
+		}
+	case models.CouponFixed:
+		if coupon.Value == nil {
+			return "a FIXED coupon needs a value"
+		}
+	case models.CouponBuyXGetY:
+		if coupon.Buy_quantity == nil || coupon.Get_quantity == nil {
+			return "a BUY_X_GET_Y coupon needs buy_quantity and get_quantity"
+		}
+		if coupon.Value != nil && *coupon.Value > 100 {
+			return "the value of a BUY_X_GET_Y coupon is a percentage between 0 and 100"
+		}
+	}
+	if coupon.Valid_from != nil && coupon.Valid_until != nil && !coupon.Valid_until.After(*coupon.Valid_from) {
+		return "valid_until must be after valid_from"
+	}
+	return ""
+}
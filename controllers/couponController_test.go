@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCoupon(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 12)
+	cola := createTestFood(t, router, "Cola", "Drinks", 4)
+	tableId := createTestTable(t, router, 2, 5)
+
+	w := performRequest(router, "POST", "/coupons", gin.H{
+		"code":        " happyhour ",
+		"type":        models.CouponPercentage,
+		"value":       50,
+		"category":    "drinks",
+		"min_spend":   10,
+		"max_uses":    1,
+		"valid_until": time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	couponId := decode[models.Coupon](t, w.Body.Bytes()).Coupon_id
+
+	w = performRequest(router, "POST", "/coupons", gin.H{"code": "HAPPYHOUR", "type": models.CouponFixed, "value": 1})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// below the minimum spend
+	small := createTestOrder(t, router, tableId, cola)
+	w = performRequest(router, "POST", "/orders/"+small+"/coupon", gin.H{"code": "HAPPYHOUR"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	orderId := createTestOrder(t, router, tableId, burger, cola, cola)
+	w = performRequest(router, "POST", "/orders/"+orderId+"/coupon", gin.H{"code": "happyhour"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	summary := decode[OrderItemsSummary](t, w.Body.Bytes())
+	assert.Equal(t, 20.0, summary.Items_total)
+	assert.Equal(t, 4.0, summary.Discount_total)
+	assert.Equal(t, "50% off drinks", summary.Discounts[0].Description)
+	assert.Equal(t, 16.0, summary.Payment_due)
+
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, 4.0, view.Discount_total)
+	assert.Equal(t, 16.0, view.Payment_due)
+
+	// the only use is taken
+	other := createTestOrder(t, router, tableId, burger, cola)
+	w = performRequest(router, "POST", "/orders/"+other+"/coupon", gin.H{"code": "HAPPYHOUR"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// removing the coupon gives the use back
+	w = performRequest(router, "DELETE", "/orders/"+orderId+"/coupon", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 20.0, decode[OrderItemsSummary](t, w.Body.Bytes()).Payment_due)
+
+	coupon, _ := store.Coupons.FindByID(context.Background(), couponId)
+	assert.Equal(t, 0, coupon.Used_count)
+
+	w = performRequest(router, "POST", "/orders/"+other+"/coupon", gin.H{"code": "HAPPYHOUR"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// a voided item does not count towards the minimum spend
+	w = performRequest(router, "POST", "/coupons", gin.H{"code": "TENOFF", "type": models.CouponPercentage, "value": 10, "min_spend": 10})
+	assert.Equal(t, http.StatusOK, w.Code)
+	voided := createTestOrder(t, router, tableId, burger, cola)
+	w = performRequest(router, "GET", "/orderItems-order/"+voided, nil)
+	items := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0].Order_items
+	w = performRequest(router, "POST", "/adjustments", gin.H{
+		"type": models.AdjustmentVoid, "order_item_id": items[0].Order_item_id, "reason_code": models.ReasonKitchenError,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(router, "POST", "/orders/"+voided+"/coupon", gin.H{"code": "TENOFF"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
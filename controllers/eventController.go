@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+)
+
+// Events carries what happens in the service to the live streams
+var Events = events.NewBroker()
+
+// heartbeatInterval keeps idle streams from being closed by proxies
+var heartbeatInterval = 15 * time.Second
+
+// StreamEvents sends the events of ?topics= (comma separated, every topic when
+// empty) as server-sent events. A client reconnecting with the Last-Event-ID
+// header, or ?last_event_id= where it cannot set headers, first gets the
+// events it missed. When some of those are no longer kept it gets a
+// stream.reset event instead and should load the current state again.
+func StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var topics []string
+		if query := c.Query("topics"); query != "" {
+			topics = strings.Split(query, ",")
+		}
+		for _, topic := range topics {
+			if !events.KnownTopic(topic) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown topic %q, use one of %s", topic, strings.Join(events.Topics, ", "))})
+				return
+			}
+		}
+
+		lastEventId := c.GetHeader("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = c.Query("last_event_id")
+		}
+		if lastEventId == "" {
+			streamEvents(c, Events.Subscribe(topics...), nil)
+			return
+		}
+
+		lastID, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last event id must be a number"})
+			return
+		}
+		sub, missed, complete := Events.SubscribeSince(lastID, topics...)
+		if !complete {
+			// the state loaded after a reset already has the missed events
+			missed = []events.Event{{Type: events.StreamReset, Time: time.Now().UTC()}}
+		}
+		streamEvents(c, sub, nil, missed...)
+	}
+}
+
+// streamEvents sends first and then the events of sub that keep accepts to the
+// client as server-sent events, until the client goes away or falls behind.
+func streamEvents(c *gin.Context, sub *events.Subscription, keep func(events.Event) bool, first ...events.Event) {
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	// the stream outlives the write timeout of the server
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	for _, event := range first {
+		if writeEvent(c, event) != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if keep != nil && !keep(event) {
+				continue
+			}
+			if writeEvent(c, event) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent writes one server-sent event. Events without an id, such as
+// snapshots, are not given one so that clients keep their last event id.
+func writeEvent(c *gin.Context, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
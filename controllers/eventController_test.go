@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the next server-sent event, skipping heartbeats.
+func readEvent(t *testing.T, stream *bufio.Reader) (string, events.Event) {
+	var eventType string
+	var event events.Event
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+		case line == "" && eventType != "":
+			return eventType, event
+		}
+	}
+}
+
+func openStream(t *testing.T, url string, lastEventId string) *bufio.Reader {
+	req, _ := http.NewRequest("GET", url, nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStreamEvents(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+	router.GET("/events", StreamEvents())
+	server := httptest.NewServer(router)
+	// registered first so the streams are closed before the server waits for them
+	t.Cleanup(server.Close)
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	tableId := createTestTable(t, router, 2, 4)
+
+	w := performRequest(router, "GET", "/events?topics=orders,menus", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	stream := openStream(t, server.URL+"/events?topics=orders,tables", "")
+	orderId := createTestOrder(t, router, tableId, steak)
+
+	eventType, created := readEvent(t, stream)
+	assert.Equal(t, events.OrderCreated, eventType)
+	assert.Equal(t, events.TopicOrders, created.Topic)
+	assert.Equal(t, orderId, created.Data.(map[string]interface{})["order_id"])
+
+	eventType, seated := readEvent(t, stream)
+	assert.Equal(t, events.TableSeated, eventType)
+	assert.Equal(t, tableId, seated.Data.(map[string]interface{})["table_id"])
+
+	eventType, _ = readEvent(t, stream)
+	assert.Equal(t, events.OrderItemAdded, eventType)
+
+	w = performRequest(router, "POST", "/orders/"+orderId+"/transition", map[string]string{"status": models.OrderInKitchen})
+	require.Equal(t, http.StatusOK, w.Code)
+	eventType, changed := readEvent(t, stream)
+	assert.Equal(t, events.OrderStatusChanged, eventType)
+	assert.Equal(t, models.OrderInKitchen, changed.Data.(map[string]interface{})["to"])
+
+	// a client that dropped after the order was created catches up
+	resumed := openStream(t, server.URL+"/events?topics=tables", strconv.FormatUint(created.ID, 10))
+	_, event := readEvent(t, resumed)
+	assert.Equal(t, seated.ID, event.ID)
+
+	// ids from before a restart cannot be resumed
+	reset := openStream(t, server.URL+"/events", strconv.FormatUint(changed.ID+1000, 10))
+	eventType, _ = readEvent(t, reset)
+	assert.Equal(t, events.StreamReset, eventType)
+}
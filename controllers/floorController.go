@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// FloorTable is a table on the floor plan with the next reservation it is
+// held for today, if any.
+type FloorTable struct {
+	models.Table
+	Next_reservation *models.Reservation `json:"next_reservation"`
+}
+
+// FloorSection is the tables of one section, by table number. Tables without
+// a section are in the section with no name.
+type FloorSection struct {
+	Name   string       `json:"name"`
+	Tables []FloorTable `json:"tables"`
+}
+
+// FloorPlan is the whole floor with the number of tables in each status.
+type FloorPlan struct {
+	Sections []FloorSection `json:"sections"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// GetFloor returns the layout of the floor with the live status of every
+// table. ?section= limits it to one section.
+func GetFloor(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		tables, err := store.Tables.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching tables"})
+			return
+		}
+		upcoming, err := upcomingReservations(ctx, store)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching reservations"})
+			return
+		}
+
+		plan := FloorPlan{Sections: []FloorSection{}, Counts: map[string]int{
+			models.TableFree: 0, models.TableSeated: 0, models.TableOrdered: 0, models.TableAwaitingPayment: 0,
+		}}
+		index := map[string]int{}
+		for _, table := range tables {
+			section := ""
+			if table.Section != nil {
+				section = *table.Section
+			}
+			if wanted := c.Query("section"); wanted != "" && section != wanted {
+				continue
+			}
+			table.Status = table.CurrentStatus()
+			plan.Counts[table.Status]++
+
+			i, ok := index[section]
+			if !ok {
+				i = len(plan.Sections)
+				index[section] = i
+				plan.Sections = append(plan.Sections, FloorSection{Name: section})
+			}
+			floorTable := FloorTable{Table: table}
+			if reservation, ok := upcoming[table.Table_id]; ok {
+				floorTable.Next_reservation = &reservation
+			}
+			plan.Sections[i].Tables = append(plan.Sections[i].Tables, floorTable)
+		}
+
+		sort.SliceStable(plan.Sections, func(i, j int) bool {
+			// the tables without a section go last
+			if plan.Sections[i].Name == "" || plan.Sections[j].Name == "" {
+				return plan.Sections[j].Name == "" && plan.Sections[i].Name != ""
+			}
+			return plan.Sections[i].Name < plan.Sections[j].Name
+		})
+		for _, section := range plan.Sections {
+			sort.SliceStable(section.Tables, func(i, j int) bool {
+				return tableNumber(section.Tables[i].Table) < tableNumber(section.Tables[j].Table)
+			})
+		}
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+// upcomingReservations returns, by table, the first booking still to arrive
+// between now and the end of the day.
+func upcomingReservations(ctx context.Context, store *repository.Store) (map[string]models.Reservation, error) {
+	now := time.Now()
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	holding, err := store.Reservations.Holding(ctx, now, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := map[string]models.Reservation{}
+	for _, reservation := range holding {
+		if reservation.Status != models.ReservationBooked || reservation.Table_id == nil {
+			continue
+		}
+		if first, ok := upcoming[*reservation.Table_id]; !ok || reservation.Starts_at.Before(*first.Starts_at) {
+			upcoming[*reservation.Table_id] = reservation
+		}
+	}
+	return upcoming, nil
+}
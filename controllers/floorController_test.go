@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/floor"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloorPlan(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+	router.PATCH("/tables/:table_id", UpdateTable(store))
+	router.GET("/floor", GetFloor(store))
+	tracker := floor.NewTracker(store, Events)
+	t.Cleanup(tracker.Close)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	w := performRequest(router, "POST", "/tables", gin.H{
+		"number_of_guests": 4, "table_number": 2, "section": "Patio", "shape": "ROUND", "position_x": 10, "position_y": 20,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	patio := decode[models.Table](t, w.Body.Bytes())
+	assert.Equal(t, models.TableFree, patio.Status)
+	inside := createTestTable(t, router, 2, 1)
+
+	w = performRequest(router, "POST", "/tables", gin.H{"number_of_guests": 4, "table_number": 3, "shape": "TRIANGLE"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "PATCH", "/tables/"+inside, gin.H{"min_capacity": 3})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a table for two cannot be kept for three or more")
+	w = performRequest(router, "PATCH", "/tables/"+inside, gin.H{"max_capacity": 3, "section": "Bar"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, decode[models.Table](t, w.Body.Bytes()).MaxParty())
+
+	createTestOrder(t, router, patio.Table_id, burger)
+
+	var plan FloorPlan
+	assert.Eventually(t, func() bool {
+		w = performRequest(router, "GET", "/floor", nil)
+		plan = decode[FloorPlan](t, w.Body.Bytes())
+		return plan.Counts[models.TableOrdered] == 1
+	}, time.Second, 5*time.Millisecond)
+
+	require.Len(t, plan.Sections, 2)
+	assert.Equal(t, "Bar", plan.Sections[0].Name)
+	assert.Equal(t, models.TableFree, plan.Sections[0].Tables[0].Status)
+	assert.Equal(t, "Patio", plan.Sections[1].Name)
+	table := plan.Sections[1].Tables[0]
+	assert.Equal(t, models.TableOrdered, table.Status)
+	assert.NotNil(t, table.Order_id)
+	assert.Equal(t, 10.0, *table.Position_x)
+	assert.Equal(t, models.TableRound, *table.Shape)
+	assert.Equal(t, 1, plan.Counts[models.TableFree])
+
+	w = performRequest(router, "GET", "/floor?section=Bar", nil)
+	assert.Len(t, decode[FloorPlan](t, w.Body.Bytes()).Sections, 1)
+}
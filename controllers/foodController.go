@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"math"
 	"net/http"
 	"strconv"
@@ -10,27 +9,28 @@ import (
 
 	"github.com/gin-gonic/gin"               // Gin framework for handling HTTP requests
 	"github.com/go-playground/validator/v10" // Input validation package
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson" // BSON format for MongoDB interactions
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
 var validate = validator.New()
 
+// QueryTimeout bounds the database work of one request, main sets it from the config
+var QueryTimeout = 100 * time.Second
+
 // GetFoods returns a function (gin.HandlerFunc) to be used as a route handler in Gin.
 // c *gin.Context provides the HTTP request context, allowing access to query params,
 // response writing, etc.
 
-func GetFoods() gin.HandlerFunc {
+func GetFoods(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		// Creates a context with a timeout of 100 seconds to prevent MongoDB operations from
+		// Creates a context with a timeout of QueryTimeout to prevent MongoDB operations from
 		// running indefinitely.
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		// Get recordPerPage from query, default to 10
@@ -54,46 +54,19 @@ func GetFoods() gin.HandlerFunc {
 			startIndex, _ = strconv.Atoi(queryStartIndex)
 		}
 
-		// Aggregation pipeline
-		matchStage := bson.D{{Key: "$match", Value: bson.D{}}}
-
-		groupStage := bson.D{
-			{Key: "$group", Value: bson.D{
-				{Key: "_id", Value: "null"},
-				{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},
-				{Key: "data", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
-			}},
-		}
-
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "food_items", Value: bson.D{
-					{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}},
-				}},
-			}},
-		}
-
-		// Execute the aggregation pipeline
-		result, err := foodCollection.Aggregate(
-			ctx, mongo.Pipeline{
-				matchStage, groupStage, projectStage,
-			})
+		// Fetch one page of food items and the total count
+		foods, total, err := store.Foods.List(ctx, startIndex, recordPerPage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing items"})
 			return
 		}
 
-		// Decode results into a slice
-		var allFood []bson.M
-		if err = result.All(ctx, &allFood); err != nil {
-			log.Fatal(err)
-		}
-
 		// Return response
-		if len(allFood) > 0 {
-			c.JSON(http.StatusOK, allFood[0])
+		if total > 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"total_count": total,
+				"food_items":  foods,
+			})
 		} else {
 			c.JSON(http.StatusOK, gin.H{"message": "No food items found"})
 		}
@@ -102,22 +75,25 @@ func GetFoods() gin.HandlerFunc {
 
 // returns food
 
-func GetFood() gin.HandlerFunc {
+func GetFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		// Creates a context.Context with a 100-second timeout.
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
 
 		// Retrieves food_id from the URL path
 		foodId := c.Param("food_id")
 
-		var food models.Food
-
-		// mongo query which searches for the document where food_id matches the given foodId
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
-		defer cancel()
+		// searches for the food item where food_id matches the given foodId
+		food, err := store.Foods.FindByID(ctx, foodId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "food item not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the food item"})
+			return
 		}
 
 		// Sending the Food Item as JSON Response
@@ -125,9 +101,9 @@ func GetFood() gin.HandlerFunc {
 	}
 }
 
-func CreateFood() gin.HandlerFunc {
+func CreateFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var foods []models.Food
@@ -144,13 +120,24 @@ func CreateFood() gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
 				return
 			}
+			groups, msg := helpers.PrepareModifierGroups(foods[i].Modifier_groups)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			foods[i].Modifier_groups = groups
+			variants, msg := helpers.PrepareVariants(foods[i].Variants)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			foods[i].Variants = variants
 
 			// ✅ If `menu_id` is missing or invalid, fetch from category
 			if foods[i].Menu_id == nil || *foods[i].Menu_id == "" {
-				var existingMenu models.Menu
 
 				// ✅ Try to find an existing menu by category
-				err := menuCollections.FindOne(ctx, bson.M{"category": foods[i].Category}).Decode(&existingMenu)
+				existingMenu, err := store.Menus.FindByCategory(ctx, foods[i].Category)
 
 				if err == nil {
 					// ✅ Found existing menu, assign `menu_id`
@@ -166,7 +153,7 @@ func CreateFood() gin.HandlerFunc {
 						Updated_at: time.Now(),
 					}
 
-					_, err := menuCollections.InsertOne(ctx, newMenu)
+					err := store.Menus.Insert(ctx, newMenu)
 					if err != nil {
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create menu for category: " + foods[i].Category})
 						return
@@ -187,19 +174,21 @@ func CreateFood() gin.HandlerFunc {
 			var num = toFixed(*foods[i].Price, 2)
 			foods[i].Price = &num
 
-			// ✅ Insert food item into MongoDB
-			_, insertErr := foodCollection.InsertOne(ctx, foods[i])
+			// ✅ Insert food item
+			insertErr := store.Foods.Insert(ctx, foods[i])
 			if insertErr != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error inserting food: " + *foods[i].Name})
 				return
 			}
 
 			insertedFoods = append(insertedFoods, gin.H{
-				"name":      foods[i].Name,
-				"food_id":   foods[i].Food_id,
-				"menu_id":   foods[i].Menu_id,
-				"price":     foods[i].Price,
-				"image_url": foods[i].Food_image,
+				"name":            foods[i].Name,
+				"food_id":         foods[i].Food_id,
+				"menu_id":         foods[i].Menu_id,
+				"price":           foods[i].Price,
+				"image_url":       foods[i].Food_image,
+				"modifier_groups": foods[i].Modifier_groups,
+				"variants":        foods[i].Variants,
 			})
 		}
 
@@ -221,18 +210,25 @@ func toFixed(num float64, precision int) float64 {
 
 }
 
-func UpdateFood() gin.HandlerFunc {
+func UpdateFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
-		var menu models.Menu
 		var food models.Food
 
 		foodId := c.Param("food_id")
 
 		if err := c.BindJSON(&food); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// PATCH /foods, kept for older clients, names the food in the body
+		if foodId == "" {
+			foodId = food.Food_id
+		}
+		if foodId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "food_id is required"})
 			return
 		}
 
@@ -243,7 +239,8 @@ func UpdateFood() gin.HandlerFunc {
 		}
 
 		if food.Price != nil {
-			updateObj = append(updateObj, bson.E{Key: "price", Value: food.Price})
+			var num = toFixed(*food.Price, 2)
+			updateObj = append(updateObj, bson.E{Key: "price", Value: num})
 		}
 
 		if food.Food_image != nil {
@@ -251,42 +248,64 @@ func UpdateFood() gin.HandlerFunc {
 		}
 
 		if food.Menu_id != nil {
-			err := menuCollections.FindOne(ctx, bson.M{"menu_id": food.Menu_id}).Decode(&menu)
-			defer cancel()
+			_, err := store.Menus.FindByID(ctx, *food.Menu_id)
 			if err != nil {
 				msg := "message: Menu not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+				c.JSON(http.StatusNotFound, gin.H{"error": msg})
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "menu", Value: food.Price})
+			updateObj = append(updateObj, bson.E{Key: "menu_id", Value: food.Menu_id})
 		}
 
-		food.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: food.Updated_at})
-
-		upsert := true
-		filter := bson.M{"food_id": foodId}
+		// the groups are replaced as a whole, an empty list removes them
+		if food.Modifier_groups != nil {
+			if validationErr := validate.Var(food.Modifier_groups, "dive"); validationErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+				return
+			}
+			groups, msg := helpers.PrepareModifierGroups(food.Modifier_groups)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "modifier_groups", Value: groups})
+		}
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		// so are the variants
+		if food.Variants != nil {
+			if validationErr := validate.Var(food.Variants, "dive"); validationErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+				return
+			}
+			variants, msg := helpers.PrepareVariants(food.Variants)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "variants", Value: variants})
 		}
 
-		result, err := foodCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		food.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: food.Updated_at})
 
+		err := store.Foods.Update(ctx, foodId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "food item not found"})
+			return
+		}
 		if err != nil {
 			msg := "error while updating food"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		updatedFood, err := store.Foods.FindByID(ctx, foodId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the food item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedFood)
 
 	}
 }
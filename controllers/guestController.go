@@ -0,0 +1,688 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GuestMenu is a menu with its foods as guests see it.
+type GuestMenu struct {
+	Menu  models.Menu   `json:"menu"`
+	Foods []models.Food `json:"foods"`
+}
+
+// GuestOrderLine is an item of a guest order with the food it is for. Price
+// is the price of one item in its variant with its modifiers, and Amount the
+// price of Quantity items.
+type GuestOrderLine struct {
+	models.GuestOrderItem
+	Food_name *string `json:"food_name"`
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+}
+
+// GuestOrderView is a guest order with the name and price of its items.
+// Items_total is what the items cost at the current menu prices.
+type GuestOrderView struct {
+	models.GuestOrder
+	Items       []GuestOrderLine `json:"items"`
+	Items_total float64          `json:"items_total"`
+}
+
+// CreateGuestSession starts a guest session from the link of a table code.
+// The guest token it returns only opens the guest routes, for that table.
+func CreateGuestSession(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var body struct {
+			Table_id string `json:"table_id" validate:"required"`
+			Sig      string `json:"sig" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if !helpers.ValidTableSignature(body.Table_id, body.Sig) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "the link of the table is not valid"})
+			return
+		}
+
+		table, err := store.Tables.FindByID(ctx, body.Table_id)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+			return
+		}
+
+		token, sessionId, expiresAt, err := helpers.GenerateGuestToken(table.Table_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while starting the guest session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"token":        token,
+			"session_id":   sessionId,
+			"table_id":     table.Table_id,
+			"table_number": table.Table_number,
+			"expires_at":   expiresAt,
+		})
+	}
+}
+
+// GetGuestMenu lists the menus with their foods.
+func GetGuestMenu(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		menus, err := store.Menus.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the menu"})
+			return
+		}
+
+		// guests see the menus served now
+		now := time.Now()
+		guestMenus := make([]GuestMenu, 0, len(menus))
+		for _, menu := range menus {
+			if !helpers.MenuActiveAt(menu, now) {
+				continue
+			}
+			foods, err := store.Foods.FindByMenu(ctx, menu.Menu_id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching food items"})
+				return
+			}
+			guestMenus = append(guestMenus, GuestMenu{Menu: menu, Foods: foods})
+		}
+		c.JSON(http.StatusOK, guestMenus)
+	}
+}
+
+// GetGuestCart returns the cart of the guest session, empty until the guest
+// adds an item.
+func GetGuestCart(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		cart, _, err := guestCart(ctx, store, c.GetString("table_id"), c.GetString("guest_session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the cart"})
+			return
+		}
+		respondGuestOrder(ctx, c, store, cart)
+	}
+}
+
+// AddGuestCartItem puts a food in the cart of the guest session.
+func AddGuestCartItem(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var item models.GuestOrderItem
+		if err := c.BindJSON(&item); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(item); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		food, err := store.Foods.FindByID(ctx, *item.Food_id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "food was not found"})
+			return
+		}
+		served, err := menusServedAt(ctx, store, map[string]models.Food{food.Food_id: food}, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the menu of the food"})
+			return
+		}
+		if !foodServed(served, food) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": menuNotServed})
+			return
+		}
+
+		// the item is checked as it will be ordered
+		orderItem := guestOrderItem(item)
+		if _, msg := priceOrderItem(food, &orderItem); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		item.Variant = orderItem.Variant
+		item.Modifiers = orderItem.Modifiers
+		item.Item_id = primitive.NewObjectID().Hex()
+
+		cart, saved, err := guestCart(ctx, store, c.GetString("table_id"), c.GetString("guest_session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the cart"})
+			return
+		}
+		cart.Items = append(cart.Items, item)
+
+		if !saved {
+			err = store.GuestOrders.Insert(ctx, cart)
+		} else {
+			err = updateGuestCart(ctx, store, &cart)
+		}
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the cart was submitted in the meantime"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the item was not added to the cart"})
+			return
+		}
+		respondGuestOrder(ctx, c, store, cart)
+	}
+}
+
+// RemoveGuestCartItem takes an item out of the cart of the guest session.
+func RemoveGuestCartItem(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		itemId := c.Param("item_id")
+
+		cart, _, err := guestCart(ctx, store, c.GetString("table_id"), c.GetString("guest_session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the cart"})
+			return
+		}
+		kept := []models.GuestOrderItem{}
+		for _, item := range cart.Items {
+			if item.Item_id != itemId {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) == len(cart.Items) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "the item is not in the cart"})
+			return
+		}
+		cart.Items = kept
+
+		err = updateGuestCart(ctx, store, &cart)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the cart was submitted in the meantime"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the item was not removed from the cart"})
+			return
+		}
+		respondGuestOrder(ctx, c, store, cart)
+	}
+}
+
+// SubmitGuestCart sends the cart of the guest session to the staff. Nothing
+// reaches the kitchen until they confirm it, and the guest starts a new cart.
+func SubmitGuestCart(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var body struct {
+			Note *string `json:"note" validate:"omitempty,max=500"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		cart, _, err := guestCart(ctx, store, c.GetString("table_id"), c.GetString("guest_session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the cart"})
+			return
+		}
+		if len(cart.Items) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the cart is empty"})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = store.GuestOrders.UpdateStatus(ctx, cart.Guest_order_id, models.GuestOrderCart, bson.D{
+			{Key: "status", Value: models.GuestOrderSubmitted},
+			{Key: "note", Value: body.Note},
+			{Key: "submitted_at", Value: now},
+			{Key: "updated_at", Value: now},
+		})
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the cart was submitted in the meantime"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the cart was not submitted"})
+			return
+		}
+
+		submitted, err := store.GuestOrders.FindByID(ctx, cart.Guest_order_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the guest order"})
+			return
+		}
+		Events.Publish(events.TopicOrders, events.GuestOrderSubmitted, submitted)
+		respondGuestOrder(ctx, c, store, submitted)
+	}
+}
+
+// GetGuestOrders lists what the guest session submitted and how the staff
+// answered.
+func GetGuestOrders(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		guestOrders, err := store.GuestOrders.FindBySession(ctx, c.GetString("guest_session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the guest orders"})
+			return
+		}
+		var submitted []models.GuestOrder
+		for _, guestOrder := range guestOrders {
+			if guestOrder.Status != models.GuestOrderCart {
+				submitted = append(submitted, guestOrder)
+			}
+		}
+
+		views, err := guestOrderViews(ctx, store, submitted)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the foods of the guest orders"})
+			return
+		}
+		c.JSON(http.StatusOK, views)
+	}
+}
+
+// GetTableQRCode draws the code guests scan to order at the table, as a PNG
+// or, with format=svg, an SVG. scale is the size of a module in pixels.
+func GetTableQRCode(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		format := c.DefaultQuery("format", "png")
+		if format != "png" && format != "svg" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or svg"})
+			return
+		}
+		scale, err := strconv.Atoi(c.DefaultQuery("scale", "8"))
+		if err != nil || scale < 1 || scale > 40 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scale must be a number from 1 to 40"})
+			return
+		}
+
+		table, link, status, msg := guestLink(ctx, store, c.Param("table_id"))
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		code, err := helpers.EncodeQR(link)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the ordering link does not fit a QR code, shorten guest.order_url"})
+			return
+		}
+
+		c.Header("Content-Disposition", "inline; filename=\"table-"+table.Table_id+"."+format+"\"")
+		if format == "svg" {
+			c.Data(http.StatusOK, "image/svg+xml", []byte(code.SVG(scale)))
+			return
+		}
+		image, err := code.PNG(scale)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while drawing the QR code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", image)
+	}
+}
+
+// GetTableGuestLink returns the signed ordering link of the table, the one
+// its code holds.
+func GetTableGuestLink(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		table, link, status, msg := guestLink(ctx, store, c.Param("table_id"))
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"table_id": table.Table_id,
+			"url":      link,
+			"sig":      helpers.TableSignature(table.Table_id),
+		})
+	}
+}
+
+// GetGuestOrderQueue lists the guest orders having status, the submitted ones
+// waiting for the staff by default, oldest first.
+func GetGuestOrderQueue(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		status := c.DefaultQuery("status", models.GuestOrderSubmitted)
+		switch status {
+		case models.GuestOrderSubmitted, models.GuestOrderConfirmed, models.GuestOrderRejected:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be SUBMITTED, CONFIRMED or REJECTED"})
+			return
+		}
+
+		guestOrders, err := store.GuestOrders.List(ctx, []string{status})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the guest orders"})
+			return
+		}
+		views, err := guestOrderViews(ctx, store, guestOrders)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the foods of the guest orders"})
+			return
+		}
+		c.JSON(http.StatusOK, views)
+	}
+}
+
+// ConfirmGuestOrder turns a submitted guest order into an order for its table,
+// through the same path as CreateOrderItem, and so sends it to the kitchen.
+// The staff member confirming it looks after the order.
+func ConfirmGuestOrder(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		guestOrderId := c.Param("guest_order_id")
+		userId := c.GetString("uid")
+
+		guestOrder, status, msg := submittedGuestOrder(ctx, store, guestOrderId)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		// claim the guest order first, so it is confirmed at most once
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := store.GuestOrders.UpdateStatus(ctx, guestOrderId, models.GuestOrderSubmitted, bson.D{
+			{Key: "status", Value: models.GuestOrderConfirmed},
+			{Key: "reviewed_by", Value: userId},
+			{Key: "reviewed_at", Value: now},
+			{Key: "updated_at", Value: now},
+		})
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the guest order was reviewed by someone else"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while confirming the guest order"})
+			return
+		}
+
+		order, status, errBody := confirmedOrder(ctx, store, guestOrder, userId)
+		if errBody != nil {
+			revert := bson.D{
+				{Key: "status", Value: models.GuestOrderSubmitted},
+				{Key: "reviewed_by", Value: nil},
+				{Key: "reviewed_at", Value: nil},
+			}
+			if err := store.GuestOrders.UpdateStatus(ctx, guestOrderId, models.GuestOrderConfirmed, revert); err != nil {
+				errBody["detail"] = "the guest order could not be put back in the queue"
+			}
+			c.JSON(status, errBody)
+			return
+		}
+
+		err = store.GuestOrders.UpdateStatus(ctx, guestOrderId, models.GuestOrderConfirmed, bson.D{{Key: "order_id", Value: order.Order_id}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the order " + order.Order_id + " was created but not linked to the guest order"})
+			return
+		}
+
+		confirmed, err := store.GuestOrders.FindByID(ctx, guestOrderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the guest order"})
+			return
+		}
+		Events.Publish(events.TopicOrders, events.GuestOrderConfirmed, confirmed)
+		respondGuestOrder(ctx, c, store, confirmed)
+	}
+}
+
+// RejectGuestOrder turns down a submitted guest order with the reason the
+// guest is shown.
+func RejectGuestOrder(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		guestOrderId := c.Param("guest_order_id")
+
+		var body struct {
+			Reason string `json:"reason" validate:"required,max=200"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		if _, status, msg := submittedGuestOrder(ctx, store, guestOrderId); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := store.GuestOrders.UpdateStatus(ctx, guestOrderId, models.GuestOrderSubmitted, bson.D{
+			{Key: "status", Value: models.GuestOrderRejected},
+			{Key: "reject_reason", Value: body.Reason},
+			{Key: "reviewed_by", Value: c.GetString("uid")},
+			{Key: "reviewed_at", Value: now},
+			{Key: "updated_at", Value: now},
+		})
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the guest order was reviewed by someone else"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while rejecting the guest order"})
+			return
+		}
+
+		rejected, err := store.GuestOrders.FindByID(ctx, guestOrderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the guest order"})
+			return
+		}
+		Events.Publish(events.TopicOrders, events.GuestOrderRejected, rejected)
+		respondGuestOrder(ctx, c, store, rejected)
+	}
+}
+
+// guestCart returns the cart of the guest session and whether it is saved. A
+// session without one gets a new, unsaved cart.
+func guestCart(ctx context.Context, store *repository.Store, tableId string, sessionId string) (models.GuestOrder, bool, error) {
+	guestOrders, err := store.GuestOrders.FindBySession(ctx, sessionId)
+	if err != nil {
+		return models.GuestOrder{}, false, err
+	}
+	for _, guestOrder := range guestOrders {
+		if guestOrder.Status == models.GuestOrderCart {
+			return guestOrder, true, nil
+		}
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	cart := models.GuestOrder{
+		ID:         primitive.NewObjectID(),
+		Table_id:   tableId,
+		Session_id: sessionId,
+		Items:      []models.GuestOrderItem{},
+		Status:     models.GuestOrderCart,
+		Created_at: now,
+		Updated_at: now,
+	}
+	cart.Guest_order_id = cart.ID.Hex()
+	return cart, false, nil
+}
+
+// updateGuestCart saves the items of the cart while it is still a cart.
+func updateGuestCart(ctx context.Context, store *repository.Store, cart *models.GuestOrder) error {
+	cart.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return store.GuestOrders.UpdateStatus(ctx, cart.Guest_order_id, models.GuestOrderCart, bson.D{
+		{Key: "items", Value: cart.Items},
+		{Key: "updated_at", Value: cart.Updated_at},
+	})
+}
+
+// submittedGuestOrder returns the guest order while it waits for the staff.
+// On failure it returns the HTTP status and message to send back.
+func submittedGuestOrder(ctx context.Context, store *repository.Store, guestOrderId string) (models.GuestOrder, int, string) {
+	guestOrder, err := store.GuestOrders.FindByID(ctx, guestOrderId)
+	if err == repository.ErrNotFound {
+		return guestOrder, http.StatusNotFound, "guest order not found"
+	}
+	if err != nil {
+		return guestOrder, http.StatusInternalServerError, "error while fetching the guest order"
+	}
+	if guestOrder.Status != models.GuestOrderSubmitted {
+		return guestOrder, http.StatusConflict, "a " + guestOrder.Status + " guest order cannot be reviewed"
+	}
+	return guestOrder, http.StatusOK, ""
+}
+
+// confirmedOrder adds the items of a confirmed guest order, priced at the
+// current menu prices, to the open order of the table that nothing was paid
+// into yet, like moved items, or opens one when there is none. On failure it
+// returns the HTTP status and the error body to send back.
+func confirmedOrder(ctx context.Context, store *repository.Store, guestOrder models.GuestOrder, userId string) (models.Order, int, gin.H) {
+	items := make([]models.OrderItem, 0, len(guestOrder.Items))
+	for _, item := range guestOrder.Items {
+		items = append(items, guestOrderItem(item))
+	}
+
+	// a merged table orders at the primary table of the group
+	tableId := guestOrder.Table_id
+	table, err := seatingTable(ctx, store, tableId)
+	if err != nil && err != repository.ErrNotFound {
+		return models.Order{}, http.StatusInternalServerError, gin.H{"error": "error while fetching table details"}
+	}
+	if err == nil {
+		tableId = table.Table_id
+	}
+	open, found, err := receivingOrder(ctx, store, tableId)
+	if err != nil {
+		return models.Order{}, http.StatusInternalServerError, gin.H{"error": "error while fetching the orders of the table"}
+	}
+	var into *models.Order
+	if found {
+		into = &open
+	}
+
+	order, _, status, errBody := createOrderItems(ctx, store, &tableId, into, items, nil, userId)
+	return order, status, errBody
+}
+
+// guestOrderItem is the order item a guest asked for, before it is priced.
+func guestOrderItem(item models.GuestOrderItem) models.OrderItem {
+	return models.OrderItem{
+		Food_id:   item.Food_id,
+		Quantity:  item.Quantity,
+		Variant:   item.Variant,
+		Modifiers: item.Modifiers,
+	}
+}
+
+// guestLink returns the table and its signed ordering link. On failure it
+// returns the HTTP status and message to send back.
+func guestLink(ctx context.Context, store *repository.Store, tableId string) (models.Table, string, int, string) {
+	table, err := store.Tables.FindByID(ctx, tableId)
+	if err == repository.ErrNotFound {
+		return table, "", http.StatusNotFound, "table not found"
+	}
+	if err != nil {
+		return table, "", http.StatusInternalServerError, "error while fetching table details"
+	}
+	link, err := helpers.GuestOrderURL(table.Table_id)
+	if err != nil {
+		return table, "", http.StatusInternalServerError, "guest.order_url is not a valid URL"
+	}
+	return table, link, http.StatusOK, ""
+}
+
+// guestOrderFoods loads the foods of the items of the guest orders by id.
+func guestOrderFoods(ctx context.Context, store *repository.Store, guestOrders []models.GuestOrder) (map[string]models.Food, error) {
+	var foodIds []string
+	for _, guestOrder := range guestOrders {
+		for _, item := range guestOrder.Items {
+			foodIds = append(foodIds, *item.Food_id)
+		}
+	}
+	return foodsByID(ctx, store, foodIds)
+}
+
+// guestOrderViews joins the items of the guest orders with their food.
+func guestOrderViews(ctx context.Context, store *repository.Store, guestOrders []models.GuestOrder) ([]GuestOrderView, error) {
+	foodsById, err := guestOrderFoods(ctx, store, guestOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]GuestOrderView, 0, len(guestOrders))
+	for _, guestOrder := range guestOrders {
+		view := GuestOrderView{GuestOrder: guestOrder, Items: []GuestOrderLine{}}
+		for _, item := range guestOrder.Items {
+			line := GuestOrderLine{GuestOrderItem: item}
+			if food, ok := foodsById[*item.Food_id]; ok {
+				line.Food_name = food.Name
+				_, price, _ := helpers.VariantPrice(food, item.Variant)
+				line.Price = toFixed(price+helpers.ModifierTotal(item.Modifiers), 2)
+				line.Amount = toFixed(line.Price*float64(*item.Quantity), 2)
+			}
+			view.Items = append(view.Items, line)
+			view.Items_total += line.Amount
+		}
+		view.Items_total = toFixed(view.Items_total, 2)
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func respondGuestOrder(ctx context.Context, c *gin.Context, store *repository.Store, guestOrder models.GuestOrder) {
+	views, err := guestOrderViews(ctx, store, []models.GuestOrder{guestOrder})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the foods of the guest order"})
+		return
+	}
+	c.JSON(http.StatusOK, views[0])
+}
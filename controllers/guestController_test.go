@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupGuestRouter(store *repository.Store) *gin.Engine {
+	router := setupOrderRouter(store, models.RoleServer)
+	router.POST("/guest/sessions", CreateGuestSession(store))
+	guest := router.Group("/guest", middleware.GuestAuthentication())
+	guest.GET("/menu", GetGuestMenu(store))
+	guest.GET("/cart", GetGuestCart(store))
+	guest.POST("/cart/items", AddGuestCartItem(store))
+	guest.DELETE("/cart/items/:item_id", RemoveGuestCartItem(store))
+	guest.POST("/cart/submit", SubmitGuestCart(store))
+	guest.GET("/orders", GetGuestOrders(store))
+	router.GET("/tables/:table_id/qr", GetTableQRCode(store))
+	router.GET("/tables/:table_id/guest-link", GetTableGuestLink(store))
+	router.GET("/guest-orders", GetGuestOrderQueue(store))
+	router.POST("/guest-orders/:guest_order_id/confirm", ConfirmGuestOrder(store))
+	router.POST("/guest-orders/:guest_order_id/reject", RejectGuestOrder(store))
+	return router
+}
+
+func guestRequest(router *gin.Engine, token string, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	jsonValue, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("token", token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// startGuestSession scans the code of the table: it follows the signed link
+// and returns the guest token.
+func startGuestSession(t *testing.T, router *gin.Engine, tableId string) string {
+	w := performRequest(router, "GET", "/tables/"+tableId+"/guest-link", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	link := decode[gin.H](t, w.Body.Bytes())
+	assert.Contains(t, link["url"], "table="+tableId)
+
+	w = performRequest(router, "POST", "/guest/sessions", gin.H{"table_id": tableId, "sig": link["sig"]})
+	require.Equal(t, http.StatusOK, w.Code)
+	return decode[gin.H](t, w.Body.Bytes())["token"].(string)
+}
+
+func TestGuestOrderingFlow(t *testing.T) {
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupGuestRouter(store)
+	ctx := context.Background()
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	fries := createTestFood(t, router, "Fries", "Sides", 4)
+	tableId := createTestTable(t, router, 2, 5)
+	other := createTestTable(t, router, 2, 6)
+
+	// the signature of one table does not open another
+	sig := helpers.TableSignature(tableId)
+	w := performRequest(router, "POST", "/guest/sessions", gin.H{"table_id": other, "sig": sig})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token := startGuestSession(t, router, tableId)
+	w = guestRequest(router, "", "GET", "/guest/cart", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = guestRequest(router, token, "POST", "/guest/cart/submit", gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "an empty cart is not submitted")
+	w = guestRequest(router, token, "POST", "/guest/cart/items", gin.H{"food_id": "missing", "quantity": 1})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	guestRequest(router, token, "POST", "/guest/cart/items", gin.H{"food_id": burger, "quantity": 1})
+	w = guestRequest(router, token, "POST", "/guest/cart/items", gin.H{"food_id": fries, "quantity": 1})
+	require.Equal(t, http.StatusOK, w.Code)
+	cart := decode[GuestOrderView](t, w.Body.Bytes())
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, 14.0, cart.Items_total)
+
+	w = guestRequest(router, token, "DELETE", "/guest/cart/items/"+cart.Items[1].Item_id, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, decode[GuestOrderView](t, w.Body.Bytes()).Items, 1)
+
+	w = guestRequest(router, token, "POST", "/guest/cart/submit", gin.H{"note": "no onions"})
+	require.Equal(t, http.StatusOK, w.Code)
+	submitted := decode[GuestOrderView](t, w.Body.Bytes())
+	assert.Equal(t, models.GuestOrderSubmitted, submitted.Status)
+	assert.Equal(t, tableId, submitted.Table_id)
+
+	// nothing is cooked before the staff confirm it
+	tickets, _ := store.Tickets.List(ctx, "", models.ActiveTicketStatuses)
+	assert.Empty(t, tickets)
+	w = guestRequest(router, token, "GET", "/guest/cart", nil)
+	assert.Empty(t, decode[GuestOrderView](t, w.Body.Bytes()).Items, "the guest starts a new cart")
+
+	w = performRequest(router, "GET", "/guest-orders", nil)
+	queue := decode[[]GuestOrderView](t, w.Body.Bytes())
+	require.Len(t, queue, 1)
+	assert.Equal(t, submitted.Guest_order_id, queue[0].Guest_order_id)
+
+	w = performRequest(router, "POST", "/guest-orders/"+submitted.Guest_order_id+"/confirm", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	confirmed := decode[GuestOrderView](t, w.Body.Bytes())
+	assert.Equal(t, models.GuestOrderConfirmed, confirmed.Status)
+	require.NotNil(t, confirmed.Order_id)
+
+	w = performRequest(router, "GET", "/orderItems-order/"+*confirmed.Order_id, nil)
+	summaries := decode[[]OrderItemsSummary](t, w.Body.Bytes())
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 1, summaries[0].Total_count)
+	assert.Equal(t, 10.0, summaries[0].Items_total)
+	tickets, _ = store.Tickets.FindByOrder(ctx, *confirmed.Order_id)
+	assert.NotEmpty(t, tickets)
+
+	w = performRequest(router, "POST", "/guest-orders/"+submitted.Guest_order_id+"/confirm", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "a guest order is confirmed once")
+
+	// a second round is turned down
+	guestRequest(router, token, "POST", "/guest/cart/items", gin.H{"food_id": fries, "quantity": 1})
+	w = guestRequest(router, token, "POST", "/guest/cart/submit", gin.H{})
+	second := decode[GuestOrderView](t, w.Body.Bytes())
+	w = performRequest(router, "POST", "/guest-orders/"+second.Guest_order_id+"/reject", gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/guest-orders/"+second.Guest_order_id+"/reject", gin.H{"reason": "kitchen closed"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = guestRequest(router, token, "GET", "/guest/orders", nil)
+	mine := decode[[]GuestOrderView](t, w.Body.Bytes())
+	require.Len(t, mine, 2)
+	assert.Equal(t, models.GuestOrderConfirmed, mine[0].Status)
+	assert.Equal(t, models.GuestOrderRejected, mine[1].Status)
+	assert.Equal(t, "kitchen closed", *mine[1].Reject_reason)
+
+	// the next round confirmed joins the open order of the table
+	guestRequest(router, token, "POST", "/guest/cart/items", gin.H{"food_id": fries, "quantity": 1})
+	w = guestRequest(router, token, "POST", "/guest/cart/submit", gin.H{})
+	third := decode[GuestOrderView](t, w.Body.Bytes())
+	w = performRequest(router, "POST", "/guest-orders/"+third.Guest_order_id+"/confirm", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, *confirmed.Order_id, *decode[GuestOrderView](t, w.Body.Bytes()).Order_id)
+	w = performRequest(router, "GET", "/orderItems-order/"+*confirmed.Order_id, nil)
+	summaries = decode[[]OrderItemsSummary](t, w.Body.Bytes())
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 2, summaries[0].Total_count)
+	assert.Equal(t, 14.0, summaries[0].Items_total)
+	orders, _ := store.Orders.FindByTable(ctx, tableId)
+	assert.Len(t, orders, 1)
+}
+
+func TestTableQRCode(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupGuestRouter(store)
+	tableId := createTestTable(t, router, 4, 1)
+
+	w := performRequest(router, "GET", "/tables/"+tableId+"/qr?scale=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	img, err := png.Decode(w.Body)
+	require.NoError(t, err)
+	assert.Zero(t, img.Bounds().Dx()%2)
+
+	w = performRequest(router, "GET", "/tables/"+tableId+"/qr?format=svg", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<svg")
+
+	w = performRequest(router, "GET", "/tables/"+tableId+"/qr?format=gif", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "GET", "/tables/missing/qr", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
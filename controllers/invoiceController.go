@@ -2,17 +2,16 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type InvoiceViewFormat struct {
@@ -21,91 +20,147 @@ type InvoiceViewFormat struct {
 	Order_id         string
 	Payment_status   *string
 	Payment_due      interface{}
+	Items_total      float64
+	Discounts        []helpers.DiscountLine
+	Discount_total   float64
+	Subtotal         float64
+	Taxes            []helpers.TaxLine
+	Tax_total        float64
+	Service_charge   *helpers.ServiceChargeLine
+	Adjustments      []AdjustmentLine
+	Adjustment_total float64
+	Grand_total      float64
+	Amount_paid      float64
+	Balance_due      float64
+	Tip_total        float64
+	Payments         []models.Payment
 	Table_number     interface{}
 	Payment_due_date time.Time
 	Order_details    interface{}
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
-
-func GetInvoices() gin.HandlerFunc {
+func GetInvoices(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-		result, err := invoiceCollection.Find(context.TODO(), bson.M{})
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		allInvoices, err := store.Invoices.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while getting the invoice"})
-		}
-
-		var allInvoices []bson.M
-		if err := result.All(ctx, &allInvoices); err != nil {
-			log.Fatal(err)
+			return
 		}
 		c.JSON(http.StatusOK, allInvoices)
 	}
 }
 
-func GetInvoice() gin.HandlerFunc {
+func GetInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		invoiceId := c.Param("invoice_id")
-
-		var invoice models.Invoice
-
-		err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceId}).Decode(invoice)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		invoiceView, err := invoiceView(ctx, store, c.Param("invoice_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing invoice item"})
+			return
 		}
 
-		var invoiceView InvoiceViewFormat
+		c.JSON(http.StatusOK, invoiceView)
 
-		allOrderItems, err := ItemsByOrder(invoice.Order_id)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
-		invoiceView.Order_id = invoice.Order_id
-		invoiceView.Payment_due = invoice.Payment_due_date
+	}
+}
 
-		invoiceView.Payment_method = "null"
-		if invoice.Payment_method != nil {
-			invoiceView.Payment_method = *invoice.Payment_method
-		}
+// invoiceView puts together the invoice, its bill and its payments.
+func invoiceView(ctx context.Context, store *repository.Store, invoiceId string) (InvoiceViewFormat, error) {
+	var invoiceView InvoiceViewFormat
 
-		invoiceView.Invoice_id = invoice.Invoice_id
-		invoiceView.Payment_status = invoice.Payment_status
-		invoiceView.Payment_due = allOrderItems[0]["payment_due"]
-		invoiceView.Table_number = allOrderItems[0]["table_number"]
-		invoiceView.Order_details = allOrderItems[0]["order_items"]
+	invoice, summary, payments, err := invoicePayments(ctx, store, invoiceId)
+	if err != nil {
+		return invoiceView, err
+	}
 
-		c.JSON(http.StatusOK, invoiceView)
+	invoiceView.Order_id = invoice.Order_id
+	invoiceView.Payment_due_date = invoice.Payment_due_date
+
+	invoiceView.Payment_method = "null"
+	if invoice.Payment_method != nil {
+		invoiceView.Payment_method = *invoice.Payment_method
+	}
 
+	invoiceView.Invoice_id = invoice.Invoice_id
+	invoiceView.Payment_status = invoice.Payment_status
+	invoiceView.Payment_due = summary.Payment_due
+	invoiceView.Items_total = summary.Items_total
+	invoiceView.Discounts = []helpers.DiscountLine{}
+	if summary.Discounts != nil {
+		invoiceView.Discounts = summary.Discounts
 	}
+	invoiceView.Discount_total = summary.Discount_total
+	invoiceView.Subtotal = summary.Subtotal
+	invoiceView.Taxes = []helpers.TaxLine{}
+	if summary.Taxes != nil {
+		invoiceView.Taxes = summary.Taxes
+	}
+	invoiceView.Tax_total = summary.Tax_total
+	invoiceView.Service_charge = summary.Service_charge
+	invoiceView.Adjustments = []AdjustmentLine{}
+	if summary.Adjustments != nil {
+		invoiceView.Adjustments = summary.Adjustments
+	}
+	invoiceView.Adjustment_total = summary.Adjustment_total
+	invoiceView.Grand_total = summary.Payment_due
+	invoiceView.Amount_paid = payments.Amount_paid
+	invoiceView.Balance_due = payments.Balance_due
+	invoiceView.Tip_total = payments.Tip_total
+	invoiceView.Payments = payments.Payments
+	invoiceView.Table_number = summary.Table_number
+	invoiceView.Order_details = summary.Order_items
+
+	return invoiceView, nil
 }
 
-func CreateInvoice() gin.HandlerFunc {
+func CreateInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 		var invoice models.Invoice
 
 		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		var order models.Order
 
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.Order_id}).Decode(&order)
+		// the status and the amount paid follow from the payments recorded
+		if invoice.Payment_status != nil || invoice.Amount_paid != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a new invoice is PENDING with nothing paid, payments are recorded against it"})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, invoice.Order_id)
 		if err != nil {
 			msg := "message: order was not found"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusNotFound, gin.H{"error": msg})
+			return
+		}
+		if !orderOpen(order) {
+			c.JSON(http.StatusConflict, gin.H{"error": "the order is " + order.CurrentStatus() + ", it cannot be billed"})
+			return
+		}
+		// an invoice bills the whole order, a second one would bill it twice
+		billed, err := store.Invoices.FindByOrder(ctx, invoice.Order_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the invoices of the order"})
 			return
 		}
-		status := "PENDING"
-		if invoice.Payment_status == nil {
-			invoice.Payment_status = &status
+		if len(billed) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "the order already has invoice " + billed[0].Invoice_id})
+			return
 		}
+		status := models.PaymentPending
+		invoice.Payment_status = &status
 		invoice.Payment_due_date, _ = time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
 		invoice.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -117,38 +172,39 @@ func CreateInvoice() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": validateErr.Error()})
 			return
 		}
-		result, inserterr := invoiceCollection.InsertOne(ctx, invoice)
+		inserterr := store.Invoices.Insert(ctx, invoice)
 		if inserterr != nil {
 			msg := "invoice not created"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
+		Events.Publish(events.TopicInvoices, events.InvoiceCreated, invoice)
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, invoice)
 	}
 }
 
-func UpdateInvoice() gin.HandlerFunc {
+func UpdateInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var invoice models.Invoice
 		invoiceId := c.Param("invoice_id")
 
 		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"errror": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"errror": err.Error()})
 			return
 		}
 
-		filter := bson.M{"invoice_id": invoiceId}
-
-		var updateObj primitive.D
-
+		// the status follows from the payments recorded against the invoice
 		if invoice.Payment_status != nil {
-			updateObj = append(updateObj, bson.E{Key: "payment_status", Value: invoice.Payment_status})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the payment status follows from the payments recorded, it cannot be set"})
+			return
 		}
 
+		var updateObj primitive.D
+
 		if invoice.Payment_method != nil {
 			updateObj = append(updateObj, bson.E{Key: "payment_method", Value: invoice.Payment_method})
 
@@ -156,32 +212,25 @@ func UpdateInvoice() gin.HandlerFunc {
 		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: invoice.Updated_at})
 
-		upsert := true
-
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
-		}
-
-		status := "PENDING"
-		if invoice.Payment_status == nil {
-			invoice.Payment_status = &status
+		err := store.Invoices.Update(ctx, invoiceId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
 		}
-
-		result, err := invoiceCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
 		if err != nil {
 			msg := "invoice item update failed"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
-		defer cancel()
-		c.JSON(http.StatusOK, result)
+
+		updatedInvoice, err := store.Invoices.FindByID(ctx, invoiceId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing invoice item"})
+			return
+		}
+		Events.Publish(events.TopicInvoices, events.InvoiceUpdated, updatedInvoice)
+
+		c.JSON(http.StatusOK, updatedInvoice)
 
 	}
 }
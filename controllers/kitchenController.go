@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stationItems are the items of an order that one kitchen station prepares.
+type stationItems struct {
+	station config.StationConfig
+	items   []OrderItemView
+}
+
+// byStation sorts the items onto their kitchen stations, in the order the
+// stations first appear. Voided and comped items are left off, and so are the
+// items missing from wanted unless it is empty.
+func byStation(items []OrderItemView, wanted map[string]bool) []stationItems {
+	var stations []stationItems
+	index := map[string]int{}
+
+	for _, item := range items {
+		if item.Adjustment != nil || (len(wanted) > 0 && !wanted[item.Order_item_id]) {
+			continue
+		}
+		station := helpers.KitchenStation(item.Category)
+		i, ok := index[station.Name]
+		if !ok {
+			i = len(stations)
+			index[station.Name] = i
+			stations = append(stations, stationItems{station: station})
+		}
+		stations[i].items = append(stations[i].items, item)
+	}
+	return stations
+}
+
+// foodName is the name of the food of the item, with the variant it was
+// ordered in.
+func foodName(item OrderItemView) string {
+	name := "Unknown item"
+	if item.Food_name != nil {
+		name = *item.Food_name
+	}
+	if item.Variant != nil {
+		name += " - " + *item.Variant
+	}
+	return name
+}
+
+// modifierNames are the modifiers of the item as the cooks and the guest read
+// them.
+func modifierNames(item OrderItemView) []string {
+	names := make([]string, 0, len(item.Modifiers))
+	for _, modifier := range item.Modifiers {
+		names = append(names, modifier.Name)
+	}
+	return names
+}
+
+// sendToKitchen puts a ticket for the new items of the order on the screens of
+// their stations. The order is already saved, so a failure is only logged.
+func sendToKitchen(ctx context.Context, store *repository.Store, orderId string, orderItems []models.OrderItem) {
+	summaries, err := ItemsByOrder(ctx, store, orderId)
+	if err != nil || len(summaries) == 0 {
+		log.Printf("kitchen tickets for order %s: %v", orderId, err)
+		return
+	}
+
+	wanted := map[string]bool{}
+	for _, item := range orderItems {
+		wanted[item.Order_item_id] = true
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	var tickets []models.KitchenTicket
+	for _, s := range byStation(summaries[0].Order_items, wanted) {
+		ticket := models.KitchenTicket{
+			ID:           primitive.NewObjectID(),
+			Order_id:     orderId,
+			Station:      s.station.Name,
+			Table_number: summaries[0].Table_number,
+			Status:       models.TicketNew,
+			Created_at:   now,
+			Updated_at:   now,
+		}
+		ticket.Ticket_id = ticket.ID.Hex()
+		for _, item := range s.items {
+			ticket.Items = append(ticket.Items, models.KitchenTicketItem{
+				Order_item_id: item.Order_item_id,
+				Food_name:     foodName(item),
+				Quantity:      item.Quantity,
+				Modifiers:     modifierNames(item),
+			})
+		}
+		tickets = append(tickets, ticket)
+	}
+	if len(tickets) == 0 {
+		return
+	}
+
+	if err := store.Tickets.Insert(ctx, tickets...); err != nil {
+		log.Printf("kitchen tickets for order %s: %v", orderId, err)
+		return
+	}
+	for _, ticket := range tickets {
+		Events.Publish(events.TopicKitchen, events.TicketCreated, ticket)
+	}
+}
+
+// GetKitchenTickets lists the tickets on the screens, oldest first.
+// ?station= shows one station and ?status= takes a comma separated list of
+// statuses, BUMPED for example to find a ticket to recall.
+func GetKitchenTickets(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		statuses := models.ActiveTicketStatuses
+		if status := c.Query("status"); status != "" {
+			statuses = strings.Split(status, ",")
+		}
+
+		tickets, err := store.Tickets.List(ctx, c.Query("station"), statuses)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing kitchen tickets"})
+			return
+		}
+		if tickets == nil {
+			tickets = []models.KitchenTicket{}
+		}
+		c.JSON(http.StatusOK, tickets)
+	}
+}
+
+func GetKitchenTicket(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		ticket, err := store.Tickets.FindByID(ctx, c.Param("ticket_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "kitchen ticket not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the kitchen ticket"})
+			return
+		}
+		c.JSON(http.StatusOK, ticket)
+	}
+}
+
+func StartKitchenTicket(store *repository.Store) gin.HandlerFunc {
+	return kitchenTicketAction(store, models.TicketStart)
+}
+
+func ReadyKitchenTicket(store *repository.Store) gin.HandlerFunc {
+	return kitchenTicketAction(store, models.TicketMark)
+}
+
+// BumpKitchenTicket takes the ticket off the screens.
+func BumpKitchenTicket(store *repository.Store) gin.HandlerFunc {
+	return kitchenTicketAction(store, models.TicketBump)
+}
+
+// RecallKitchenTicket puts a bumped ticket back on the screens as READY.
+func RecallKitchenTicket(store *repository.Store) gin.HandlerFunc {
+	return kitchenTicketAction(store, models.TicketRecall)
+}
+
+// kitchenTicketAction moves the ticket on with action and tells the screens.
+// Starting a ticket starts the order, and the order is READY once all of its
+// tickets are.
+func kitchenTicketAction(store *repository.Store, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		ticketId := c.Param("ticket_id")
+
+		ticket, err := store.Tickets.FindByID(ctx, ticketId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "kitchen ticket not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the kitchen ticket"})
+			return
+		}
+
+		to, _, allowed := models.TicketTransition(action, ticket.Status)
+		if !allowed {
+			c.JSON(http.StatusConflict, gin.H{"error": "cannot " + action + " a " + ticket.Status + " ticket"})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		fields := bson.D{{Key: "status", Value: to}, {Key: "updated_at", Value: now}}
+		switch action {
+		case models.TicketStart:
+			fields = append(fields, bson.E{Key: "started_at", Value: now})
+		case models.TicketMark:
+			fields = append(fields, bson.E{Key: "ready_at", Value: now})
+			if ticket.Started_at == nil {
+				fields = append(fields, bson.E{Key: "started_at", Value: now})
+			}
+		case models.TicketBump:
+			fields = append(fields, bson.E{Key: "bumped_at", Value: now})
+		case models.TicketRecall:
+			fields = append(fields, bson.E{Key: "bumped_at", Value: nil})
+		}
+
+		err = store.Tickets.UpdateStatus(ctx, ticketId, ticket.Status, fields)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the ticket was changed on another screen, please retry"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while updating the kitchen ticket"})
+			return
+		}
+
+		ticket, err = store.Tickets.FindByID(ctx, ticketId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the kitchen ticket"})
+			return
+		}
+		Events.Publish(events.TopicKitchen, events.TicketUpdated, ticket)
+
+		switch to {
+		case models.TicketInProgress:
+			advanceOrder(ctx, store, ticket.Order_id, models.OrderInKitchen, c)
+		case models.TicketReady:
+			if orderTicketsReady(ctx, store, ticket.Order_id) {
+				advanceOrder(ctx, store, ticket.Order_id, models.OrderInKitchen, c)
+				advanceOrder(ctx, store, ticket.Order_id, models.OrderReady, c)
+			}
+		}
+		c.JSON(http.StatusOK, ticket)
+	}
+}
+
+// orderTicketsReady tells whether every ticket of the order is ready or bumped.
+func orderTicketsReady(ctx context.Context, store *repository.Store, orderId string) bool {
+	tickets, err := store.Tickets.FindByOrder(ctx, orderId)
+	if err != nil {
+		return false
+	}
+	for _, ticket := range tickets {
+		if ticket.Status != models.TicketReady && ticket.Status != models.TicketBumped {
+			return false
+		}
+	}
+	return true
+}
+
+// advanceOrder moves the order to the next kitchen status when it is right
+// before it. The ticket is what matters to the kitchen, so an order that has
+// moved on or cannot be moved by the user is left alone.
+func advanceOrder(ctx context.Context, store *repository.Store, orderId string, to string, c *gin.Context) {
+	order, err := store.Orders.FindByID(ctx, orderId)
+	if err != nil {
+		return
+	}
+	from := order.CurrentStatus()
+	if (to == models.OrderInKitchen && from != models.OrderPlaced) || (to == models.OrderReady && from != models.OrderInKitchen) {
+		return
+	}
+	transitionOrder(ctx, store, orderId, to, c.GetString("uid"), c.GetString("user_type"), nil)
+}
+
+// KitchenStream pushes new and changed tickets to a kitchen screen as
+// server-sent events, ?station= limits it to one station. The stream starts
+// with a ticket.snapshot event listing the tickets already on the screen.
+func KitchenStream(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		station := c.Query("station")
+		// subscribe first so nothing happening while the snapshot is read is missed
+		sub := Events.Subscribe(events.TopicKitchen)
+
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		tickets, err := store.Tickets.List(ctx, station, models.ActiveTicketStatuses)
+		cancel()
+		if err != nil {
+			sub.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing kitchen tickets"})
+			return
+		}
+		if tickets == nil {
+			tickets = []models.KitchenTicket{}
+		}
+
+		snapshot := events.Event{Topic: events.TopicKitchen, Type: events.TicketSnapshot, Time: time.Now().UTC(), Data: tickets}
+		streamEvents(c, sub, func(event events.Event) bool {
+			ticket, ok := event.Data.(models.KitchenTicket)
+			return station == "" || (ok && ticket.Station == station)
+		}, snapshot)
+	}
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configureTestStations sends drinks to the bar and everything else to the grill.
+func configureTestStations(t *testing.T) {
+	helpers.ConfigureKitchen(config.KitchenConfig{Stations: []config.StationConfig{
+		{Name: "Grill"},
+		{Name: "Bar", Categories: []string{"drinks"}},
+	}})
+	t.Cleanup(func() { helpers.ConfigureKitchen(config.KitchenConfig{}) })
+}
+
+func TestKitchenTicketFlow(t *testing.T) {
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupOrderRouter(store, models.RoleKitchen)
+	router.GET("/kitchen/tickets", GetKitchenTickets(store))
+	router.POST("/kitchen/tickets/:ticket_id/start", StartKitchenTicket(store))
+	router.POST("/kitchen/tickets/:ticket_id/ready", ReadyKitchenTicket(store))
+	router.POST("/kitchen/tickets/:ticket_id/bump", BumpKitchenTicket(store))
+	router.POST("/kitchen/tickets/:ticket_id/recall", RecallKitchenTicket(store))
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	beer := createTestFood(t, router, "Beer", "Drinks", 5)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 4), steak, beer, steak)
+
+	w := performRequest(router, "GET", "/kitchen/tickets?station=Grill", nil)
+	grill := decode[[]models.KitchenTicket](t, w.Body.Bytes())
+	require.Len(t, grill, 1)
+	assert.Equal(t, orderId, grill[0].Order_id)
+	assert.Equal(t, models.TicketNew, grill[0].Status)
+	assert.Equal(t, 4, *grill[0].Table_number)
+	assert.Len(t, grill[0].Items, 2)
+
+	w = performRequest(router, "GET", "/kitchen/tickets?station=Bar", nil)
+	bar := decode[[]models.KitchenTicket](t, w.Body.Bytes())
+	require.Len(t, bar, 1)
+	assert.Equal(t, "Beer", bar[0].Items[0].Food_name)
+
+	// starting a ticket starts the order
+	w = performRequest(router, "POST", "/kitchen/tickets/"+grill[0].Ticket_id+"/start", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	order, _ := store.Orders.FindByID(context.Background(), orderId)
+	assert.Equal(t, models.OrderInKitchen, order.CurrentStatus())
+
+	w = performRequest(router, "POST", "/kitchen/tickets/"+grill[0].Ticket_id+"/start", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(router, "POST", "/kitchen/tickets/"+grill[0].Ticket_id+"/recall", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(router, "POST", "/kitchen/tickets/"+grill[0].Ticket_id+"/ready", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	order, _ = store.Orders.FindByID(context.Background(), orderId)
+	assert.Equal(t, models.OrderInKitchen, order.CurrentStatus(), "the drinks are not ready yet")
+
+	// the order is ready with its last ticket
+	w = performRequest(router, "POST", "/kitchen/tickets/"+bar[0].Ticket_id+"/ready", nil)
+	ticket := decode[models.KitchenTicket](t, w.Body.Bytes())
+	assert.NotNil(t, ticket.Started_at)
+	assert.NotNil(t, ticket.Ready_at)
+	order, _ = store.Orders.FindByID(context.Background(), orderId)
+	assert.Equal(t, models.OrderReady, order.CurrentStatus())
+
+	w = performRequest(router, "POST", "/kitchen/tickets/"+bar[0].Ticket_id+"/bump", nil)
+	assert.Equal(t, models.TicketBumped, decode[models.KitchenTicket](t, w.Body.Bytes()).Status)
+	w = performRequest(router, "GET", "/kitchen/tickets?station=Bar", nil)
+	assert.Empty(t, decode[[]models.KitchenTicket](t, w.Body.Bytes()))
+
+	w = performRequest(router, "POST", "/kitchen/tickets/"+bar[0].Ticket_id+"/recall", nil)
+	ticket = decode[models.KitchenTicket](t, w.Body.Bytes())
+	assert.Equal(t, models.TicketReady, ticket.Status)
+	assert.Nil(t, ticket.Bumped_at)
+
+	w = performRequest(router, "POST", "/kitchen/tickets/missing/bump", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestKitchenStream(t *testing.T) {
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupOrderRouter(store, models.RoleServer)
+	router.GET("/kitchen/stream", KitchenStream(store))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	beer := createTestFood(t, router, "Beer", "Drinks", 5)
+	tableId := createTestTable(t, router, 2, 4)
+	createTestOrder(t, router, tableId, steak)
+
+	resp, err := http.Get(server.URL + "/kitchen/stream?station=Bar")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	stream := bufio.NewReader(resp.Body)
+
+	eventType, event := readEvent(t, stream)
+	assert.Equal(t, events.TicketSnapshot, eventType)
+	assert.Empty(t, event.Data, "the steak is not for the bar")
+
+	// only the beer reaches the bar screen
+	createTestOrder(t, router, tableId, steak, beer)
+	eventType, event = readEvent(t, stream)
+	assert.Equal(t, events.TicketCreated, eventType)
+	assert.NotZero(t, event.ID)
+	data, _ := json.Marshal(event.Data)
+	ticket := decode[models.KitchenTicket](t, data)
+	assert.Equal(t, "Bar", ticket.Station)
+	assert.Equal(t, "Beer", ticket.Items[0].Food_name)
+}
@@ -2,72 +2,54 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var menuCollections *mongo.Collection = database.OpenCollection(database.Client, "menu")
-
-func GetMenus() gin.HandlerFunc {
+func GetMenus(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-		result, err := menuCollections.Find(context.TODO(), bson.M{})
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		allMenu, err := store.Menus.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the menu"})
 			return
 		}
 
-		var allMenu []bson.M
-
-		if err := result.All(ctx, &allMenu); err != nil {
-			log.Fatal(err)
-		}
-
 		c.JSON(http.StatusOK, allMenu)
 	}
 }
 
-func GetMenuByID() gin.HandlerFunc {
+func GetMenuByID(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		menuID := c.Param("menu_id")
-		var menu models.Menu
 
 		// ✅ Fetch the menu details
-		err := menuCollections.FindOne(ctx, bson.M{"menu_id": menuID}).Decode(&menu)
+		menu, err := store.Menus.FindByID(ctx, menuID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Menu not found"})
 			return
 		}
 
 		// ✅ Fetch all food items in this menu
-		cursor, err := foodCollection.Find(ctx, bson.M{"menu_id": menuID})
+		foodItems, err := store.Foods.FindByMenu(ctx, menuID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching food items"})
 			return
 		}
 
-		// ✅ Decode all food items into a slice
-		var foodItems []models.Food
-		if err = cursor.All(ctx, &foodItems); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding food items"})
-			return
-		}
-
 		// ✅ Return menu details along with all its foods
 		c.JSON(http.StatusOK, gin.H{
 			"menu":  menu,
@@ -76,11 +58,11 @@ func GetMenuByID() gin.HandlerFunc {
 	}
 }
 
-func CreateMenu() gin.HandlerFunc {
+func CreateMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var menus []models.Menu
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		// ✅ Bind JSON request body
@@ -101,10 +83,8 @@ func CreateMenu() gin.HandlerFunc {
 				return
 			}
 
-			// ✅ Check if a menu with the same category already exists
-			var existingMenu models.Menu
-
-			err := menuCollections.FindOne(ctx, gin.H{"menu_id": menus[i].Menu_id}).Decode(&existingMenu)
+			// ✅ Check if the menu already exists
+			existingMenu, err := store.Menus.FindByID(ctx, menus[i].Menu_id)
 			if err == nil {
 
 				// menu exists, assign the existing "menu_id"
@@ -117,7 +97,7 @@ func CreateMenu() gin.HandlerFunc {
 				menus[i].Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 				// Append assigned menu details
-				_, insertErr := menuCollections.InsertOne(ctx, menus[i])
+				insertErr := store.Menus.Insert(ctx, menus[i])
 				if insertErr != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "error while instering"})
 					return
@@ -140,91 +120,127 @@ func CreateMenu() gin.HandlerFunc {
 	}
 }
 
-func inTimeSpan(start, end, check time.Time) bool {
-	return start.After(time.Now()) && end.After(time.Now())
+// GetActiveMenus lists the menus served at ?at=, an RFC 3339 time, or now.
+func GetActiveMenus(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		at := time.Now()
+		if value := c.Query("at"); value != "" {
+			var err error
+			at, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "at must be a time like 2026-01-02T12:00:00Z"})
+				return
+			}
+		}
+
+		allMenu, err := store.Menus.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the menu"})
+			return
+		}
+
+		activeMenus := []models.Menu{}
+		for _, menu := range allMenu {
+			if helpers.MenuActiveAt(menu, at) {
+				activeMenus = append(activeMenus, menu)
+			}
+		}
+		c.JSON(http.StatusOK, activeMenus)
+	}
 }
 
-func UpdateMenu() gin.HandlerFunc {
+func UpdateMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 		var menu models.Menu
 
 		if err := c.BindJSON(&menu); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
 		menuId := c.Param("menu_id")
-		filter := bson.M{"menu_id": menuId}
+		// PATCH /menus, kept for older clients, names the menu in the body
+		if menuId == "" {
+			menuId = menu.Menu_id
+		}
+		if menuId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "menu_id is required"})
+			return
+		}
 
 		var updateObj primitive.D
 
 		if menu.Start_date != nil && menu.End_date != nil {
-			if !inTimeSpan(*menu.Start_date, *menu.End_date, time.Now()) {
+			// the end date is after the start date and not yet past
+			if !menu.End_date.After(*menu.Start_date) || menu.End_date.Before(time.Now()) {
 				msg := "kindly retype the time"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				defer cancel()
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 				return
 			}
 
 			updateObj = append(updateObj, bson.E{Key: "start_date", Value: menu.Start_date})
 			updateObj = append(updateObj, bson.E{Key: "end_date", Value: menu.End_date})
+		}
 
-			if menu.Name != "" {
-				updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
-			}
-
-			if menu.Category != "" {
-				updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
-			}
-
-			menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.Updated_at})
+		if menu.Name != "" {
+			updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
+		}
 
-			upsert := true
+		if menu.Category != "" {
+			updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
+		}
 
-			opt := options.UpdateOptions{
-				Upsert: &upsert,
+		// an empty schedule serves the menu all day again
+		if menu.Schedule != nil {
+			if validationErr := validate.Var(menu.Schedule, "dive"); validationErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+				return
 			}
+			updateObj = append(updateObj, bson.E{Key: "schedule", Value: menu.Schedule})
+		}
 
-			result, err := menuCollections.UpdateOne(
-				ctx,
-				filter,
-				bson.D{
-					{Key: "$set", Value: updateObj},
-				},
-				&opt,
-			)
-			if err != nil {
-				msg := "error while updating menu"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-			}
+		menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.Updated_at})
 
-			defer cancel()
-			c.JSON(http.StatusOK, result)
+		err := store.Menus.Update(ctx, menuId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+			return
+		}
+		if err != nil {
+			msg := "error while updating menu"
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			return
 		}
 
+		updatedMenu, err := store.Menus.FindByID(ctx, menuId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the menu"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedMenu)
 	}
 }
 
-func DeleteMenu() gin.HandlerFunc {
+func DeleteMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		menuID := c.Param("menu_id")
 
-		// ✅ Check if menu exists before deleting
-		var menu models.Menu
-		err := menuCollections.FindOne(ctx, bson.M{"menu_id": menuID}).Decode(&menu)
-		if err != nil {
+		// ✅ Delete the menu, reporting a missing one
+		err := store.Menus.Delete(ctx, menuID)
+		if err == repository.ErrNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
 			return
 		}
-
-		// ✅ Delete associated food items
-		_, err = menuCollections.DeleteOne(ctx, bson.M{"menu_id": menuID})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete menu"})
 			return
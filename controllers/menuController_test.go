@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledMenus(t *testing.T) {
+	helpers.ConfigureMenus(config.MenuConfig{Timezone: "UTC"})
+	defer helpers.ConfigureMenus(config.MenuConfig{})
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+	router.POST("/menus", CreateMenu(store))
+	router.GET("/menus/active", GetActiveMenus(store))
+	tableId := createTestTable(t, router, 2, 1)
+
+	// breakfast starts next week, brunch is served at weekend mornings
+	nextWeek := time.Now().AddDate(0, 0, 7)
+	w := performRequest(router, "POST", "/menus", []gin.H{
+		{"name": "Breakfast", "category": "Breakfast", "start_date": nextWeek},
+		{"name": "Brunch", "category": "Brunch", "schedule": []gin.H{{"days": []string{"SAT", "SUN"}, "start": "09:00", "end": "13:00"}}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(router, "POST", "/menus", []gin.H{
+		{"name": "Late", "category": "Late", "schedule": []gin.H{{"days": []string{"FUN"}, "start": "22:00", "end": "02:00"}}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	pancakes := createTestFood(t, router, "Pancakes", "Breakfast", 7)
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+
+	active := func(at string) []string {
+		w := performRequest(router, "GET", "/menus/active?at="+at, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var names []string
+		for _, menu := range decode[[]models.Menu](t, w.Body.Bytes()) {
+			names = append(names, menu.Name)
+		}
+		return names
+	}
+	saturday := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	assert.ElementsMatch(t, []string{"Brunch", "Mains Menu"}, active(saturday.Format(time.RFC3339)))
+	assert.ElementsMatch(t, []string{"Mains Menu"}, active(saturday.Add(4*time.Hour).Format(time.RFC3339)))
+	w = performRequest(router, "GET", "/menus/active?at=tomorrow", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(router, "POST", "/orderItems", gin.H{"table_id": tableId, "order_items": []gin.H{
+		{"food_id": burger, "quantity": 1},
+		{"food_id": pancakes, "quantity": 1},
+	}})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	detail := decode[struct {
+		Detail []gin.H `json:"detail"`
+	}](t, w.Body.Bytes()).Detail
+	require.Len(t, detail, 1)
+	assert.Equal(t, 1.0, detail[0]["index"])
+	assert.Equal(t, "menu", detail[0]["rule"])
+
+	createTestOrder(t, router, tableId, burger)
+}
+
+func TestUpdateWithoutIdInPath(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+	router.PATCH("/foods", UpdateFood(store))
+	router.PATCH("/menus", UpdateMenu(store))
+	ctx := context.Background()
+
+	// older clients name the food or menu in the body
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	w := performRequest(router, "PATCH", "/foods", gin.H{"food_id": burger, "price": 12})
+	require.Equal(t, http.StatusOK, w.Code)
+	food, err := store.Foods.FindByID(ctx, burger)
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, *food.Price)
+	w = performRequest(router, "PATCH", "/foods", gin.H{"price": 12})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	menu, err := store.Menus.FindByID(ctx, *food.Menu_id)
+	require.NoError(t, err)
+	w = performRequest(router, "PATCH", "/menus", gin.H{"menu_id": menu.Menu_id, "name": "Dinner"})
+	require.Equal(t, http.StatusOK, w.Code)
+	menu, err = store.Menus.FindByID(ctx, menu.Menu_id)
+	require.NoError(t, err)
+	assert.Equal(t, "Dinner", menu.Name)
+	w = performRequest(router, "PATCH", "/menus", gin.H{"name": "Dinner"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
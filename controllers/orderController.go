@@ -2,59 +2,56 @@ package controllers
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/events"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var orderCollection *mongo.Collection = database.OpenCollection(database.Client, "order")
-
-func GetOrders() gin.HandlerFunc {
+func GetOrders(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		result, err := orderCollection.Find(context.TODO(), bson.M{})
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		allOrders, err := store.Orders.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the order items"})
-		}
-		var allOrders []bson.M
-		if err = result.All(ctx, &allOrders); err != nil {
-			log.Fatal(err)
+			return
 		}
 		c.JSON(http.StatusOK, allOrders)
 
 	}
 }
 
-func GetOrder() gin.HandlerFunc {
+func GetOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		orderID := c.Param("food_id")
-
-		var order models.Order
-
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": orderID}).Decode(&order)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+		orderID := c.Param("order_id")
+
+		order, err := store.Orders.FindByID(ctx, orderID)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
 		}
 		c.JSON(http.StatusOK, order)
 	}
 }
 
-func CreateOrder() gin.HandlerFunc {
+func CreateOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
-		var table models.Table
 		var order models.Order
 
 		if err := c.BindJSON(&order); err != nil {
@@ -68,100 +65,252 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		if order.Table_id != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table": order.Table_id}).Decode(&table)
-			defer cancel()
-			if err != nil {
-				msg := "message: Table was not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				return
-			}
+		order, status, msg := createOrder(ctx, store, order, c.GetString("uid"))
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
 		}
-		order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		order.ID = primitive.NewObjectID()
-		order.Order_id = order.ID.Hex()
+		c.JSON(http.StatusOK, order)
 
-		result, err := orderCollection.InsertOne(ctx, order)
+	}
+}
 
+// createOrder saves a new order for the table, and is what seats a party. On
+// failure it returns the HTTP status and message to send back.
+func createOrder(ctx context.Context, store *repository.Store, order models.Order, userId string) (models.Order, int, string) {
+	order, status, msg := newOrder(ctx, store, order, userId)
+	if msg != "" {
+		return order, status, msg
+	}
+
+	err := store.Orders.Insert(ctx, order)
+
+	if err != nil {
+		msg := "order item was not created"
+		return order, http.StatusInternalServerError, msg
+	}
+	publishOrderCreated(order)
+
+	return order, http.StatusOK, ""
+}
+
+// newOrder fills in a new order for the table, ready to be written.
+func newOrder(ctx context.Context, store *repository.Store, order models.Order, userId string) (models.Order, int, string) {
+	// a new order is either still being drafted or placed straight away
+	status := models.OrderDraft
+	if order.Status != nil {
+		status = *order.Status
+	}
+	if status != models.OrderDraft && status != models.OrderPlaced {
+		return order, http.StatusBadRequest, "a new order must be DRAFT or PLACED"
+	}
+
+	// an order for a merged table is seated at the primary table of the group
+	if order.Table_id != nil {
+		table, err := seatingTable(ctx, store, *order.Table_id)
 		if err != nil {
-			msg := "order item was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-			return
+			msg := "message: Table was not found"
+			return order, http.StatusNotFound, msg
 		}
+		order.Table_id = &table.Table_id
+	}
 
-		c.JSON(http.StatusOK, result)
-
+	// the user taking the order looks after it unless someone else is named
+	if order.Server_id == nil {
+		order.Server_id = &userId
+	} else if _, err := store.Users.FindByID(ctx, *order.Server_id); err != nil {
+		return order, http.StatusNotFound, "server not found"
 	}
+	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	order.ID = primitive.NewObjectID()
+	order.Order_id = order.ID.Hex()
+	setInitialStatus(&order, status, userId)
+
+	return order, http.StatusOK, ""
 }
 
-func UpdateOrder() gin.HandlerFunc {
+// UpdateOrder hands an order to another server and/or moves it to another
+// status. Status changes go through the same transition table as
+// TransitionOrder. Orders change tables through TransferOrder.
+func UpdateOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
-		var table models.Table
 		var order models.Order
 
 		var updateObj primitive.D
 
 		orderId := c.Param("order_id")
 		if err := c.BindJSON(&order); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// PATCH /orders, kept for older clients, names the order in the body
+		if orderId == "" {
+			orderId = order.Order_id
+		}
+		if orderId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required"})
 			return
 		}
 
 		if order.Table_id != nil {
-			err := menuCollections.FindOne(ctx, bson.M{"table_id": order.Table_id}).Decode(&table)
-			defer cancel()
-			if err != nil {
-				msg := "message: table not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "move an order to another table with POST /orders/" + orderId + "/transfer"})
+			return
+		}
+
+		if order.Server_id != nil {
+			if _, err := store.Users.FindByID(ctx, *order.Server_id); err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "menu", Value: order.Table_id})
+			updateObj = append(updateObj, bson.E{Key: "server_id", Value: order.Server_id})
+		}
 
+		// with a status change the fields are written together with it, so a
+		// refused transition changes nothing
+		if order.Status != nil {
+			status, msg := transitionOrder(ctx, store, orderId, *order.Status, c.GetString("uid"), c.GetString("user_type"), updateObj)
+			if msg != "" {
+				c.JSON(status, gin.H{"error": msg})
+				return
+			}
+		} else if len(updateObj) > 0 {
 			order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			updateObj = append(updateObj, bson.E{Key: "updated_at", Value: order.Updated_at})
 
-			upsert := true
-
-			filter := bson.M{"food_id": orderId}
-			opt := options.UpdateOptions{
-				Upsert: &upsert,
+			err := store.Orders.Update(ctx, orderId, updateObj)
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+				return
 			}
-
-			result, err := foodCollection.UpdateOne(
-				ctx,
-				filter,
-				bson.D{
-					{Key: "$set", Value: updateObj},
-				},
-				&opt,
-			)
-
 			if err != nil {
 				msg := "error while updating order"
 				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 				return
 			}
+		}
 
-			c.JSON(http.StatusOK, result)
+		updatedOrder, err := store.Orders.FindByID(ctx, orderId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
 		}
+		if len(updateObj) > 0 {
+			Events.Publish(events.TopicOrders, events.OrderUpdated, updatedOrder)
+		}
+
+		c.JSON(http.StatusOK, updatedOrder)
 	}
 }
 
-func OrderItemOrderCreator(order models.Order) string {
+// TransitionOrder moves an order to the status in the request body.
+func TransitionOrder(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
 
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		orderId := c.Param("order_id")
 
-	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	order.ID = primitive.NewObjectID()
-	order.Order_id = order.ID.Hex()
+		var body struct {
+			Status string `json:"status" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		status, msg := transitionOrder(ctx, store, orderId, body.Status, c.GetString("uid"), c.GetString("user_type"), nil)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
 
-	orderCollection.InsertOne(ctx, order)
-	defer cancel()
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// transitionOrder checks the move against the transition table and applies it.
+// The update only matches while the order still has the status we checked, so
+// two concurrent transitions cannot both succeed. On failure it returns the
+// HTTP status and message to send back.
+func transitionOrder(ctx context.Context, store *repository.Store, orderId string, to string, userId string, userType string, fields primitive.D) (int, string) {
+	order, err := store.Orders.FindByID(ctx, orderId)
+	if err != nil {
+		return http.StatusNotFound, "order not found"
+	}
+
+	from := order.CurrentStatus()
+	legal, permitted := models.CanTransitionOrder(from, to, userType)
+	if !legal {
+		return http.StatusConflict, fmt.Sprintf("an order cannot move from %s to %s", from, to)
+	}
+	if !permitted {
+		return http.StatusForbidden, fmt.Sprintf("%s cannot move an order from %s to %s", userType, from, to)
+	}
 
-	return order.Order_id
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	change := models.OrderStatusChange{From: from, To: to, Changed_by: userId, Changed_at: now}
+
+	err = store.Orders.UpdateStatus(ctx, orderId, order.Status, change, fields)
+	if err == repository.ErrConflict {
+		return http.StatusConflict, "the order status was changed by someone else, please retry"
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "error while updating the order status"
+	}
+	Events.Publish(events.TopicOrders, events.OrderStatusChanged, gin.H{
+		"order_id":   orderId,
+		"table_id":   order.Table_id,
+		"from":       from,
+		"to":         to,
+		"changed_by": userId,
+		"changed_at": now,
+	})
+
+	return http.StatusOK, ""
+}
+
+// orderServer returns who looks after the order. Orders from before servers
+// were recorded belong to whoever created them.
+func orderServer(order models.Order) string {
+	if order.Server_id != nil {
+		return *order.Server_id
+	}
+	if len(order.Status_history) > 0 {
+		return order.Status_history[0].Changed_by
+	}
+	return ""
+}
+
+// setInitialStatus gives a new order its status and the first history entry.
+func setInitialStatus(order *models.Order, status string, userId string) {
+	order.Status = &status
+	order.Status_history = []models.OrderStatusChange{{
+		To:         status,
+		Changed_by: userId,
+		Changed_at: order.Created_at,
+	}}
+}
+
+// publishOrderCreated tells the floor about a new order, and that its table
+// is seated.
+func publishOrderCreated(order models.Order) {
+	Events.Publish(events.TopicOrders, events.OrderCreated, order)
+	if order.Table_id != nil {
+		Events.Publish(events.TopicTables, events.TableSeated, gin.H{"table_id": *order.Table_id, "order_id": order.Order_id})
+	}
 }
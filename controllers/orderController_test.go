@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateOrderIsAllOrNothing(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+	router.PATCH("/orders/:order_id", UpdateOrder(store))
+	ctx := context.Background()
+
+	require.NoError(t, store.Users.Insert(ctx, models.User{ID: primitive.NewObjectID(), User_id: "server-2"}))
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 1), burger)
+
+	// a placed order cannot be paid, so the new server is not taken either
+	w := performRequest(router, "PATCH", "/orders/"+orderId, gin.H{"server_id": "server-2", "status": models.OrderPaid})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	order, err := store.Orders.FindByID(ctx, orderId)
+	require.NoError(t, err)
+	assert.Equal(t, "test-user", *order.Server_id)
+	assert.Equal(t, models.OrderPlaced, *order.Status)
+
+	w = performRequest(router, "PATCH", "/orders/"+orderId, gin.H{"server_id": "server-2", "status": models.OrderInKitchen})
+	require.Equal(t, http.StatusOK, w.Code)
+	order = decode[models.Order](t, w.Body.Bytes())
+	assert.Equal(t, "server-2", *order.Server_id)
+	assert.Equal(t, models.OrderInKitchen, *order.Status)
+}
+
+func TestUpdateOrderWithoutIdInPath(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+	router.PATCH("/orders", UpdateOrder(store))
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 1), burger)
+
+	// older clients name the order in the body
+	w := performRequest(router, "PATCH", "/orders", gin.H{"order_id": orderId, "status": models.OrderInKitchen})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.OrderInKitchen, *decode[models.Order](t, w.Body.Bytes()).Status)
+	w = performRequest(router, "PATCH", "/orders", gin.H{"status": models.OrderReady})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -2,50 +2,102 @@ package controllers
 
 import (
 	"context"
-	"log"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/go-playground/validator/v10"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderItemPack struct {
 	Table_id    *string
 	Order_items []models.OrderItem
+	Combos      []models.ComboOrder
 }
 
-var OrderitemCollection *mongo.Collection = database.OpenCollection(database.Client, "orderItem")
+// OrderItemView is one order item joined with its food and table. Total is
+// the share of the payment due for this item, after discounts and with tax.
+// Adjustment is VOID or COMP when an approved adjustment reversed the item.
+// Price is the price of one item in its variant with its modifiers, and
+// Amount the price of Quantity items.
+type OrderItemView struct {
+	Order_item_id string                    `json:"order_item_id"`
+	Amount        float64                   `json:"amount"`
+	Food_name     *string                   `json:"food_name"`
+	Food_image    *string                   `json:"food_image"`
+	Table_number  *int                      `json:"table_number"`
+	Table_id      string                    `json:"table_id"`
+	Order_id      string                    `json:"order_id"`
+	Price         float64                   `json:"price"`
+	Quantity      int                       `json:"quantity"`
+	Variant       *string                   `json:"variant"`
+	Category      string                    `json:"category"`
+	Total         float64                   `json:"total"`
+	Adjustment    *string                   `json:"adjustment"`
+	Modifiers     []models.SelectedModifier `json:"modifiers"`
+	Combo         *models.OrderItemCombo    `json:"combo"`
+}
 
-func GetOrderItems() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		result, err := OrderitemCollection.Find(context.TODO(), bson.M{})
+// AdjustmentLine is an approved void, comp or refund as shown on the bill.
+type AdjustmentLine struct {
+	Adjustment_id string  `json:"adjustment_id"`
+	Type          string  `json:"type"`
+	Reason_code   string  `json:"reason_code"`
+	Order_item_id *string `json:"order_item_id"`
+	Amount        float64 `json:"amount"`
+}
 
+// OrderItemsSummary groups the items of an order with the amount due for them.
+// Items_total is the menu price of the items, Subtotal what is left of it after
+// voids, comps and discounts and without tax, and Payment_due the grand total
+// including tax and the service charge, less refunds.
+type OrderItemsSummary struct {
+	Payment_due      float64                    `json:"payment_due"`
+	Items_total      float64                    `json:"items_total"`
+	Discounts        []helpers.DiscountLine     `json:"discounts"`
+	Discount_total   float64                    `json:"discount_total"`
+	Subtotal         float64                    `json:"subtotal"`
+	Taxes            []helpers.TaxLine          `json:"taxes"`
+	Tax_total        float64                    `json:"tax_total"`
+	Service_charge   *helpers.ServiceChargeLine `json:"service_charge"`
+	Total_count      int                        `json:"total_count"`
+	Table_number     *int                       `json:"table_number"`
+	Order_items      []OrderItemView            `json:"order_items"`
+	Adjustments      []AdjustmentLine           `json:"adjustments"`
+	Adjustment_total float64                    `json:"adjustment_total"`
+}
+
+func GetOrderItems(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		allOrderItems, err := store.OrderItems.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing order items"})
 			return
 		}
-		var allOrderItems []bson.M
-		if err = result.All(ctx, &allOrderItems); err != nil {
-			log.Fatal(err)
-			return
-		}
 		c.JSON(http.StatusOK, allOrderItems)
 	}
 }
 
-func GetOrderItemsByOrder() gin.HandlerFunc {
+func GetOrderItemsByOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
 		orderId := c.Param("order_id")
 
-		allOrderItems, err := ItemsByOrder(orderId)
+		allOrderItems, err := ItemsByOrder(ctx, store, orderId)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing the order items by order"})
 			return
@@ -54,142 +106,181 @@ func GetOrderItemsByOrder() gin.HandlerFunc {
 	}
 }
 
-func ItemsByOrder(id string) (orderItems []primitive.M, err error) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-	matchStage := bson.D{
-		{Key: "$match", Value: bson.D{
-			{Key: "order_id", Value: id},
-		}},
-	}
-
-	lookupStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "food"},
-			{Key: "localField", Value: "food_id"},
-			{Key: "foreignField", Value: "food_id"},
-			{Key: "as", Value: "food"},
-		}},
-	}
-
-	unwindStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$food"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	lookupOrderStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "order"},
-			{Key: "localField", Value: "order_id"},
-			{Key: "foreignField", Value: "order_id"},
-			{Key: "as", Value: "order"},
-		}},
-	}
-
-	unwindOrderStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$order"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	lookupTableStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "table"},
-			{Key: "localField", Value: "order.table_id"},
-			{Key: "foreignField", Value: "table_id"},
-			{Key: "as", Value: "table"},
-		}},
-	}
-
-	unwindTableStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$table"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	projectStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "amount", Value: "$food.price"},
-			{Key: "total_count", Value: 1},
-			{Key: "food_name", Value: "$food.name"},
-			{Key: "food_image", Value: "$food.food_image"},
-			{Key: "table_number", Value: "$table.table_number"},
-			{Key: "table_id", Value: "$table.table_id"},
-			{Key: "order_id", Value: "$order.order_id"},
-			{Key: "price", Value: "$food.price"},
-			{Key: "quantity", Value: 1},
-		}},
-	}
-
-	groupStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "order_id", Value: "$order_id"},
-				{Key: "table_id", Value: "$table_id"},
-				{Key: "table_number", Value: "$table_number"},
-			}},
-			{Key: "payment_due", Value: bson.D{
-				{Key: "$sum", Value: "$amount"},
-			}},
-			{Key: "total_count", Value: bson.D{
-				{Key: "$sum", Value: 1},
-			}},
-			{Key: "order_items", Value: bson.D{
-				{Key: "$push", Value: "$$ROOT"},
-			}},
-		}},
-	}
-
-	projectStage2 := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "payment_due", Value: 1},
-			{Key: "total_count", Value: 1},
-			{Key: "table_number", Value: "$_id.table_number"},
-			{Key: "order_items", Value: 1},
-		}},
-	}
-
-	result, err := OrderitemCollection.Aggregate(ctx, mongo.Pipeline{
-		matchStage,
-		lookupStage,
-		unwindStage,
-		lookupOrderStage,
-		unwindOrderStage,
-		lookupTableStage,
-		unwindTableStage,
-		projectStage,
-		groupStage,
-		projectStage2})
+// ItemsByOrder joins the items of an order with their food and the order's
+// table, reverses voided and comped items, takes off the discount of the
+// order's coupon, taxes the food prices by category, adds the service charge of
+// large parties and takes off refunds into the payment due.
+// Like a grouping aggregation it returns one summary per order, or none when the
+// order has no items.
+func ItemsByOrder(ctx context.Context, store *repository.Store, id string) ([]OrderItemsSummary, error) {
+	orderItems, err := store.OrderItems.FindByOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(orderItems) == 0 {
+		return []OrderItemsSummary{}, nil
+	}
 
+	foodIds := make([]string, 0, len(orderItems))
+	for _, orderItem := range orderItems {
+		if orderItem.Food_id != nil {
+			foodIds = append(foodIds, *orderItem.Food_id)
+		}
+	}
+	foodsById, err := foodsByID(ctx, store, foodIds)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	if err := result.All(ctx, &orderItems); err != nil {
-		panic(err)
+	// the order or its table may have been removed, the items are still listed
+	var table models.Table
+	order, err := store.Orders.FindByID(ctx, id)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	if order.Table_id != nil {
+		table, err = store.Tables.FindByID(ctx, *order.Table_id)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
 	}
 
-	defer cancel()
+	summary := OrderItemsSummary{Table_number: table.Table_number, Discounts: []helpers.DiscountLine{}, Adjustments: []AdjustmentLine{}}
+	var lines []helpers.DiscountableLine
+	itemIndex := map[string]int{}
 
-	return orderItems, err
+	for _, orderItem := range orderItems {
+		var food models.Food
+		if orderItem.Food_id != nil {
+			food = foodsById[*orderItem.Food_id]
+		}
 
+		// the line is priced as the item was ordered, later menu changes
+		// leave it alone
+		var price float64
+		if orderItem.Unit_price != nil {
+			price = *orderItem.Unit_price
+		}
+		quantity := 1
+		if orderItem.Quantity != nil {
+			quantity = *orderItem.Quantity
+		}
+		amount := toFixed(price*float64(quantity), 2)
+
+		summary.Order_items = append(summary.Order_items, OrderItemView{
+			Order_item_id: orderItem.Order_item_id,
+			Amount:        amount,
+			Food_name:     food.Name,
+			Food_image:    food.Food_image,
+			Table_number:  table.Table_number,
+			Table_id:      table.Table_id,
+			Order_id:      order.Order_id,
+			Price:         price,
+			Quantity:      quantity,
+			Variant:       orderItem.Variant,
+			Category:      food.Category,
+			Modifiers:     orderItem.Modifiers,
+			Combo:         orderItem.Combo,
+		})
+		itemIndex[orderItem.Order_item_id] = len(lines)
+		lines = append(lines, helpers.DiscountableLine{Category: food.Category, Amount: amount, Quantity: quantity})
+		summary.Items_total += amount
+		summary.Total_count += quantity
+	}
+	summary.Items_total = toFixed(summary.Items_total, 2)
+
+	// approved adjustments are reversing entries: a voided or comped item costs
+	// nothing from here on, a refund comes off the payment due at the end
+	adjustments, err := store.Adjustments.FindByOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var refunds float64
+	for _, adjustment := range adjustments {
+		if adjustment.Status != models.AdjustmentApproved {
+			continue
+		}
+		line := AdjustmentLine{
+			Adjustment_id: adjustment.Adjustment_id,
+			Type:          *adjustment.Type,
+			Reason_code:   *adjustment.Reason_code,
+			Order_item_id: adjustment.Order_item_id,
+		}
+		if *adjustment.Type == models.AdjustmentRefund {
+			line.Amount = *adjustment.Amount
+			refunds += line.Amount
+		} else {
+			i, ok := itemIndex[*adjustment.Order_item_id]
+			if !ok || lines[i].Amount == 0 {
+				continue
+			}
+			line.Amount = lines[i].Amount
+			lines[i].Amount = 0
+			summary.Order_items[i].Adjustment = adjustment.Type
+		}
+		summary.Adjustments = append(summary.Adjustments, line)
+		summary.Adjustment_total += line.Amount
+	}
+	summary.Adjustment_total = toFixed(summary.Adjustment_total, 2)
+
+	discounts := make([]float64, len(lines))
+	if order.Coupon_id != nil {
+		coupon, err := store.Coupons.FindByID(ctx, *order.Coupon_id)
+		if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+		if err == nil {
+			var discount helpers.DiscountLine
+			discounts, discount = helpers.CalculateDiscount(coupon, lines)
+			if discount.Amount > 0 {
+				summary.Discounts = append(summary.Discounts, discount)
+				summary.Discount_total = discount.Amount
+			}
+		}
+	}
+
+	taxable := make([]helpers.TaxableLine, len(lines))
+	gross := make([]float64, len(lines))
+	for i, line := range lines {
+		taxable[i] = helpers.TaxableLine{Category: line.Category, Amount: line.Amount - discounts[i]}
+		gross[i] = helpers.GrossAmount(line.Category, line.Amount-discounts[i])
+	}
+
+	tax := helpers.CalculateTax(taxable)
+	summary.Subtotal = tax.Subtotal
+	summary.Taxes = tax.Taxes
+	summary.Tax_total = tax.Tax_total
+	summary.Payment_due = tax.Grand_total
+
+	var guests int
+	if table.Number_of_guests != nil {
+		guests = *table.Number_of_guests
+	}
+	summary.Service_charge = helpers.ServiceCharge(guests, tax.Subtotal)
+	if summary.Service_charge != nil {
+		summary.Payment_due = toFixed(summary.Payment_due+summary.Service_charge.Amount, 2)
+	}
+
+	for i, total := range helpers.AllocateTotal(summary.Payment_due, gross) {
+		summary.Order_items[i].Total = total
+	}
+	summary.Payment_due = toFixed(summary.Payment_due-refunds, 2)
+
+	return []OrderItemsSummary{summary}, nil
 }
 
-func GetOrderItem() gin.HandlerFunc {
+func GetOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
-		orderItemId := c.Param("order_item_id")
-		var orderItem models.OrderItem
+		orderItemId := c.Param("orderItem_id")
 
-		err := OrderitemCollection.FindOne(ctx, bson.M{"order_item_id": orderItemId}).Decode(&orderItem)
+		orderItem, err := store.OrderItems.FindByID(ctx, orderItemId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing order item"})
 			return
@@ -198,96 +289,416 @@ func GetOrderItem() gin.HandlerFunc {
 	}
 }
 
-func UpdateOrderItem() gin.HandlerFunc {
+func UpdateOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var orderItem models.OrderItem
 
-		orderItemId := c.Param("order_item_id")
+		orderItemId := c.Param("orderItem_id")
 
-		filter := bson.M{"order_item_id": orderItemId}
+		if err := c.BindJSON(&orderItem); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		var updateObj primitive.D
+		existing, err := store.OrderItems.FindByID(ctx, orderItemId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing order item"})
+			return
+		}
 
-		if orderItem.Unit_price != nil {
-			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: orderItem.Unit_price})
+		// the items of a combo share its price, they change with the combo
+		if existing.Combo != nil && (orderItem.Quantity != nil || orderItem.Food_id != nil || orderItem.Variant != nil || orderItem.Modifiers != nil) {
+			c.JSON(http.StatusConflict, gin.H{"error": "the item is part of a combo, order the combo again to change it"})
+			return
 		}
 
+		var updateObj primitive.D
+
 		if orderItem.Quantity != nil {
+			if validationErr := validate.Var(*orderItem.Quantity, "min=1"); validationErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be at least 1"})
+				return
+			}
 			updateObj = append(updateObj, bson.E{Key: "quantity", Value: *orderItem.Quantity})
 		}
 
-		if orderItem.Food_id != nil {
-			updateObj = append(updateObj, bson.E{Key: "food_id", Value: *orderItem.Food_id})
+		// the price follows from the food, variant and modifiers, so a change
+		// to any of them prices the item again with the others as they were
+		if orderItem.Food_id != nil || orderItem.Variant != nil || orderItem.Modifiers != nil {
+			if orderItem.Food_id == nil {
+				orderItem.Food_id = existing.Food_id
+			}
+			if orderItem.Variant == nil {
+				orderItem.Variant = existing.Variant
+			}
+			if orderItem.Modifiers == nil {
+				orderItem.Modifiers = existing.Modifiers
+			}
+			food, err := store.Foods.FindByID(ctx, *orderItem.Food_id)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "food was not found"})
+				return
+			}
+			if _, msg := priceOrderItem(food, &orderItem); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			updateObj = append(updateObj,
+				bson.E{Key: "food_id", Value: *orderItem.Food_id},
+				bson.E{Key: "variant", Value: orderItem.Variant},
+				bson.E{Key: "modifiers", Value: orderItem.Modifiers},
+				bson.E{Key: "unit_price", Value: *orderItem.Unit_price},
+			)
 		}
 
 		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: orderItem.Updated_at})
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		err = store.OrderItems.Update(ctx, orderItemId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
 		}
-
-		result, err := OrderitemCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
 		if err != nil {
 			msg := "error while updating the order item."
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		updatedOrderItem, err := store.OrderItems.FindByID(ctx, orderItemId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing order item"})
+			return
+		}
+		Events.Publish(events.TopicOrders, events.OrderItemUpdated, updatedOrderItem)
+
+		c.JSON(http.StatusOK, updatedOrderItem)
 	}
 }
 
-func CreateOrderItem() gin.HandlerFunc {
+// CreateOrderItem creates an order for the table together with all its items.
+// Every item is validated before anything is written, and the order and items
+// are written in one transaction so a failure never leaves an order behind.
+func CreateOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var orderItemPack OrderItemPack
-		var order models.Order
 
 		if err := c.BindJSON(&orderItemPack); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		order.Order_date, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		orderItemToBeInserted := []interface{}{}
-		order.Table_id = orderItemPack.Table_id
-		order_id := OrderItemOrderCreator(order)
 
-		for _, orderItem := range orderItemPack.Order_items {
-			orderItem.Order_id = order_id
+		order, orderItems, status, errBody := createOrderItems(ctx, store, orderItemPack.Table_id, nil, orderItemPack.Order_items, orderItemPack.Combos, c.GetString("uid"))
+		if errBody != nil {
+			c.JSON(status, errBody)
+			return
+		}
 
-			validationErr := validate.Struct(orderItem)
-			if validationErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
-				return
+		c.JSON(http.StatusOK, gin.H{
+			"order_id":    order.Order_id,
+			"order_items": orderItems,
+		})
+	}
+}
+
+// createOrderItems is how orders with their items are taken, by the staff
+// and from confirmed guest orders: it validates every item, expands the combos
+// into the items of their slots, writes the order and items in one
+// transaction and sends the items to the kitchen. With into the items are
+// added to that open order instead of a new one. On failure it returns the
+// HTTP status and the error body to send back.
+func createOrderItems(ctx context.Context, store *repository.Store, tableId *string, into *models.Order, items []models.OrderItem, combos []models.ComboOrder, userId string) (models.Order, []models.OrderItem, int, gin.H) {
+	if len(items) == 0 && len(combos) == 0 {
+		return models.Order{}, nil, http.StatusBadRequest, gin.H{"error": "an order needs at least one item"}
+	}
+
+	// items for a merged table are seated at the primary table of the group
+	if tableId != nil {
+		table, err := seatingTable(ctx, store, *tableId)
+		if err != nil && err != repository.ErrNotFound {
+			return models.Order{}, nil, http.StatusInternalServerError, gin.H{"error": "error while fetching table details"}
+		}
+		if err == nil {
+			tableId = &table.Table_id
+		}
+	}
+
+	order := newItemsOrder(tableId, userId)
+	takenAt := order.Order_date
+	if into != nil {
+		order = *into
+	}
+	if validationErr := validate.Struct(order); validationErr != nil {
+		return order, nil, http.StatusBadRequest, gin.H{"error": validationErr.Error()}
+	}
+
+	combosById, err := combosByID(ctx, store, combos)
+	if err != nil {
+		return order, nil, http.StatusInternalServerError, gin.H{"error": "error while fetching the combos of the order"}
+	}
+
+	var foodIds []string
+	for _, orderItem := range items {
+		if orderItem.Food_id != nil {
+			foodIds = append(foodIds, *orderItem.Food_id)
+		}
+	}
+	for _, combo := range combosById {
+		for _, slot := range combo.Slots {
+			foodIds = append(foodIds, slot.Food_ids...)
+		}
+	}
+	for _, ordered := range combos {
+		for _, choice := range ordered.Choices {
+			if choice.Food_id != nil {
+				foodIds = append(foodIds, *choice.Food_id)
 			}
-			orderItem.ID = primitive.NewObjectID()
-			orderItem.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			orderItem.Order_item_id = orderItem.ID.Hex()
-			var num = toFixed(*orderItem.Unit_price, 2)
-			orderItem.Unit_price = &num
-			orderItemToBeInserted = append(orderItemToBeInserted, orderItem)
+		}
+	}
+	foodsById, err := foodsByID(ctx, store, foodIds)
+	if err != nil {
+		return order, nil, http.StatusInternalServerError, gin.H{"error": "error while fetching the foods of the items"}
+	}
+	served, err := menusServedAt(ctx, store, foodsById, takenAt)
+	if err != nil {
+		return order, nil, http.StatusInternalServerError, gin.H{"error": "error while fetching the menus of the items"}
+	}
 
+	orderItems := make([]models.OrderItem, 0, len(items))
+	var itemErrors []gin.H
+
+	for i, orderItem := range items {
+		orderItem.Order_id = order.Order_id
+
+		validationErr := validate.Struct(orderItem)
+		if validationErr != nil {
+			itemErrors = append(itemErrors, itemValidationErrors(i, validationErr)...)
+			continue
+		}
+		food, found := foodsById[*orderItem.Food_id]
+		if !found {
+			itemErrors = append(itemErrors, itemRuleError(i, "Food_id", "exists", "the food was not found"))
+			continue
+		}
+		if !foodServed(served, food) {
+			itemErrors = append(itemErrors, itemRuleError(i, "Food_id", "menu", menuNotServed))
+			continue
+		}
+		if field, msg := priceOrderItem(food, &orderItem); msg != "" {
+			itemErrors = append(itemErrors, itemRuleError(i, field, strings.ToLower(field), msg))
+			continue
+		}
+		orderItems = append(orderItems, orderItem)
+	}
+
+	// the errors of combos are told apart from those of items by combo_index
+	for i, ordered := range combos {
+		if validationErr := validate.Struct(ordered); validationErr != nil {
+			itemErrors = append(itemErrors, comboErrors(itemValidationErrors(i, validationErr))...)
+			continue
+		}
+		combo, found := combosById[*ordered.Combo_id]
+		if !found {
+			itemErrors = append(itemErrors, comboErrors([]gin.H{itemRuleError(i, "Combo_id", "exists", "the combo was not found")})...)
+			continue
+		}
+		comboItems, field, msg := expandCombo(combo, ordered, foodsById)
+		if msg != "" {
+			itemErrors = append(itemErrors, comboErrors([]gin.H{itemRuleError(i, field, strings.ToLower(field), msg)})...)
+			continue
+		}
+		if slices.ContainsFunc(comboItems, func(item models.OrderItem) bool { return !foodServed(served, foodsById[*item.Food_id]) }) {
+			itemErrors = append(itemErrors, comboErrors([]gin.H{itemRuleError(i, "Choices", "menu", menuNotServed)})...)
+			continue
+		}
+		orderItems = append(orderItems, comboItems...)
+	}
+
+	for i := range orderItems {
+		orderItem := &orderItems[i]
+		orderItem.Order_id = order.Order_id
+		orderItem.ID = primitive.NewObjectID()
+		orderItem.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		orderItem.Order_item_id = orderItem.ID.Hex()
+	}
+
+	if len(itemErrors) > 0 {
+		return order, nil, http.StatusBadRequest, gin.H{
+			"error":  "order items failed validation, nothing was created",
+			"detail": itemErrors,
+		}
+	}
+
+	if into != nil {
+		err := store.Orders.AddItems(ctx, order.Order_id, orderItems)
+		if err == repository.ErrConflict {
+			return order, nil, http.StatusConflict, gin.H{"error": "the order was closed in the meantime, nothing was added"}
 		}
-		insertedOrderItems, err := OrderitemCollection.InsertMany(ctx, orderItemToBeInserted)
 		if err != nil {
-			log.Fatal(err)
+			return order, nil, http.StatusInternalServerError, gin.H{"error": "order items were not added, all changes were rolled back"}
+		}
+	} else {
+		if err := store.Orders.CreateWithItems(ctx, order, orderItems); err != nil {
+			return order, nil, http.StatusInternalServerError, gin.H{"error": "order was not created, all changes were rolled back"}
+		}
+		publishOrderCreated(order)
+	}
+	for _, orderItem := range orderItems {
+		Events.Publish(events.TopicOrders, events.OrderItemAdded, orderItem)
+	}
+	sendToKitchen(ctx, store, order.Order_id, orderItems)
+
+	return order, orderItems, http.StatusOK, nil
+}
+
+// newItemsOrder builds, without saving, the order that createOrderItems files the items under.
+func newItemsOrder(tableId *string, userId string) models.Order {
+	var order models.Order
+
+	order.Order_date, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	order.Table_id = tableId
+	order.Server_id = &userId
+	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	order.ID = primitive.NewObjectID()
+	order.Order_id = order.ID.Hex()
+	setInitialStatus(&order, models.OrderPlaced, userId)
+
+	return order
+}
+
+// priceOrderItem checks the variant and modifiers of the item against its
+// food, and sets the price of one item from them. On failure it returns the
+// field at fault and why.
+func priceOrderItem(food models.Food, orderItem *models.OrderItem) (string, string) {
+	variant, price, msg := helpers.VariantPrice(food, orderItem.Variant)
+	if msg != "" {
+		return "Variant", msg
+	}
+	// the modifiers take their names and prices from the food
+	selected, msg := helpers.SelectModifiers(food, orderItem.Modifiers)
+	if msg != "" {
+		return "Modifiers", msg
+	}
+	unitPrice := toFixed(price+helpers.ModifierTotal(selected), 2)
+	orderItem.Variant = variant
+	orderItem.Modifiers = selected
+	orderItem.Unit_price = &unitPrice
+	return "", ""
+}
+
+// itemRuleError describes why item number index broke a rule of its food.
+func itemRuleError(index int, field string, rule string, msg string) gin.H {
+	return gin.H{"index": index, "field": field, "rule": rule, "message": msg}
+}
+
+// comboErrors moves the index of the details to combo_index.
+func comboErrors(details []gin.H) []gin.H {
+	for _, detail := range details {
+		detail["combo_index"] = detail["index"]
+		delete(detail, "index")
+	}
+	return details
+}
+
+// combosByID loads the combos that are ordered by their id.
+func combosByID(ctx context.Context, store *repository.Store, ordered []models.ComboOrder) (map[string]models.Combo, error) {
+	if len(ordered) == 0 {
+		return map[string]models.Combo{}, nil
+	}
+	var comboIds []string
+	for _, combo := range ordered {
+		if combo.Combo_id != nil {
+			comboIds = append(comboIds, *combo.Combo_id)
+		}
+	}
+	combos, err := store.Combos.FindByIDs(ctx, comboIds)
+	if err != nil {
+		return nil, err
+	}
+	combosById := make(map[string]models.Combo, len(combos))
+	for _, combo := range combos {
+		combosById[combo.Combo_id] = combo
+	}
+	return combosById, nil
+}
+
+// menuNotServed is why a food whose menu is not served is refused.
+const menuNotServed = "the menu of the food is not served at this time"
+
+// menusServedAt loads the menus of the foods and tells for each whether it is
+// served at t.
+func menusServedAt(ctx context.Context, store *repository.Store, foodsById map[string]models.Food, t time.Time) (map[string]bool, error) {
+	var menuIds []string
+	for _, food := range foodsById {
+		if food.Menu_id != nil {
+			menuIds = append(menuIds, *food.Menu_id)
 		}
-		c.JSON(http.StatusOK, insertedOrderItems)
+	}
+	if len(menuIds) == 0 {
+		return map[string]bool{}, nil
+	}
+	menus, err := store.Menus.FindByIDs(ctx, menuIds)
+	if err != nil {
+		return nil, err
+	}
+	served := make(map[string]bool, len(menus))
+	for _, menu := range menus {
+		served[menu.Menu_id] = helpers.MenuActiveAt(menu, t)
+	}
+	return served, nil
+}
+
+// foodServed reports whether the menu of the food is served. A food without
+// a menu, or whose menu was removed, is always served.
+func foodServed(served map[string]bool, food models.Food) bool {
+	if food.Menu_id == nil {
+		return true
+	}
+	active, found := served[*food.Menu_id]
+	return !found || active
+}
+
+// foodsByID loads the foods by their id.
+func foodsByID(ctx context.Context, store *repository.Store, foodIds []string) (map[string]models.Food, error) {
+	foods, err := store.Foods.FindByIDs(ctx, foodIds)
+	if err != nil {
+		return nil, err
+	}
+	foodsById := make(map[string]models.Food, len(foods))
+	for _, food := range foods {
+		foodsById[food.Food_id] = food
+	}
+	return foodsById, nil
+}
+
+// itemValidationErrors describes why item number index failed validation.
+func itemValidationErrors(index int, err error) []gin.H {
+	var details []gin.H
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return []gin.H{{"index": index, "message": err.Error()}}
+	}
 
+	for _, fieldErr := range validationErrs {
+		details = append(details, gin.H{
+			"index":   index,
+			"field":   fieldErr.Field(),
+			"rule":    fieldErr.Tag(),
+			"message": fieldErr.Error(),
+		})
 	}
+	return details
 }
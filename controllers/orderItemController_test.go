@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupOrderRouter registers the order handlers behind a stand-in for the
+// authentication middleware that logs every request in as role.
+func setupOrderRouter(store *repository.Store, role string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("uid", "test-user")
+		c.Set("user_type", role)
+	})
+	router.POST("/foods", CreateFood(store))
+	router.PATCH("/foods/:food_id", UpdateFood(store))
+	router.POST("/tables", CreateTable(store))
+	router.POST("/orders", CreateOrder(store))
+	router.POST("/orders/:order_id/transition", TransitionOrder(store))
+	router.POST("/orderItems", CreateOrderItem(store))
+	router.GET("/orderItems-order/:order_id", GetOrderItemsByOrder(store))
+	router.POST("/invoices", CreateInvoice(store))
+	router.GET("/invoices/:invoice_id", GetInvoice(store))
+	router.POST("/invoices/:invoice_id/payments", CreatePayment(store))
+	router.GET("/invoices/:invoice_id/split", SplitInvoice(store))
+	router.GET("/invoices/:invoice_id/pdf", GetInvoicePDF(store))
+	router.GET("/invoices/:invoice_id/html", GetInvoiceHTML(store))
+	router.GET("/reports/tips", GetTipReport(store))
+	router.GET("/reports/adjustments", GetAdjustmentReport(store))
+	router.POST("/adjustments", CreateAdjustment(store))
+	router.POST("/adjustments/:adjustment_id/approve", ApproveAdjustment(store))
+	router.POST("/adjustments/:adjustment_id/reject", RejectAdjustment(store))
+	router.POST("/coupons", CreateCoupon(store))
+	router.POST("/orders/:order_id/coupon", ApplyCoupon(store))
+	router.DELETE("/orders/:order_id/coupon", RemoveCoupon(store))
+	return router
+}
+
+func decode[T any](t *testing.T, body []byte) T {
+	var value T
+	assert.NoError(t, json.Unmarshal(body, &value))
+	return value
+}
+
+func createTestFood(t *testing.T, router *gin.Engine, name string, category string, price float64) string {
+	w := performRequest(router, "POST", "/foods", []gin.H{{
+		"name":       name,
+		"price":      price,
+		"food_image": "http://example.com/" + name + ".png",
+		"category":   category,
+	}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	created := decode[struct {
+		Foods []struct {
+			Food_id string `json:"food_id"`
+		} `json:"foods"`
+	}](t, w.Body.Bytes())
+	return created.Foods[0].Food_id
+}
+
+func createTestTable(t *testing.T, router *gin.Engine, guests int, number int) string {
+	w := performRequest(router, "POST", "/tables", gin.H{"number_of_guests": guests, "table_number": number})
+	assert.Equal(t, http.StatusOK, w.Code)
+	return decode[models.Table](t, w.Body.Bytes()).Table_id
+}
+
+func TestCreateOrderItemAndInvoice(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10.5)
+	fries := createTestFood(t, router, "Fries", "Sides", 4)
+	tableId := createTestTable(t, router, 4, 7)
+
+	w := performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id": tableId,
+		"order_items": []gin.H{
+			{"food_id": burger, "quantity": 1},
+			{"food_id": fries, "quantity": 1},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	orderId := decode[struct {
+		Order_id string `json:"order_id"`
+	}](t, w.Body.Bytes()).Order_id
+
+	w = performRequest(router, "GET", "/orderItems-order/"+orderId, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	summaries := decode[[]OrderItemsSummary](t, w.Body.Bytes())
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, 14.5, summaries[0].Payment_due)
+	assert.Equal(t, 2, summaries[0].Total_count)
+	assert.Equal(t, 7, *summaries[0].Table_number)
+
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	assert.Equal(t, http.StatusOK, w.Code)
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, 14.5, view.Payment_due)
+	assert.Equal(t, "PENDING", *view.Payment_status)
+}
+
+// createTestOrder orders one item of each food on the table and returns the order id.
+func createTestOrder(t *testing.T, router *gin.Engine, tableId string, foodIds ...string) string {
+	var items []gin.H
+	for _, foodId := range foodIds {
+		items = append(items, gin.H{"food_id": foodId, "quantity": 1})
+	}
+	w := performRequest(router, "POST", "/orderItems", gin.H{"table_id": tableId, "order_items": items})
+	assert.Equal(t, http.StatusOK, w.Code)
+	return decode[struct {
+		Order_id string `json:"order_id"`
+	}](t, w.Body.Bytes()).Order_id
+}
+
+func TestInvoiceTaxBreakdown(t *testing.T) {
+	inclusive := true
+	helpers.ConfigureTax(config.TaxConfig{
+		Default: config.TaxRate{Name: "GST", Percent: 10},
+		Rules: []config.TaxRule{
+			{Category: "Alcohol", TaxRate: config.TaxRate{Name: "VAT", Percent: 20}, Inclusive: &inclusive},
+		},
+	})
+	defer helpers.ConfigureTax(config.TaxConfig{})
+
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	wine := createTestFood(t, router, "Wine", "alcohol", 6)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 3), burger, wine)
+
+	w := performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	assert.Equal(t, http.StatusOK, w.Code)
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, 15.0, view.Subtotal)
+	assert.Equal(t, []helpers.TaxLine{
+		{Name: "GST", Percent: 10, Taxable: 10, Tax: 1},
+		{Name: "VAT", Percent: 20, Inclusive: true, Taxable: 5, Tax: 1},
+	}, view.Taxes)
+	assert.Equal(t, 2.0, view.Tax_total)
+	assert.Equal(t, 17.0, view.Grand_total)
+	assert.Equal(t, 17.0, view.Payment_due)
+}
+
+func TestCreateOrderItemRollsBackOnInvalidItem(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10.5)
+	tableId := createTestTable(t, router, 4, 7)
+
+	w := performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id": tableId,
+		"order_items": []gin.H{
+			{"food_id": burger, "quantity": 1},
+			{"food_id": burger, "quantity": 2},
+			{"food_id": burger, "quantity": 0},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	response := decode[struct {
+		Detail []struct {
+			Index int    `json:"index"`
+			Field string `json:"field"`
+		} `json:"detail"`
+	}](t, w.Body.Bytes())
+	assert.Len(t, response.Detail, 1)
+	assert.Equal(t, 2, response.Detail[0].Index)
+	assert.Equal(t, "Quantity", response.Detail[0].Field)
+
+	// neither the order nor the valid items were written
+	orders, _ := store.Orders.List(context.Background())
+	orderItems, _ := store.OrderItems.List(context.Background())
+	assert.Empty(t, orders)
+	assert.Empty(t, orderItems)
+}
+
+func TestTransitionOrder(t *testing.T) {
+	store := repository.NewMemoryStore()
+	server := setupOrderRouter(store, models.RoleServer)
+	kitchen := setupOrderRouter(store, models.RoleKitchen)
+
+	tableId := createTestTable(t, server, 2, 3)
+	w := performRequest(server, "POST", "/orders", gin.H{"table_id": tableId, "order_date": "2026-10-16T12:00:00Z"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	orderId := decode[models.Order](t, w.Body.Bytes()).Order_id
+
+	w = performRequest(server, "POST", "/orders/"+orderId+"/transition", gin.H{"status": models.OrderPlaced})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// skipping the kitchen is not a legal move
+	w = performRequest(server, "POST", "/orders/"+orderId+"/transition", gin.H{"status": models.OrderServed})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(kitchen, "POST", "/orders/"+orderId+"/transition", gin.H{"status": models.OrderInKitchen})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// only the kitchen or a manager marks food ready
+	w = performRequest(server, "POST", "/orders/"+orderId+"/transition", gin.H{"status": models.OrderReady})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequest(kitchen, "POST", "/orders/"+orderId+"/transition", gin.H{"status": models.OrderReady})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	order := decode[models.Order](t, w.Body.Bytes())
+	assert.Equal(t, models.OrderReady, *order.Status)
+	assert.Len(t, order.Status_history, 4)
+	assert.Equal(t, "test-user", order.Status_history[3].Changed_by)
+}
+
+func TestOrderItemModifiers(t *testing.T) {
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupOrderRouter(store, models.RoleServer)
+	tableId := createTestTable(t, router, 2, 3)
+
+	w := performRequest(router, "POST", "/foods", []gin.H{{
+		"name":       "Burger",
+		"price":      10,
+		"food_image": "http://example.com/burger.png",
+		"category":   "Mains",
+		"modifier_groups": []gin.H{
+			{"name": "Sauce", "required": true, "max_select": 1, "modifiers": []gin.H{{"name": "Ketchup"}, {"name": "Mayo", "price_delta": 0.5}}},
+			{"name": "Extras", "max_select": 2, "modifiers": []gin.H{{"name": "Extra cheese", "price_delta": 1.5}, {"name": "No onions"}}},
+		},
+	}})
+	require.Equal(t, http.StatusOK, w.Code)
+	food := decode[struct {
+		Foods []struct {
+			Food_id         string                 `json:"food_id"`
+			Modifier_groups []models.ModifierGroup `json:"modifier_groups"`
+		} `json:"foods"`
+	}](t, w.Body.Bytes()).Foods[0]
+	sauce, extras := food.Modifier_groups[0], food.Modifier_groups[1]
+
+	// the sauce is not optional
+	w = performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id": tableId,
+		"order_items": []gin.H{{"food_id": food.Food_id, "quantity": 1,
+			"modifiers": []gin.H{{"group_id": extras.Group_id, "modifier_id": extras.Modifiers[1].Modifier_id}}}},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	detail := decode[struct {
+		Detail []struct {
+			Field string `json:"field"`
+		} `json:"detail"`
+	}](t, w.Body.Bytes()).Detail
+	require.Len(t, detail, 1)
+	assert.Equal(t, "Modifiers", detail[0].Field)
+
+	w = performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id": tableId,
+		"order_items": []gin.H{{"food_id": food.Food_id, "quantity": 1,
+			"modifiers": []gin.H{
+				{"group_id": sauce.Group_id, "modifier_id": sauce.Modifiers[1].Modifier_id},
+				{"group_id": extras.Group_id, "modifier_id": extras.Modifiers[0].Modifier_id},
+				{"group_id": extras.Group_id, "modifier_id": extras.Modifiers[1].Modifier_id},
+			}}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	orderId := decode[gin.H](t, w.Body.Bytes())["order_id"].(string)
+
+	w = performRequest(router, "GET", "/orderItems-order/"+orderId, nil)
+	summary := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0]
+	assert.Equal(t, 12.0, summary.Items_total)
+	require.Len(t, summary.Order_items[0].Modifiers, 3)
+	assert.Equal(t, "Mayo", summary.Order_items[0].Modifiers[0].Name)
+
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	require.Equal(t, http.StatusOK, w.Code)
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	assert.Equal(t, summary.Payment_due, decode[InvoiceViewFormat](t, w.Body.Bytes()).Payment_due)
+
+	tickets, _ := store.Tickets.FindByOrder(context.Background(), orderId)
+	require.Len(t, tickets, 1)
+	assert.Equal(t, []string{"Mayo", "Extra cheese", "No onions"}, tickets[0].Items[0].Modifiers)
+}
+
+func TestOrderItemQuantityAndVariant(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+	tableId := createTestTable(t, router, 4, 5)
+
+	w := performRequest(router, "POST", "/foods", []gin.H{{
+		"name":       "Pizza",
+		"price":      12,
+		"food_image": "http://example.com/pizza.png",
+		"category":   "Mains",
+		"variants":   []gin.H{{"name": "Small", "price": 9}, {"name": "Large", "price": 14}},
+	}})
+	require.Equal(t, http.StatusOK, w.Code)
+	pizza := decode[gin.H](t, w.Body.Bytes())["foods"].([]any)[0].(map[string]any)["food_id"].(string)
+	burger := createTestFood(t, router, "Burger", "Mains", 10.5)
+
+	// a food with variants is ordered in one of them
+	w = performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id":    tableId,
+		"order_items": []gin.H{{"food_id": pizza, "quantity": 1}},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(router, "POST", "/orderItems", gin.H{
+		"table_id": tableId,
+		"order_items": []gin.H{
+			{"food_id": pizza, "quantity": 2, "variant": "large"},
+			{"food_id": burger, "quantity": 3},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	orderId := decode[gin.H](t, w.Body.Bytes())["order_id"].(string)
+
+	// the price is kept from order time
+	w = performRequest(router, "PATCH", "/foods/"+burger, gin.H{"price": 20})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(router, "GET", "/orderItems-order/"+orderId, nil)
+	summary := decode[[]OrderItemsSummary](t, w.Body.Bytes())[0]
+	require.Len(t, summary.Order_items, 2)
+	assert.Equal(t, "Large", *summary.Order_items[0].Variant)
+	assert.Equal(t, 14.0, summary.Order_items[0].Price)
+	assert.Equal(t, 28.0, summary.Order_items[0].Amount)
+	assert.Equal(t, 31.5, summary.Order_items[1].Amount)
+	assert.Equal(t, 59.5, summary.Items_total)
+	assert.Equal(t, 5, summary.Total_count)
+}
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvoicePayments is the payment state of an invoice.
+type InvoicePayments struct {
+	Invoice_id     string           `json:"invoice_id"`
+	Payment_status string           `json:"payment_status"`
+	Payment_due    float64          `json:"payment_due"`
+	Amount_paid    float64          `json:"amount_paid"`
+	Balance_due    float64          `json:"balance_due"`
+	Tip_total      float64          `json:"tip_total"`
+	Payments       []models.Payment `json:"payments"`
+}
+
+// invoicePayments loads an invoice with what is due on it and its payments.
+func invoicePayments(ctx context.Context, store *repository.Store, invoiceId string) (models.Invoice, OrderItemsSummary, InvoicePayments, error) {
+	var summary OrderItemsSummary
+	var state InvoicePayments
+
+	invoice, err := store.Invoices.FindByID(ctx, invoiceId)
+	if err != nil {
+		return invoice, summary, state, err
+	}
+	summaries, err := ItemsByOrder(ctx, store, invoice.Order_id)
+	if err != nil {
+		return invoice, summary, state, err
+	}
+	if len(summaries) > 0 {
+		summary = summaries[0]
+	}
+	payments, err := store.Payments.FindByInvoice(ctx, invoiceId)
+	if err != nil {
+		return invoice, summary, state, err
+	}
+
+	state = InvoicePayments{
+		Invoice_id:     invoice.Invoice_id,
+		Payment_status: models.PaymentPending,
+		Payment_due:    summary.Payment_due,
+		Amount_paid:    invoice.Amount_paid,
+		Balance_due:    toFixed(summary.Payment_due-invoice.Amount_paid, 2),
+		Payments:       payments,
+	}
+	if invoice.Payment_status != nil {
+		state.Payment_status = *invoice.Payment_status
+	}
+	for _, payment := range payments {
+		if payment.Tip != nil {
+			state.Tip_total += *payment.Tip
+		}
+	}
+	state.Tip_total = toFixed(state.Tip_total, 2)
+	if state.Balance_due < 0 {
+		state.Balance_due = 0
+	}
+	return invoice, summary, state, nil
+}
+
+func GetInvoicePayments(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		_, _, state, err := invoicePayments(ctx, store, c.Param("invoice_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the payments"})
+			return
+		}
+		c.JSON(http.StatusOK, state)
+	}
+}
+
+// CreatePayment records one tender towards an invoice. Without an amount it
+// pays the listed items, or the whole balance when no items are listed. The
+// invoice becomes PARTIALLY_PAID or PAID from the sum of its payments; tips are
+// paid on top and do not count towards it.
+func CreatePayment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		invoiceId := c.Param("invoice_id")
+
+		var payment models.Payment
+		if err := c.BindJSON(&payment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(payment); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		invoice, summary, state, err := invoicePayments(ctx, store, invoiceId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the invoice"})
+			return
+		}
+		if state.Balance_due <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice is already paid"})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, invoice.Order_id)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+
+		if len(payment.Order_item_ids) > 0 {
+			itemsTotal, msg := unpaidItemsTotal(summary, state.Payments, payment.Order_item_ids)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			if payment.Amount == nil {
+				payment.Amount = &itemsTotal
+			}
+		}
+		if payment.Amount == nil {
+			payment.Amount = &state.Balance_due
+		}
+		amount := toFixed(*payment.Amount, 2)
+		payment.Amount = &amount
+		if amount > state.Balance_due {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("amount %.2f is more than the balance due of %.2f", amount, state.Balance_due)})
+			return
+		}
+
+		var tip float64
+		if payment.Tip != nil {
+			tip = toFixed(*payment.Tip, 2)
+			payment.Tip = &tip
+		}
+
+		switch *payment.Method {
+		case models.PaymentCash:
+			charged := toFixed(amount+tip, 2)
+			if payment.Tendered == nil {
+				payment.Tendered = &charged
+			}
+			tendered := toFixed(*payment.Tendered, 2)
+			if tendered < charged {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tendered %.2f does not cover the amount and tip of %.2f", tendered, charged)})
+				return
+			}
+			payment.Tendered = &tendered
+			payment.Change_due = toFixed(tendered-charged, 2)
+		case models.PaymentCard:
+			if payment.Tendered != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tendered is only used for cash payments"})
+				return
+			}
+		}
+
+		payment.ID = primitive.NewObjectID()
+		payment.Payment_id = payment.ID.Hex()
+		payment.Invoice_id = invoiceId
+		payment.Server_id = orderServer(order)
+		payment.Created_by = c.GetString("uid")
+		payment.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		status := paymentStatus(invoice.Amount_paid+amount, state.Payment_due)
+
+		err = store.Invoices.AddPayment(ctx, payment, invoice.Amount_paid, status)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "another payment was recorded at the same time, please retry"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "payment was not recorded"})
+			return
+		}
+
+		Events.Publish(events.TopicInvoices, events.InvoicePaymentRecorded, gin.H{
+			"invoice_id":     invoiceId,
+			"order_id":       invoice.Order_id,
+			"payment":        payment,
+			"payment_status": status,
+		})
+		if status == models.PaymentPaid {
+			Events.Publish(events.TopicInvoices, events.InvoicePaid, gin.H{
+				"invoice_id":  invoiceId,
+				"order_id":    invoice.Order_id,
+				"amount_paid": toFixed(invoice.Amount_paid+amount, 2),
+			})
+		}
+
+		// a served order is done once it is paid; any other status is left to the staff
+		if status == models.PaymentPaid {
+			transitionOrder(ctx, store, invoice.Order_id, models.OrderPaid, c.GetString("uid"), c.GetString("user_type"), nil)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"payment":        payment,
+			"payment_status": status,
+			"amount_paid":    toFixed(invoice.Amount_paid+amount, 2),
+			"balance_due":    toFixed(state.Balance_due-amount, 2),
+		})
+	}
+}
+
+// unpaidItemsTotal adds up the totals of the items, which must belong to the
+// order and not be paid by an earlier payment.
+func unpaidItemsTotal(summary OrderItemsSummary, payments []models.Payment, orderItemIds []string) (float64, string) {
+	paid := paidItems(payments)
+	totals := map[string]float64{}
+	for _, item := range summary.Order_items {
+		totals[item.Order_item_id] = item.Total
+	}
+
+	var total float64
+	seen := map[string]bool{}
+	for _, id := range orderItemIds {
+		itemTotal, ok := totals[id]
+		if !ok {
+			return 0, "order item " + id + " is not on this invoice"
+		}
+		if paid[id] || seen[id] {
+			return 0, "order item " + id + " is already paid"
+		}
+		seen[id] = true
+		total += itemTotal
+	}
+	return toFixed(total, 2), ""
+}
+
+// paymentStatus is the status of an invoice with paid paid on due.
+func paymentStatus(paid float64, due float64) string {
+	switch {
+	case toFixed(paid, 2) >= toFixed(due, 2):
+		return models.PaymentPaid
+	case paid > 0:
+		return models.PaymentPartiallyPaid
+	default:
+		return models.PaymentPending
+	}
+}
+
+// paidItems returns the ids of the items paid for by item.
+func paidItems(payments []models.Payment) map[string]bool {
+	paid := map[string]bool{}
+	for _, payment := range payments {
+		for _, id := range payment.Order_item_ids {
+			paid[id] = true
+		}
+	}
+	return paid
+}
+
+// SplitInvoice proposes how to split the balance: evenly between ?guests=N,
+// or without guests item by item, listing the items no payment covered yet.
+func SplitInvoice(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		_, summary, state, err := invoicePayments(ctx, store, c.Param("invoice_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the invoice"})
+			return
+		}
+
+		if guestsParam := c.Query("guests"); guestsParam != "" {
+			guests, err := strconv.Atoi(guestsParam)
+			if err != nil || guests < 1 || guests > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "guests must be a number between 1 and 100"})
+				return
+			}
+			weights := make([]float64, guests)
+			for i := range weights {
+				weights[i] = 1
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"balance_due": state.Balance_due,
+				"guests":      guests,
+				"shares":      helpers.AllocateTotal(state.Balance_due, weights),
+			})
+			return
+		}
+
+		paid := paidItems(state.Payments)
+		unpaid := []OrderItemView{}
+		for _, item := range summary.Order_items {
+			if !paid[item.Order_item_id] {
+				unpaid = append(unpaid, item)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"balance_due": state.Balance_due, "items": unpaid})
+	}
+}
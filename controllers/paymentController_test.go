@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSplitBillPayments(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	salad := createTestFood(t, router, "Salad", "Starters", 9)
+	soup := createTestFood(t, router, "Soup", "Starters", 6)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 4, 1), steak, salad, soup)
+	for _, status := range []string{models.OrderInKitchen, models.OrderReady, models.OrderServed} {
+		w := performRequest(router, "POST", "/orders/"+orderId+"/transition", gin.H{"status": status})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	payments := "/invoices/" + invoiceId + "/payments"
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId+"/split?guests=3", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	even := decode[struct {
+		Shares []float64 `json:"shares"`
+	}](t, w.Body.Bytes())
+	assert.Equal(t, []float64{13.33, 13.33, 13.34}, even.Shares)
+
+	// one guest pays for the steak by card
+	w = performRequest(router, "GET", "/invoices/"+invoiceId+"/split", nil)
+	items := decode[struct {
+		Items []OrderItemView `json:"items"`
+	}](t, w.Body.Bytes()).Items
+	assert.Len(t, items, 3)
+	w = performRequest(router, "POST", payments, gin.H{"method": "CARD", "order_item_ids": []string{items[0].Order_item_id}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.PaymentPartiallyPaid, decode[gin.H](t, w.Body.Bytes())["payment_status"])
+
+	// the steak cannot be paid twice, and nobody can pay more than what is left
+	w = performRequest(router, "POST", payments, gin.H{"method": "CARD", "order_item_ids": []string{items[0].Order_item_id}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", payments, gin.H{"method": "CARD", "amount": 20})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the rest is paid in cash with change
+	w = performRequest(router, "POST", payments, gin.H{"method": "CASH", "tendered": 20})
+	assert.Equal(t, http.StatusOK, w.Code)
+	paid := decode[struct {
+		Payment        models.Payment `json:"payment"`
+		Payment_status string         `json:"payment_status"`
+		Balance_due    float64        `json:"balance_due"`
+	}](t, w.Body.Bytes())
+	assert.Equal(t, 15.0, *paid.Payment.Amount)
+	assert.Equal(t, 5.0, paid.Payment.Change_due)
+	assert.Equal(t, models.PaymentPaid, paid.Payment_status)
+	assert.Equal(t, 0.0, paid.Balance_due)
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, 40.0, view.Amount_paid)
+	assert.Len(t, view.Payments, 2)
+	assert.Equal(t, models.PaymentPaid, *view.Payment_status)
+
+	order, _ := store.Orders.FindByID(context.Background(), orderId)
+	assert.Equal(t, models.OrderPaid, order.CurrentStatus())
+
+	w = performRequest(router, "POST", payments, gin.H{"method": "CASH"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestTipsAndServiceCharge(t *testing.T) {
+	helpers.ConfigureServiceCharge(config.ServiceChargeConfig{Name: "Service charge", Percent: 12.5, MinGuests: 7})
+	defer helpers.ConfigureServiceCharge(config.ServiceChargeConfig{})
+
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleServer)
+
+	pizza := createTestFood(t, router, "Pizza", "Mains", 16)
+	small := createTestOrder(t, router, createTestTable(t, router, 6, 1), pizza, pizza)
+	large := createTestOrder(t, router, createTestTable(t, router, 8, 2), pizza, pizza)
+
+	w := performRequest(router, "POST", "/invoices", gin.H{"order_id": small})
+	smallInvoice := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "GET", "/invoices/"+smallInvoice, nil)
+	view := decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Nil(t, view.Service_charge)
+	assert.Equal(t, 32.0, view.Grand_total)
+
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": large})
+	largeInvoice := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "GET", "/invoices/"+largeInvoice, nil)
+	view = decode[InvoiceViewFormat](t, w.Body.Bytes())
+	assert.Equal(t, &helpers.ServiceChargeLine{Name: "Service charge", Percent: 12.5, Amount: 4}, view.Service_charge)
+	assert.Equal(t, 36.0, view.Grand_total)
+
+	// the tip comes on top of the amount, the change is what is left of both
+	w = performRequest(router, "POST", "/invoices/"+largeInvoice+"/payments", gin.H{"method": "CASH", "tip": 4, "tendered": 50})
+	assert.Equal(t, http.StatusOK, w.Code)
+	paid := decode[struct {
+		Payment        models.Payment `json:"payment"`
+		Payment_status string         `json:"payment_status"`
+	}](t, w.Body.Bytes())
+	assert.Equal(t, 36.0, *paid.Payment.Amount)
+	assert.Equal(t, 10.0, paid.Payment.Change_due)
+	assert.Equal(t, "test-user", paid.Payment.Server_id)
+	assert.Equal(t, models.PaymentPaid, paid.Payment_status)
+
+	w = performRequest(router, "POST", "/invoices/"+smallInvoice+"/payments", gin.H{"method": "CARD", "tip": 2.5})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(router, "GET", "/invoices/"+largeInvoice, nil)
+	assert.Equal(t, 4.0, decode[InvoiceViewFormat](t, w.Body.Bytes()).Tip_total)
+
+	w = performRequest(router, "GET", "/reports/tips", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decode[struct {
+		Tip_total float64         `json:"tip_total"`
+		Servers   []TipReportLine `json:"servers"`
+	}](t, w.Body.Bytes())
+	assert.Equal(t, 6.5, report.Tip_total)
+	assert.Equal(t, []TipReportLine{{Server_id: "test-user", Tip_total: 6.5, Payment_count: 2}}, report.Servers)
+
+	w = performRequest(router, "GET", "/reports/tips?from=2026-13-01", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestInvoiceStatusFollowsPayments(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+	router.PATCH("/invoices/:invoice_id", UpdateInvoice(store))
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 1), burger)
+	w := performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId, "payement_status": models.PaymentPaid})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId, "amount_paid": 10})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	require.Equal(t, http.StatusOK, w.Code)
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+
+	// one invoice bills the whole order, and only an open order is billed
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	cancelled := createTestOrder(t, router, createTestTable(t, router, 2, 2), burger)
+	require.NoError(t, store.Orders.Update(context.Background(), cancelled, bson.D{{Key: "status", Value: models.OrderCancelled}}))
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": cancelled})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// the invoice field is spelled payement_status
+	w = performRequest(router, "PATCH", "/invoices/"+invoiceId, gin.H{"payement_status": models.PaymentPaid})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "PATCH", "/invoices/"+invoiceId, gin.H{"payment_status": models.PaymentPaid, "payment_method": "CARD"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	invoice, err := store.Invoices.FindByID(context.Background(), invoiceId)
+	assert.NoError(t, err)
+	assert.Equal(t, "PENDING", *invoice.Payment_status)
+	assert.Zero(t, invoice.Amount_paid)
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/printing"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// PrintKitchenTickets prints a ticket for every kitchen station that has
+// something to prepare for the order. With order_item_ids only those items are
+// printed, for example the ones added after the first round.
+func PrintKitchenTickets(store *repository.Store, spooler *printing.Spooler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		orderId := c.Param("order_id")
+
+		var body struct {
+			Order_item_ids []string `json:"order_item_ids"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		summaries, err := ItemsByOrder(ctx, store, orderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		wanted := map[string]bool{}
+		for _, id := range body.Order_item_ids {
+			wanted[id] = true
+		}
+
+		stations := byStation(summaries[0].Order_items, wanted)
+		if len(stations) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "there is nothing to prepare on this order"})
+			return
+		}
+
+		jobs := []printing.Job{}
+		serverName := userName(ctx, store, orderServer(order))
+		for _, s := range stations {
+			if s.station.Printer == "" {
+				continue
+			}
+			ticket := printedTicket(s, summaries[0].Table_number)
+			ticket.Server_name = serverName
+			job, err := spooler.PrintKitchenTicket(ticket, s.station.Printer)
+			if err != nil {
+				respondWithPrintError(c, err)
+				return
+			}
+			jobs = append(jobs, job)
+		}
+		if len(jobs) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "none of the stations of this order has a printer"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+	}
+}
+
+// printedTicket is the paper ticket for the items of one station.
+func printedTicket(s stationItems, tableNumber *int) printing.KitchenTicket {
+	ticket := printing.KitchenTicket{
+		Station:      s.station.Name,
+		Order_id:     s.items[0].Order_id,
+		Table_number: tableNumber,
+		Created_at:   time.Now(),
+	}
+	for _, item := range s.items {
+		ticket.Items = append(ticket.Items, printing.TicketItem{Name: foodName(item), Quantity: item.Quantity, Note: strings.Join(modifierNames(item), ", ")})
+	}
+	return ticket
+}
+
+// PrintInvoiceReceipt prints the receipt of an invoice on the receipt printer,
+// or on the printer named by ?printer=.
+func PrintInvoiceReceipt(store *repository.Store, spooler *printing.Spooler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		receipt, err := invoiceReceipt(ctx, store, c.Param("invoice_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the invoice"})
+			return
+		}
+
+		job, err := spooler.PrintReceipt(receipt, c.Query("printer"))
+		if err != nil {
+			respondWithPrintError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+func GetPrintJobs(spooler *printing.Spooler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		jobs := []printing.Job{}
+		for _, job := range spooler.Jobs() {
+			if status == "" || job.Status == status {
+				jobs = append(jobs, job)
+			}
+		}
+		c.JSON(http.StatusOK, jobs)
+	}
+}
+
+func GetPrintJob(spooler *printing.Spooler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := spooler.Job(c.Param("job_id"))
+		if err != nil {
+			respondWithPrintError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// RetryPrintJob queues a failed job again, once the printer is back.
+func RetryPrintJob(spooler *printing.Spooler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := spooler.Retry(c.Param("job_id"))
+		if err != nil {
+			respondWithPrintError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+func respondWithPrintError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, printing.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, printing.ErrQueueFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	}
+}
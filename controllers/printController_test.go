@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/printing"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintTicketsAndReceipt(t *testing.T) {
+	dir := t.TempDir()
+	spooler, err := printing.NewSpooler(config.PrintingConfig{
+		Printers: []config.PrinterConfig{
+			{Name: "grill", Driver: config.PrinterFile, Address: filepath.Join(dir, "grill")},
+			{Name: "bar", Driver: config.PrinterFile, Address: filepath.Join(dir, "bar")},
+		},
+		ReceiptPrinter: "bar",
+		MaxAttempts:    1,
+	})
+	require.NoError(t, err)
+	defer spooler.Close()
+	helpers.ConfigureKitchen(config.KitchenConfig{Stations: []config.StationConfig{
+		{Name: "Grill", Printer: "grill"},
+		{Name: "Bar", Printer: "bar", Categories: []string{"drinks"}},
+	}})
+	defer helpers.ConfigureKitchen(config.KitchenConfig{})
+
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+	router.POST("/orders/:order_id/tickets", PrintKitchenTickets(store, spooler))
+	router.POST("/invoices/:invoice_id/receipt", PrintInvoiceReceipt(store, spooler))
+	router.GET("/print-jobs/:job_id", GetPrintJob(spooler))
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	beer := createTestFood(t, router, "Beer", "Drinks", 5)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 9), steak, beer, steak)
+
+	w := performRequest(router, "POST", "/orders/"+orderId+"/tickets", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	jobs := decode[struct {
+		Jobs []printing.Job `json:"jobs"`
+	}](t, w.Body.Bytes()).Jobs
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "grill", jobs[0].Printer)
+	assert.Equal(t, "bar", jobs[1].Printer)
+
+	for _, job := range jobs {
+		assert.Eventually(t, func() bool {
+			w := performRequest(router, "GET", "/print-jobs/"+job.Job_id, nil)
+			return decode[printing.Job](t, w.Body.Bytes()).Status == printing.JobDone
+		}, 2*time.Second, 5*time.Millisecond)
+	}
+	grill, _ := os.ReadFile(filepath.Join(dir, "grill"))
+	assert.Contains(t, string(grill), "TABLE 9")
+	assert.Equal(t, 2, strings.Count(string(grill), " 1 x Steak\n"))
+	assert.NotContains(t, string(grill), "Beer")
+
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "POST", "/invoices/"+invoiceId+"/receipt", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, printing.JobReceipt, decode[printing.Job](t, w.Body.Bytes()).Kind)
+
+	w = performRequest(router, "POST", "/invoices/"+invoiceId+"/receipt?printer=office", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(router, "GET", "/print-jobs/missing", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// invoiceReceipt turns the invoice view into the lines printed for the guest.
+func invoiceReceipt(ctx context.Context, store *repository.Store, invoiceId string) (helpers.Receipt, error) {
+	view, err := invoiceView(ctx, store, invoiceId)
+	if err != nil {
+		return helpers.Receipt{}, err
+	}
+
+	receipt := helpers.Receipt{
+		Invoice_id: view.Invoice_id,
+		Order_id:   view.Order_id,
+		Issued_at:  time.Now(),
+	}
+	if view.Payment_status != nil {
+		receipt.Payment_status = *view.Payment_status
+	}
+	if tableNumber, ok := view.Table_number.(*int); ok {
+		receipt.Table_number = tableNumber
+	}
+
+	items, _ := view.Order_details.([]OrderItemView)
+	names := map[string]string{}
+	for _, item := range items {
+		name := foodName(item)
+		names[item.Order_item_id] = name
+		if item.Combo != nil {
+			name = item.Combo.Combo_name + ": " + name
+		}
+		if modifiers := modifierNames(item); len(modifiers) > 0 {
+			name += " (" + strings.Join(modifiers, ", ") + ")"
+		}
+
+		line := helpers.ReceiptItem{Name: name, Quantity: item.Quantity, Price: item.Price, Amount: item.Amount}
+		if item.Adjustment != nil {
+			line.Note = *item.Adjustment
+		}
+		receipt.Items = append(receipt.Items, line)
+	}
+
+	total := func(label string, amount float64, bold bool) {
+		receipt.Totals = append(receipt.Totals, helpers.ReceiptTotal{Label: label, Amount: amount, Bold: bold})
+	}
+	total("Items", view.Items_total, false)
+	var refunds float64
+	for _, adjustment := range view.Adjustments {
+		if adjustment.Type == models.AdjustmentRefund {
+			refunds += adjustment.Amount
+			continue
+		}
+		label := adjustment.Type
+		if adjustment.Order_item_id != nil {
+			label += " " + names[*adjustment.Order_item_id]
+		}
+		total(label, -adjustment.Amount, false)
+	}
+	for _, discount := range view.Discounts {
+		total("Discount "+discount.Coupon_code, -discount.Amount, false)
+	}
+	total("Subtotal", view.Subtotal, false)
+	for _, tax := range view.Taxes {
+		label := fmt.Sprintf("%s %g%%", tax.Name, tax.Percent)
+		if tax.Inclusive {
+			label = "incl. " + label
+		}
+		total(label, tax.Tax, false)
+	}
+	if view.Service_charge != nil {
+		total(fmt.Sprintf("%s %g%%", view.Service_charge.Name, view.Service_charge.Percent), view.Service_charge.Amount, false)
+	}
+	total("TOTAL", toFixed(view.Grand_total+refunds, 2), true)
+	if refunds > 0 {
+		total("Refunded", -toFixed(refunds, 2), false)
+	}
+	if view.Tip_total > 0 {
+		total("Tips", view.Tip_total, false)
+	}
+	if view.Amount_paid > 0 {
+		total("Balance due", view.Balance_due, true)
+	}
+
+	for _, payment := range view.Payments {
+		// refunds are listed with the totals
+		if payment.Adjustment_id != nil {
+			continue
+		}
+		line := helpers.ReceiptPayment{Change: payment.Change_due}
+		if payment.Method != nil {
+			line.Method = *payment.Method
+		}
+		if payment.Amount != nil {
+			line.Amount = *payment.Amount
+		}
+		if payment.Tip != nil {
+			line.Tip = *payment.Tip
+		}
+		receipt.Payments = append(receipt.Payments, line)
+	}
+	return receipt, nil
+}
+
+// GetInvoicePDF renders the receipt of an invoice as a PDF.
+func GetInvoicePDF(store *repository.Store) gin.HandlerFunc {
+	return renderReceipt(store, "application/pdf", "pdf", helpers.WriteReceiptPDF)
+}
+
+// GetInvoiceHTML renders the receipt of an invoice as a printable web page.
+func GetInvoiceHTML(store *repository.Store) gin.HandlerFunc {
+	return renderReceipt(store, "text/html; charset=utf-8", "html", helpers.WriteReceiptHTML)
+}
+
+func renderReceipt(store *repository.Store, contentType string, extension string, write func(io.Writer, helpers.Receipt) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		receipt, err := invoiceReceipt(ctx, store, c.Param("invoice_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the invoice"})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := write(&buf, receipt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while rendering the receipt"})
+			return
+		}
+
+		disposition := "inline"
+		if c.Query("download") != "" {
+			disposition = "attachment"
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"invoice-%s.%s\"", disposition, receipt.Invoice_id, extension))
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceReceipt(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupOrderRouter(store, models.RoleManager)
+
+	steak := createTestFood(t, router, "Steak", "Mains", 25)
+	wine := createTestFood(t, router, "Wine", "Drinks", 8)
+	orderId := createTestOrder(t, router, createTestTable(t, router, 2, 12), steak, wine)
+	w := performRequest(router, "POST", "/invoices", gin.H{"order_id": orderId})
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "POST", "/invoices/"+invoiceId+"/payments", gin.H{"method": "CARD", "tip": 4})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	receipt, err := invoiceReceipt(context.Background(), store, invoiceId)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, *receipt.Table_number)
+	assert.Len(t, receipt.Items, 2)
+	assert.Equal(t, "PAID", receipt.Payment_status)
+	assert.Contains(t, receipt.Totals, helpers.ReceiptTotal{Label: "TOTAL", Amount: 33, Bold: true})
+	assert.Contains(t, receipt.Totals, helpers.ReceiptTotal{Label: "Tips", Amount: 4})
+	assert.Equal(t, 33.0, receipt.Payments[0].Amount)
+	assert.Equal(t, 4.0, receipt.Payments[0].Tip)
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId+"/pdf", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+
+	w = performRequest(router, "GET", "/invoices/"+invoiceId+"/html?download=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Contains(t, w.Body.String(), "Paid by CARD")
+
+	w = performRequest(router, "GET", "/invoices/missing/pdf", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
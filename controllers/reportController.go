@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// TipReportLine is what one server received in tips.
+type TipReportLine struct {
+	Server_id     string  `json:"server_id"`
+	Server_name   string  `json:"server_name"`
+	Tip_total     float64 `json:"tip_total"`
+	Payment_count int     `json:"payment_count"`
+}
+
+// AdjustmentReportLine is the approved adjustments of one type for one reason.
+type AdjustmentReportLine struct {
+	Type        string  `json:"type"`
+	Reason_code string  `json:"reason_code"`
+	Count       int     `json:"count"`
+	Amount      float64 `json:"amount"`
+}
+
+// reportRange reads the ?from= and ?to= of a report, as dates (2006-01-02) or
+// RFC3339 times. A date as to includes that whole day. The range defaults to today.
+func reportRange(c *gin.Context) (time.Time, time.Time, string) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, 1)
+
+	parse := func(value string, endOfDay bool) (time.Time, bool) {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, true
+		}
+		t, err := time.ParseInLocation("2006-01-02", value, now.Location())
+		if err != nil {
+			return t, false
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, true
+	}
+
+	if value := c.Query("from"); value != "" {
+		t, ok := parse(value, false)
+		if !ok {
+			return from, to, "from must be a date like 2006-01-02 or an RFC3339 time"
+		}
+		from = t
+	}
+	if value := c.Query("to"); value != "" {
+		t, ok := parse(value, true)
+		if !ok {
+			return from, to, "to must be a date like 2006-01-02 or an RFC3339 time"
+		}
+		to = t
+	}
+	if !to.After(from) {
+		return from, to, "to must be after from"
+	}
+	return from, to, ""
+}
+
+// GetTipReport adds up the tips of the payments in the range per server, for
+// sharing them out.
+func GetTipReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		from, to, msg := reportRange(c)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		payments, err := store.Payments.FindBetween(ctx, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the payments"})
+			return
+		}
+
+		byServer := map[string]*TipReportLine{}
+		var total float64
+		for _, payment := range payments {
+			if payment.Tip == nil || *payment.Tip == 0 {
+				continue
+			}
+			line, ok := byServer[payment.Server_id]
+			if !ok {
+				line = &TipReportLine{Server_id: payment.Server_id}
+				byServer[payment.Server_id] = line
+			}
+			line.Tip_total += *payment.Tip
+			line.Payment_count++
+			total += *payment.Tip
+		}
+
+		lines := []TipReportLine{}
+		for _, line := range byServer {
+			line.Tip_total = toFixed(line.Tip_total, 2)
+			line.Server_name = userName(ctx, store, line.Server_id)
+			lines = append(lines, *line)
+		}
+		sort.Slice(lines, func(i, j int) bool { return lines[i].Tip_total > lines[j].Tip_total })
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":      from,
+			"to":        to,
+			"tip_total": toFixed(total, 2),
+			"servers":   lines,
+		})
+	}
+}
+
+// GetAdjustmentReport adds up the approved adjustments requested in the range by type and
+// reason code, so that managers can see what is costing money and why.
+func GetAdjustmentReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		from, to, msg := reportRange(c)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		adjustments, err := store.Adjustments.FindBetween(ctx, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the adjustments"})
+			return
+		}
+
+		type key struct{ adjustmentType, reason string }
+		byReason := map[key]*AdjustmentReportLine{}
+		totals := map[string]float64{
+			models.AdjustmentVoid:   0,
+			models.AdjustmentComp:   0,
+			models.AdjustmentRefund: 0,
+		}
+		pending := 0
+		for _, adjustment := range adjustments {
+			if adjustment.Status == models.AdjustmentPending {
+				pending++
+			}
+			if adjustment.Status != models.AdjustmentApproved {
+				continue
+			}
+			k := key{*adjustment.Type, *adjustment.Reason_code}
+			line, ok := byReason[k]
+			if !ok {
+				line = &AdjustmentReportLine{Type: k.adjustmentType, Reason_code: k.reason}
+				byReason[k] = line
+			}
+			line.Count++
+			line.Amount += *adjustment.Amount
+			totals[k.adjustmentType] += *adjustment.Amount
+		}
+
+		lines := []AdjustmentReportLine{}
+		for _, line := range byReason {
+			line.Amount = toFixed(line.Amount, 2)
+			lines = append(lines, *line)
+		}
+		sort.Slice(lines, func(i, j int) bool {
+			if lines[i].Type != lines[j].Type {
+				return lines[i].Type < lines[j].Type
+			}
+			return lines[i].Amount > lines[j].Amount
+		})
+		for adjustmentType, total := range totals {
+			totals[adjustmentType] = toFixed(total, 2)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":    from,
+			"to":      to,
+			"totals":  totals,
+			"pending": pending,
+			"reasons": lines,
+		})
+	}
+}
+
+// userName is the full name of a user for reports, empty when the user is gone.
+func userName(ctx context.Context, store *repository.Store, userId string) string {
+	user, err := store.Users.FindByID(ctx, userId)
+	if err != nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range []*string{user.First_name, user.Last_name} {
+		if part != nil {
+			parts = append(parts, *part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,486 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReservationSlot is a start time and the tables free for the party then,
+// smallest first.
+type ReservationSlot struct {
+	Starts_at time.Time      `json:"starts_at"`
+	Ends_at   time.Time      `json:"ends_at"`
+	Tables    []models.Table `json:"tables"`
+}
+
+// Availability answers an availability search. Alternatives are the nearby
+// slots with a free table, only given when the asked slot is full.
+type Availability struct {
+	Party_size   int               `json:"party_size"`
+	Slot         ReservationSlot   `json:"slot"`
+	Alternatives []ReservationSlot `json:"alternatives"`
+}
+
+// freeTables returns the tables that take partySize and are held by no
+// reservation from start to blockedUntil, smallest first so large tables stay
+// free for large parties. The reservation exceptId does not count, so a
+// booking being changed does not get in its own way.
+func freeTables(ctx context.Context, store *repository.Store, partySize int, start time.Time, blockedUntil time.Time, exceptId string) ([]models.Table, error) {
+	tables, err := store.Tables.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	holding, err := store.Reservations.Holding(ctx, start, blockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	taken := map[string]bool{}
+	for _, reservation := range holding {
+		if reservation.Table_id != nil && reservation.Reservation_id != exceptId {
+			taken[*reservation.Table_id] = true
+		}
+	}
+
+	free := []models.Table{}
+	for _, table := range tables {
+		if !table.Seats(partySize) || taken[table.Table_id] {
+			continue
+		}
+		free = append(free, table)
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		if free[i].MaxParty() != free[j].MaxParty() {
+			return free[i].MaxParty() < free[j].MaxParty()
+		}
+		return tableNumber(free[i]) < tableNumber(free[j])
+	})
+	return free, nil
+}
+
+func tableNumber(table models.Table) int {
+	if table.Table_number == nil {
+		return 0
+	}
+	return *table.Table_number
+}
+
+// alternativeSlots returns the nearby start times, still to come, that have a
+// table free for the party.
+func alternativeSlots(ctx context.Context, store *repository.Store, partySize int, at time.Time, exceptId string) ([]ReservationSlot, error) {
+	alternatives := []ReservationSlot{}
+	now := time.Now()
+	for _, start := range helpers.NearbySlots(at) {
+		if start.Before(now) {
+			continue
+		}
+		endsAt, blockedUntil := helpers.ReservationWindow(start)
+		tables, err := freeTables(ctx, store, partySize, start, blockedUntil, exceptId)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) > 0 {
+			alternatives = append(alternatives, ReservationSlot{Starts_at: start, Ends_at: endsAt, Tables: tables})
+		}
+	}
+	return alternatives, nil
+}
+
+// bookTable books the reservation on the first of the tables still free when
+// it is written. Another booking may take a table between the search and the
+// write; the next table is tried then. It returns ErrConflict when no table
+// is left.
+func bookTable(ctx context.Context, store *repository.Store, reservation *models.Reservation, tables []models.Table) error {
+	for _, table := range tables {
+		tableId := table.Table_id
+		reservation.Table_id = &tableId
+		reservation.Table_number = table.Table_number
+		err := store.Reservations.Book(ctx, *reservation)
+		if err != repository.ErrConflict {
+			return err
+		}
+	}
+	return repository.ErrConflict
+}
+
+// previousNoShows counts the reservations on the phone number the guests did
+// not turn up for.
+func previousNoShows(ctx context.Context, store *repository.Store, phone string) (int, error) {
+	reservations, err := store.Reservations.FindByPhone(ctx, phone)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationNoShow {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetReservations lists the reservations of a day, ?date=YYYY-MM-DD and today
+// by default, or those of a guest with ?phone=. ?status= takes a comma
+// separated list of statuses.
+func GetReservations(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var reservations []models.Reservation
+		var err error
+		if phone := c.Query("phone"); phone != "" {
+			reservations, err = store.Reservations.FindByPhone(ctx, phone)
+		} else {
+			day := time.Now()
+			if date := c.Query("date"); date != "" {
+				day, err = time.ParseInLocation("2006-01-02", date, time.Local)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "date must look like 2006-01-02"})
+					return
+				}
+			}
+			from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+			reservations, err = store.Reservations.FindBetween(ctx, from, from.AddDate(0, 0, 1))
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing reservations"})
+			return
+		}
+
+		wanted := map[string]bool{}
+		for _, status := range strings.Split(c.Query("status"), ",") {
+			wanted[status] = true
+		}
+		result := []models.Reservation{}
+		for _, reservation := range reservations {
+			if c.Query("status") == "" || wanted[reservation.Status] {
+				result = append(result, reservation)
+			}
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func GetReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		reservation, err := store.Reservations.FindByID(ctx, c.Param("reservation_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the reservation"})
+			return
+		}
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// GetAvailability searches the tables free for ?party_size= at ?at=, an
+// RFC 3339 time.
+func GetAvailability(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		partySize, err := strconv.Atoi(c.Query("party_size"))
+		if err != nil || partySize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "party_size must be a number of at least 1"})
+			return
+		}
+		at, err := time.Parse(time.RFC3339, c.Query("at"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+			return
+		}
+
+		endsAt, blockedUntil := helpers.ReservationWindow(at)
+		tables, err := freeTables(ctx, store, partySize, at, blockedUntil, "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while searching free tables"})
+			return
+		}
+		availability := Availability{
+			Party_size:   partySize,
+			Slot:         ReservationSlot{Starts_at: at, Ends_at: endsAt, Tables: tables},
+			Alternatives: []ReservationSlot{},
+		}
+		if len(tables) == 0 {
+			availability.Alternatives, err = alternativeSlots(ctx, store, partySize, at, "")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while searching free tables"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, availability)
+	}
+}
+
+// CreateReservation books a table for the party. Without a table_id the
+// smallest free table that seats the party is taken. When the slot is full
+// the answer is a 409 listing nearby slots that are not.
+func CreateReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var reservation models.Reservation
+		if err := c.BindJSON(&reservation); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(reservation); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		start := reservation.Starts_at.UTC()
+		if start.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at is in the past"})
+			return
+		}
+
+		noShows, err := previousNoShows(ctx, store, *reservation.Phone)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the guest's reservations"})
+			return
+		}
+
+		reservation.Starts_at = &start
+		reservation.Ends_at, reservation.Blocked_until = helpers.ReservationWindow(start)
+		reservation.Status = models.ReservationBooked
+		reservation.Previous_no_shows = noShows
+		reservation.Created_by = c.GetString("uid")
+		reservation.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		reservation.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		reservation.Seated_at = nil
+		reservation.Cancelled_at = nil
+		reservation.ID = primitive.NewObjectID()
+		reservation.Reservation_id = reservation.ID.Hex()
+
+		if !placeReservation(ctx, c, store, &reservation, reservation.Table_id) {
+			return
+		}
+		Events.Publish(events.TopicReservations, events.ReservationCreated, reservation)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// placeReservation books the reservation on tableId, or on the best free
+// table when it is nil, and answers the request itself when that fails.
+func placeReservation(ctx context.Context, c *gin.Context, store *repository.Store, reservation *models.Reservation, tableId *string) bool {
+	var candidates []models.Table
+	if tableId != nil {
+		table, err := store.Tables.FindByID(ctx, *tableId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "table not found"})
+			return false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+			return false
+		}
+		if !table.Seats(*reservation.Party_size) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the table does not seat the party"})
+			return false
+		}
+		candidates = []models.Table{table}
+	} else {
+		free, err := freeTables(ctx, store, *reservation.Party_size, *reservation.Starts_at, reservation.Blocked_until, reservation.Reservation_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while searching free tables"})
+			return false
+		}
+		candidates = free
+	}
+
+	err := bookTable(ctx, store, reservation, candidates)
+	if err == repository.ErrConflict {
+		msg := "no table seating the party is free at that time"
+		if tableId != nil {
+			msg = "the table is already booked at that time"
+		}
+		alternatives, err := alternativeSlots(ctx, store, *reservation.Party_size, *reservation.Starts_at, reservation.Reservation_id)
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return false
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": msg, "alternatives": alternatives})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "reservation was not saved"})
+		return false
+	}
+	return true
+}
+
+// UpdateReservation changes a BOOKED reservation. A new time or party size
+// keeps the table if it still fits and is free, and looks for another one
+// otherwise; a table_id moves the booking to that table.
+func UpdateReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		reservationId := c.Param("reservation_id")
+
+		var changes models.Reservation
+		if err := c.BindJSON(&changes); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		reservation, err := store.Reservations.FindByID(ctx, reservationId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the reservation"})
+			return
+		}
+		if reservation.Status != models.ReservationBooked {
+			c.JSON(http.StatusConflict, gin.H{"error": "a " + reservation.Status + " reservation cannot be changed"})
+			return
+		}
+
+		if changes.Guest_name != nil {
+			reservation.Guest_name = changes.Guest_name
+		}
+		if changes.Phone != nil {
+			reservation.Phone = changes.Phone
+		}
+		if changes.Note != nil {
+			reservation.Note = changes.Note
+		}
+		if changes.Party_size != nil {
+			reservation.Party_size = changes.Party_size
+		}
+		if changes.Starts_at != nil {
+			start := changes.Starts_at.UTC()
+			if start.Before(time.Now()) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at is in the past"})
+				return
+			}
+			reservation.Starts_at = &start
+		}
+		if validationErr := validate.Struct(reservation); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		reservation.Ends_at, reservation.Blocked_until = helpers.ReservationWindow(*reservation.Starts_at)
+		reservation.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		tableId := changes.Table_id
+		if tableId == nil && reservation.Table_id != nil && reservationFits(ctx, store, reservation) {
+			// the table it has is the first choice, the others only if it is taken
+			err := store.Reservations.Book(ctx, reservation)
+			if err == nil {
+				Events.Publish(events.TopicReservations, events.ReservationUpdated, reservation)
+				c.JSON(http.StatusOK, reservation)
+				return
+			}
+			if err != repository.ErrConflict {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "reservation update failed"})
+				return
+			}
+		}
+		if !placeReservation(ctx, c, store, &reservation, tableId) {
+			return
+		}
+		Events.Publish(events.TopicReservations, events.ReservationUpdated, reservation)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// reservationFits tells whether the table of the reservation seats its party.
+func reservationFits(ctx context.Context, store *repository.Store, reservation models.Reservation) bool {
+	table, err := store.Tables.FindByID(ctx, *reservation.Table_id)
+	return err == nil && table.Seats(*reservation.Party_size)
+}
+
+// CancelReservation frees the table of a BOOKED reservation.
+func CancelReservation(store *repository.Store) gin.HandlerFunc {
+	return reservationAction(store, models.ReservationCancelled, events.ReservationCancelled)
+}
+
+// SeatReservation records that the party arrived. The table stays held until
+// the end of the booking.
+func SeatReservation(store *repository.Store) gin.HandlerFunc {
+	return reservationAction(store, models.ReservationSeated, events.ReservationSeated)
+}
+
+// MarkNoShow frees the table of a party that did not turn up. It is allowed
+// once the grace period after the start is over; the no-show is counted
+// against the phone number on its next booking.
+func MarkNoShow(store *repository.Store) gin.HandlerFunc {
+	return reservationAction(store, models.ReservationNoShow, events.ReservationNoShow)
+}
+
+// reservationAction moves a BOOKED reservation to status to.
+func reservationAction(store *repository.Store, to string, eventType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		reservationId := c.Param("reservation_id")
+
+		reservation, err := store.Reservations.FindByID(ctx, reservationId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the reservation"})
+			return
+		}
+		if reservation.Status != models.ReservationBooked {
+			c.JSON(http.StatusConflict, gin.H{"error": "a " + reservation.Status + " reservation cannot become " + to})
+			return
+		}
+		if to == models.ReservationNoShow && time.Now().Before(helpers.NoShowAfter(*reservation.Starts_at)) {
+			c.JSON(http.StatusConflict, gin.H{"error": "the party has until " + helpers.NoShowAfter(*reservation.Starts_at).Format(time.RFC3339) + " to arrive"})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		fields := bson.D{{Key: "status", Value: to}, {Key: "updated_at", Value: now}}
+		switch to {
+		case models.ReservationSeated:
+			fields = append(fields, bson.E{Key: "seated_at", Value: now})
+		case models.ReservationCancelled:
+			fields = append(fields, bson.E{Key: "cancelled_at", Value: now})
+		}
+
+		err = store.Reservations.UpdateStatus(ctx, reservationId, models.ReservationBooked, fields)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the reservation was changed in the meantime, please retry"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "reservation update failed"})
+			return
+		}
+
+		reservation, err = store.Reservations.FindByID(ctx, reservationId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the reservation"})
+			return
+		}
+		Events.Publish(events.TopicReservations, eventType, reservation)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func setupReservationRouter(store *repository.Store) *gin.Engine {
+	router := setupOrderRouter(store, models.RoleServer)
+	router.GET("/reservations", GetReservations(store))
+	router.GET("/reservations/availability", GetAvailability(store))
+	router.POST("/reservations", CreateReservation(store))
+	router.PATCH("/reservations/:reservation_id", UpdateReservation(store))
+	router.POST("/reservations/:reservation_id/cancel", CancelReservation(store))
+	router.POST("/reservations/:reservation_id/seat", SeatReservation(store))
+	router.POST("/reservations/:reservation_id/no-show", MarkNoShow(store))
+	return router
+}
+
+func book(router *gin.Engine, partySize int, at time.Time, phone string) (int, models.Reservation) {
+	w := performRequest(router, "POST", "/reservations", gin.H{
+		"guest_name": "Ada",
+		"phone":      phone,
+		"party_size": partySize,
+		"starts_at":  at.Format(time.RFC3339),
+	})
+	reservation := models.Reservation{}
+	if w.Code == http.StatusOK {
+		json.Unmarshal(w.Body.Bytes(), &reservation)
+	}
+	return w.Code, reservation
+}
+
+func TestReservationAvailabilityAndBooking(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupReservationRouter(store)
+	two := createTestTable(t, router, 2, 1)
+	four := createTestTable(t, router, 4, 2)
+
+	dinner := time.Now().Add(48 * time.Hour).Truncate(time.Hour).UTC()
+
+	w := performRequest(router, "GET", "/reservations/availability?party_size=3&at="+dinner.Format(time.RFC3339), nil)
+	availability := decode[Availability](t, w.Body.Bytes())
+	require.Len(t, availability.Slot.Tables, 1, "only the table for four seats three")
+	assert.Equal(t, four, availability.Slot.Tables[0].Table_id)
+
+	// the smallest table that fits is taken
+	code, first := book(router, 2, dinner, "5550001")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, two, *first.Table_id)
+	assert.Equal(t, models.ReservationBooked, first.Status)
+	assert.Equal(t, dinner.Add(90*time.Minute), first.Ends_at)
+	assert.Equal(t, dinner.Add(105*time.Minute), first.Blocked_until)
+
+	code, second := book(router, 2, dinner.Add(30*time.Minute), "5550002")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, four, *second.Table_id)
+
+	// both tables are held, the search offers later slots
+	w = performRequest(router, "POST", "/reservations", gin.H{
+		"guest_name": "Bob", "phone": "5550003", "party_size": 2, "starts_at": dinner.Add(time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	full := decode[struct {
+		Alternatives []ReservationSlot `json:"alternatives"`
+	}](t, w.Body.Bytes())
+	require.NotEmpty(t, full.Alternatives)
+	for _, slot := range full.Alternatives {
+		assert.NotEmpty(t, slot.Tables)
+	}
+
+	// a table the party does not fit at is refused
+	w = performRequest(router, "POST", "/reservations", gin.H{
+		"guest_name": "Bob", "phone": "5550003", "party_size": 4, "table_id": two, "starts_at": dinner.Add(5 * time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// moving the first booking later keeps its table, and frees the slot
+	w = performRequest(router, "PATCH", "/reservations/"+first.Reservation_id, gin.H{"starts_at": dinner.Add(3 * time.Hour).Format(time.RFC3339)})
+	require.Equal(t, http.StatusOK, w.Code)
+	moved := decode[models.Reservation](t, w.Body.Bytes())
+	assert.Equal(t, two, *moved.Table_id)
+
+	// a larger party moves to the larger table
+	w = performRequest(router, "PATCH", "/reservations/"+first.Reservation_id, gin.H{"party_size": 4})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, four, *decode[models.Reservation](t, w.Body.Bytes()).Table_id)
+
+	code, _ = book(router, 2, dinner.Add(time.Hour), "5550003")
+	assert.Equal(t, http.StatusOK, code)
+
+	// cancelling frees the table
+	w = performRequest(router, "POST", "/reservations/"+second.Reservation_id+"/cancel", nil)
+	assert.Equal(t, models.ReservationCancelled, decode[models.Reservation](t, w.Body.Bytes()).Status)
+	w = performRequest(router, "POST", "/reservations/"+second.Reservation_id+"/seat", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(router, "PATCH", "/reservations/"+second.Reservation_id, gin.H{"party_size": 3})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(router, "GET", "/reservations/availability?party_size=4&at="+dinner.Add(30*time.Minute).Format(time.RFC3339), nil)
+	assert.Len(t, decode[Availability](t, w.Body.Bytes()).Slot.Tables, 1)
+
+	date := dinner.Local().Format("2006-01-02")
+	w = performRequest(router, "GET", "/reservations?status=BOOKED&date="+date, nil)
+	for _, reservation := range decode[[]models.Reservation](t, w.Body.Bytes()) {
+		assert.Equal(t, models.ReservationBooked, reservation.Status)
+	}
+}
+
+func TestReservationNoShow(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupReservationRouter(store)
+	createTestTable(t, router, 2, 1)
+
+	code, reservation := book(router, 2, time.Now().Add(time.Hour), "5550001")
+	require.Equal(t, http.StatusOK, code)
+
+	w := performRequest(router, "POST", "/reservations/"+reservation.Reservation_id+"/no-show", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "the party still has time")
+
+	// the booking was for twenty minutes ago
+	late := time.Now().Add(-20 * time.Minute)
+	require.NoError(t, store.Reservations.UpdateStatus(context.Background(), reservation.Reservation_id, models.ReservationBooked, bson.D{{Key: "starts_at", Value: &late}}))
+
+	w = performRequest(router, "POST", "/reservations/"+reservation.Reservation_id+"/no-show", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.ReservationNoShow, decode[models.Reservation](t, w.Body.Bytes()).Status)
+
+	// the next booking of the guest shows the no-show
+	code, next := book(router, 2, time.Now().Add(24*time.Hour), "5550001")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, next.Previous_no_shows)
+
+	w = performRequest(router, "GET", "/reservations?phone=5550001", nil)
+	assert.Len(t, decode[[]models.Reservation](t, w.Body.Bytes()), 2)
+}
+
+func TestConcurrentReservationsDoNotDoubleBook(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupReservationRouter(store)
+	createTestTable(t, router, 4, 1)
+
+	at := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	codes := make([]int, 8)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i], _ = book(router, 2, at, "5550001")
+		}()
+	}
+	wg.Wait()
+
+	booked := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			booked++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 1, booked)
+}
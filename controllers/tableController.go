@@ -2,61 +2,58 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/events"
 	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var tableCollection *mongo.Collection = database.OpenCollection(database.Client, "table")
-
-func GetTabels() gin.HandlerFunc {
+func GetTabels(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		result, err := tableCollection.Find(context.TODO(), bson.M{})
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
+
+		allTables, err := store.Tables.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching tables"})
-		}
-		var allTables []bson.M
-		if err = result.All(ctx, &allTables); err != nil {
-			log.Fatal(err)
+			return
 		}
 		c.JSON(http.StatusOK, allTables)
 	}
 }
 
-func GetTabel() gin.HandlerFunc {
+func GetTabel(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
 		tableId := c.Param("table_id")
 
-		var table models.Table
-
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": tableId}).Decode(&table)
-		defer cancel()
+		table, err := store.Tables.FindByID(ctx, tableId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+			return
 		}
 		c.JSON(http.StatusOK, table)
 	}
 }
 
-func CreateTable() gin.HandlerFunc {
+func CreateTable(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var table models.Table
 		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
@@ -66,27 +63,36 @@ func CreateTable() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
 			return
 		}
+		if table.MinParty() > table.MaxParty() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_capacity cannot be more than the table takes"})
+			return
+		}
+		table.Status = models.TableFree
+		table.Order_id = nil
+		table.Status_changed_at = nil
+		table.Group_id = nil
 		table.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 		table.ID = primitive.NewObjectID()
 		table.Table_id = table.ID.Hex()
 
-		result, inserterr := tableCollection.InsertOne(ctx, table)
+		inserterr := store.Tables.Insert(ctx, table)
 		if inserterr != nil {
 			msg := "error while creating the table item"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
+		Events.Publish(events.TopicTables, events.TableCreated, table)
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, table)
 
 	}
 }
 
-func UpdateTable() gin.HandlerFunc {
+func UpdateTable(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		var table models.Table
@@ -94,13 +100,29 @@ func UpdateTable() gin.HandlerFunc {
 		tableId := c.Param("table_id")
 
 		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.StructExcept(table, "Number_of_guests", "Table_number"); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		current, err := store.Tables.FindByID(ctx, tableId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
 			return
 		}
 
 		var updateObj primitive.D
 
 		if table.Number_of_guests != nil {
+			current.Number_of_guests = table.Number_of_guests
 			updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: table.Number_of_guests})
 		}
 
@@ -108,29 +130,53 @@ func UpdateTable() gin.HandlerFunc {
 			updateObj = append(updateObj, bson.E{Key: "table_number", Value: table.Table_number})
 		}
 
-		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-		upsert := true
+		if table.Min_capacity != nil {
+			current.Min_capacity = table.Min_capacity
+			updateObj = append(updateObj, bson.E{Key: "min_capacity", Value: table.Min_capacity})
+		}
+		if table.Max_capacity != nil {
+			current.Max_capacity = table.Max_capacity
+			updateObj = append(updateObj, bson.E{Key: "max_capacity", Value: table.Max_capacity})
+		}
+		if current.MinParty() > current.MaxParty() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_capacity cannot be more than the table takes"})
+			return
+		}
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		if table.Section != nil {
+			updateObj = append(updateObj, bson.E{Key: "section", Value: table.Section})
+		}
+		if table.Position_x != nil {
+			updateObj = append(updateObj, bson.E{Key: "position_x", Value: table.Position_x})
+		}
+		if table.Position_y != nil {
+			updateObj = append(updateObj, bson.E{Key: "position_y", Value: table.Position_y})
 		}
-		filter := bson.M{"table_id": tableId}
+		if table.Shape != nil {
+			updateObj = append(updateObj, bson.E{Key: "shape", Value: table.Shape})
+		}
+
+		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: table.Updated_at})
 
-		result, err := tableCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		err = store.Tables.Update(ctx, tableId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
 		if err != nil {
 			msg := "table item update failed"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		updatedTable, err := store.Tables.FindByID(ctx, tableId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+			return
+		}
+		Events.Publish(events.TopicTables, events.TableUpdated, updatedTable)
+
+		c.JSON(http.StatusOK, updatedTable)
 	}
 }
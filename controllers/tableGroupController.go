@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTableGroups returns the tables merged for parties.
+func GetTableGroups(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		groups, err := store.TableGroups.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the table groups"})
+			return
+		}
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+// MergeTables pushes tables together into a group seating the party of all
+// of them. Orders for the group are seated at the primary table, the first
+// table unless named. Only the primary table may have a party seated already.
+func MergeTables(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var body struct {
+			Table_ids        []string `json:"table_ids" validate:"required,min=2,dive,required"`
+			Primary_table_id *string  `json:"primary_table_id"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		group := models.TableGroup{Table_ids: body.Table_ids, Primary_table_id: body.Table_ids[0]}
+		if body.Primary_table_id != nil {
+			group.Primary_table_id = *body.Primary_table_id
+		}
+
+		primaryListed := false
+		seen := map[string]bool{}
+		for _, tableId := range body.Table_ids {
+			if seen[tableId] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "table " + tableId + " is listed twice"})
+				return
+			}
+			seen[tableId] = true
+			primaryListed = primaryListed || tableId == group.Primary_table_id
+
+			table, err := store.Tables.FindByID(ctx, tableId)
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "table " + tableId + " not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+				return
+			}
+			if table.Group_id != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "table " + strconv.Itoa(tableNumber(table)) + " is already merged"})
+				return
+			}
+			if tableId != group.Primary_table_id {
+				seated, err := partySeated(ctx, store, tableId)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the orders of the table"})
+					return
+				}
+				if seated {
+					c.JSON(http.StatusConflict, gin.H{"error": "a party sits at table " + strconv.Itoa(tableNumber(table)) + ", move its order first"})
+					return
+				}
+			}
+			if table.Number_of_guests != nil {
+				group.Number_of_guests += *table.Number_of_guests
+			}
+			group.Max_capacity += table.MaxParty()
+		}
+		if !primaryListed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the primary table must be one of the tables merged"})
+			return
+		}
+
+		group.ID = primitive.NewObjectID()
+		group.Group_id = group.ID.Hex()
+		group.Created_by = c.GetString("uid")
+		group.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		// the tables are claimed first, so two merges of the same table
+		// cannot both succeed
+		err := store.Tables.Join(ctx, group.Table_ids, group.Group_id)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "one of the tables was merged in the meantime"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the tables were not merged"})
+			return
+		}
+		if err := store.TableGroups.Insert(ctx, group); err != nil {
+			store.Tables.Leave(ctx, group.Group_id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the tables were not merged"})
+			return
+		}
+		Events.Publish(events.TopicTables, events.TablesMerged, group)
+
+		c.JSON(http.StatusOK, group)
+	}
+}
+
+// SplitTableGroup takes the tables of a group apart again. The orders of the
+// group stay at the primary table.
+func SplitTableGroup(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		groupId := c.Param("group_id")
+
+		group, err := store.TableGroups.FindByID(ctx, groupId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table group not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the table group"})
+			return
+		}
+
+		if err := store.Tables.Leave(ctx, groupId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the tables were not split"})
+			return
+		}
+		err = store.TableGroups.Delete(ctx, groupId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the tables were not split"})
+			return
+		}
+		Events.Publish(events.TopicTables, events.TablesSplit, group)
+
+		tables := []models.Table{}
+		for _, tableId := range group.Table_ids {
+			table, err := store.Tables.FindByID(ctx, tableId)
+			if err == nil {
+				tables = append(tables, table)
+			}
+		}
+		c.JSON(http.StatusOK, tables)
+	}
+}
+
+// seatingTable returns the table orders for tableId are seated at: the table
+// itself, or the primary table of the group it is merged into.
+func seatingTable(ctx context.Context, store *repository.Store, tableId string) (models.Table, error) {
+	table, err := store.Tables.FindByID(ctx, tableId)
+	if err != nil || table.Group_id == nil {
+		return table, err
+	}
+	group, err := store.TableGroups.FindByID(ctx, *table.Group_id)
+	if err == repository.ErrNotFound || (err == nil && group.Primary_table_id == tableId) {
+		return table, nil
+	}
+	if err != nil {
+		return table, err
+	}
+	return store.Tables.FindByID(ctx, group.Primary_table_id)
+}
+
+// partySeated tells whether an open order is seated at the table.
+func partySeated(ctx context.Context, store *repository.Store, tableId string) (bool, error) {
+	orders, err := store.Orders.FindByTable(ctx, tableId)
+	if err != nil {
+		return false, err
+	}
+	for _, order := range orders {
+		if orderOpen(order) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
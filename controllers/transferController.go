@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TransferOrder moves a party to another table with its order. The kitchen
+// tickets of the order follow it to the new table.
+func TransferOrder(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		orderId := c.Param("order_id")
+
+		var body struct {
+			Table_id string `json:"table_id" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		if !orderOpen(order) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a " + order.CurrentStatus() + " order stays at its table"})
+			return
+		}
+
+		table, status, msg := transferTable(ctx, store, body.Table_id)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if order.Table_id != nil && *order.Table_id == table.Table_id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the order is already at the table"})
+			return
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = store.Orders.Update(ctx, orderId, bson.D{
+			{Key: "table_id", Value: table.Table_id},
+			{Key: "updated_at", Value: updatedAt},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating order"})
+			return
+		}
+		retagTickets(ctx, store, orderId, table)
+
+		Events.Publish(events.TopicOrders, events.OrderTransferred, gin.H{
+			"order_id":      orderId,
+			"from_table_id": order.Table_id,
+			"table_id":      table.Table_id,
+		})
+
+		updatedOrder, err := store.Orders.FindByID(ctx, orderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		c.JSON(http.StatusOK, updatedOrder)
+	}
+}
+
+// TransferOrderItems moves items of an order to the party at another table.
+// They are added to the open order there, or to a new order when the table
+// has none or its bill was paid into. Items with a void or comp, and items of
+// an order already paid into, stay where they are so no bill changes after
+// money was taken against it.
+func TransferOrderItems(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		orderId := c.Param("order_id")
+
+		var body struct {
+			Order_item_ids []string `json:"order_item_ids" validate:"required,min=1,dive,required"`
+			Table_id       string   `json:"table_id" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		order, err := store.Orders.FindByID(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the order"})
+			return
+		}
+		if !orderOpen(order) {
+			c.JSON(http.StatusConflict, gin.H{"error": "the items of a " + order.CurrentStatus() + " order cannot be moved"})
+			return
+		}
+		if status, msg := checkItemsMovable(ctx, store, orderId, body.Order_item_ids); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		table, status, msg := transferTable(ctx, store, body.Table_id)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if order.Table_id != nil && *order.Table_id == table.Table_id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the items are already at the table"})
+			return
+		}
+
+		target, found, err := receivingOrder(ctx, store, table.Table_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the orders of the table"})
+			return
+		}
+		if !found {
+			placed := models.OrderPlaced
+			orderDate, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+			target, status, msg = createOrder(ctx, store, models.Order{
+				Order_date: orderDate,
+				Table_id:   &table.Table_id,
+				Status:     &placed,
+			}, c.GetString("uid"))
+			if msg != "" {
+				c.JSON(status, gin.H{"error": msg})
+				return
+			}
+		}
+
+		err = store.OrderItems.Move(ctx, body.Order_item_ids, orderId, target.Order_id)
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the items were changed in the meantime, please retry"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the items were not moved"})
+			return
+		}
+		Events.Publish(events.TopicOrders, events.OrderItemsTransferred, gin.H{
+			"order_id":       orderId,
+			"from_table_id":  order.Table_id,
+			"to_order_id":    target.Order_id,
+			"table_id":       table.Table_id,
+			"order_item_ids": body.Order_item_ids,
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"order_id":       orderId,
+			"to_order_id":    target.Order_id,
+			"table_id":       table.Table_id,
+			"order_item_ids": body.Order_item_ids,
+		})
+	}
+}
+
+// transferTable returns the table a party or items moving to tableId are
+// seated at. On failure it returns the HTTP status and message to send back.
+func transferTable(ctx context.Context, store *repository.Store, tableId string) (models.Table, int, string) {
+	table, err := seatingTable(ctx, store, tableId)
+	if err == repository.ErrNotFound {
+		return table, http.StatusNotFound, "table not found"
+	}
+	if err != nil {
+		return table, http.StatusInternalServerError, "error while fetching table details"
+	}
+	return table, http.StatusOK, ""
+}
+
+// checkItemsMovable checks the items are all in the order and can leave it.
+// On failure it returns the HTTP status and message to send back.
+func checkItemsMovable(ctx context.Context, store *repository.Store, orderId string, orderItemIds []string) (int, string) {
+	if paid, err := paidInto(ctx, store, orderId); err != nil {
+		return http.StatusInternalServerError, "error while fetching the invoices of the order"
+	} else if paid {
+		return http.StatusConflict, "payments were taken against the order, its items cannot be moved"
+	}
+
+	orderItems, err := store.OrderItems.FindByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the order items"
+	}
+	inOrder := map[string]bool{}
+	for _, orderItem := range orderItems {
+		inOrder[orderItem.Order_item_id] = true
+	}
+
+	adjustments, err := store.Adjustments.FindByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, "error while fetching the adjustments of the order"
+	}
+	adjusted := map[string]bool{}
+	for _, adjustment := range adjustments {
+		if adjustment.Order_item_id != nil && adjustment.Status != models.AdjustmentRejected {
+			adjusted[*adjustment.Order_item_id] = true
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, orderItemId := range orderItemIds {
+		if seen[orderItemId] {
+			return http.StatusBadRequest, "order item " + orderItemId + " is listed twice"
+		}
+		seen[orderItemId] = true
+		if !inOrder[orderItemId] {
+			return http.StatusBadRequest, "order item " + orderItemId + " is not in the order"
+		}
+		if adjusted[orderItemId] {
+			return http.StatusConflict, "order item " + orderItemId + " has a void or comp against it and stays on its bill"
+		}
+	}
+	return http.StatusOK, ""
+}
+
+// paidInto tells whether any money was taken against the invoices of the
+// order.
+func paidInto(ctx context.Context, store *repository.Store, orderId string) (bool, error) {
+	invoices, err := store.Invoices.FindByOrder(ctx, orderId)
+	if err != nil {
+		return false, err
+	}
+	for _, invoice := range invoices {
+		if invoice.Amount_paid > 0 || (invoice.Payment_status != nil && *invoice.Payment_status == models.PaymentPaid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// receivingOrder returns the latest open order at the table that nothing was
+// paid into yet, the one moved items are added to.
+func receivingOrder(ctx context.Context, store *repository.Store, tableId string) (models.Order, bool, error) {
+	orders, err := store.Orders.FindByTable(ctx, tableId)
+	if err != nil {
+		return models.Order{}, false, err
+	}
+	var latest models.Order
+	found := false
+	for _, order := range orders {
+		if !orderOpen(order) || (found && !order.Created_at.After(latest.Created_at)) {
+			continue
+		}
+		paid, err := paidInto(ctx, store, order.Order_id)
+		if err != nil {
+			return models.Order{}, false, err
+		}
+		if !paid {
+			latest, found = order, true
+		}
+	}
+	return latest, found, nil
+}
+
+// retagTickets shows the kitchen tickets of the order under its new table.
+func retagTickets(ctx context.Context, store *repository.Store, orderId string, table models.Table) {
+	if err := store.Tickets.SetTableNumber(ctx, orderId, table.Table_number); err != nil {
+		log.Printf("kitchen tickets for order %s: %v", orderId, err)
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/floor"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func setupTransferRouter(store *repository.Store) *gin.Engine {
+	router := setupOrderRouter(store, models.RoleServer)
+	router.POST("/tables/merge", MergeTables(store))
+	router.GET("/table-groups", GetTableGroups(store))
+	router.POST("/table-groups/:group_id/split", SplitTableGroup(store))
+	router.POST("/orders/:order_id/transfer", TransferOrder(store))
+	router.POST("/orders/:order_id/transfer-items", TransferOrderItems(store))
+	return router
+}
+
+func TestMergeAndSplitTables(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupTransferRouter(store)
+	tracker := floor.NewTracker(store, Events)
+	t.Cleanup(tracker.Close)
+	ctx := context.Background()
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	first := createTestTable(t, router, 4, 1)
+	second := createTestTable(t, router, 4, 2)
+	third := createTestTable(t, router, 2, 3)
+	createTestOrder(t, router, third, burger)
+
+	w := performRequest(router, "POST", "/tables/merge", gin.H{"table_ids": []string{first}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/tables/merge", gin.H{"table_ids": []string{first, third}})
+	assert.Equal(t, http.StatusConflict, w.Code, "a party sits at the table that would be merged away")
+
+	w = performRequest(router, "POST", "/tables/merge", gin.H{"table_ids": []string{first, second}})
+	require.Equal(t, http.StatusOK, w.Code)
+	group := decode[models.TableGroup](t, w.Body.Bytes())
+	assert.Equal(t, first, group.Primary_table_id)
+	assert.Equal(t, 8, group.Number_of_guests)
+	assert.Equal(t, 8, group.Max_capacity)
+
+	w = performRequest(router, "POST", "/tables/merge", gin.H{"table_ids": []string{second, third}, "primary_table_id": third})
+	assert.Equal(t, http.StatusConflict, w.Code, "a table is in one group at a time")
+
+	// a party seated at any table of the group sits at the primary table, and
+	// the whole group is taken
+	w = performRequest(router, "POST", "/orders", gin.H{"table_id": second, "order_date": "2026-10-16T12:00:00Z"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, first, *decode[models.Order](t, w.Body.Bytes()).Table_id)
+	assert.Eventually(t, func() bool {
+		table, _ := store.Tables.FindByID(ctx, second)
+		return table.CurrentStatus() == models.TableSeated
+	}, time.Second, 5*time.Millisecond)
+
+	w = performRequest(router, "GET", "/table-groups", nil)
+	assert.Len(t, decode[[]models.TableGroup](t, w.Body.Bytes()), 1)
+
+	w = performRequest(router, "POST", "/table-groups/"+group.Group_id+"/split", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	for _, table := range decode[[]models.Table](t, w.Body.Bytes()) {
+		assert.Nil(t, table.Group_id)
+	}
+	assert.Eventually(t, func() bool {
+		table, _ := store.Tables.FindByID(ctx, second)
+		return table.CurrentStatus() == models.TableFree
+	}, time.Second, 5*time.Millisecond)
+
+	w = performRequest(router, "POST", "/table-groups/"+group.Group_id+"/split", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTransferOrderAndItems(t *testing.T) {
+	store := repository.NewMemoryStore()
+	configureTestStations(t)
+	router := setupTransferRouter(store)
+	ctx := context.Background()
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	fries := createTestFood(t, router, "Fries", "Sides", 4)
+	window := createTestTable(t, router, 2, 1)
+	booth := createTestTable(t, router, 4, 2)
+	bar := createTestTable(t, router, 2, 3)
+
+	summaryOf := func(orderId string) OrderItemsSummary {
+		w := performRequest(router, "GET", "/orderItems-order/"+orderId, nil)
+		summaries := decode[[]OrderItemsSummary](t, w.Body.Bytes())
+		require.Len(t, summaries, 1)
+		return summaries[0]
+	}
+
+	// the party at the window moves to the booth, the kitchen follows
+	moving := createTestOrder(t, router, window, burger)
+	w := performRequest(router, "POST", "/orders/"+moving+"/transfer", gin.H{"table_id": booth})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, booth, *decode[models.Order](t, w.Body.Bytes()).Table_id)
+	assert.Equal(t, 2, *summaryOf(moving).Table_number)
+	tickets, _ := store.Tickets.FindByOrder(ctx, moving)
+	require.NotEmpty(t, tickets)
+	assert.Equal(t, 2, *tickets[0].Table_number)
+
+	w = performRequest(router, "POST", "/orders/"+moving+"/transfer", gin.H{"table_id": booth})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/orders/"+moving+"/transfer", gin.H{"table_id": "missing"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// a friend at the bar brings their fries over to the booth
+	staying := createTestOrder(t, router, bar, burger, fries)
+	items := summaryOf(staying).Order_items
+	w = performRequest(router, "POST", "/orders/"+staying+"/transfer-items", gin.H{"order_item_ids": []string{"missing"}, "table_id": booth})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/orders/"+staying+"/transfer-items", gin.H{"order_item_ids": []string{items[1].Order_item_id}, "table_id": booth})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, moving, decode[gin.H](t, w.Body.Bytes())["to_order_id"])
+
+	assert.Equal(t, 10.0, summaryOf(staying).Payment_due)
+	booked := summaryOf(moving)
+	assert.Equal(t, 14.0, booked.Payment_due)
+	assert.Equal(t, 2, booked.Total_count)
+
+	// the invoice of the booth follows its items
+	w = performRequest(router, "POST", "/invoices", gin.H{"order_id": moving})
+	require.Equal(t, http.StatusOK, w.Code)
+	invoiceId := decode[models.Invoice](t, w.Body.Bytes()).Invoice_id
+	w = performRequest(router, "GET", "/invoices/"+invoiceId, nil)
+	assert.Equal(t, 14.0, decode[InvoiceViewFormat](t, w.Body.Bytes()).Payment_due)
+
+	// once paid into, the bill of the booth no longer changes: nothing leaves
+	// it, and items sent its way start a new order
+	require.NoError(t, store.Invoices.Update(ctx, invoiceId, bson.D{{Key: "amount_paid", Value: 5.0}}))
+	w = performRequest(router, "POST", "/orders/"+moving+"/transfer-items", gin.H{"order_item_ids": []string{booked.Order_items[0].Order_item_id}, "table_id": bar})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(router, "POST", "/orders/"+staying+"/transfer-items", gin.H{"order_item_ids": []string{items[0].Order_item_id}, "table_id": booth})
+	require.Equal(t, http.StatusOK, w.Code)
+	newOrder := decode[gin.H](t, w.Body.Bytes())["to_order_id"].(string)
+	assert.NotEqual(t, moving, newOrder)
+	assert.Equal(t, 10.0, summaryOf(newOrder).Payment_due)
+	assert.Equal(t, 2, *summaryOf(newOrder).Table_number)
+	assert.Equal(t, 14.0, summaryOf(moving).Payment_due)
+}
+
+func TestUpdateOrderDoesNotMoveTables(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupTransferRouter(store)
+	router.PATCH("/orders/:order_id", UpdateOrder(store))
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	first := createTestTable(t, router, 2, 1)
+	second := createTestTable(t, router, 2, 2)
+	orderId := createTestOrder(t, router, first, burger)
+
+	w := performRequest(router, "PATCH", "/orders/"+orderId, gin.H{"table_id": second})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	order, err := store.Orders.FindByID(context.Background(), orderId)
+	require.NoError(t, err)
+	assert.Equal(t, first, *order.Table_id)
+}
@@ -9,20 +9,16 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
 	"github.com/rkmangalp/Restaurant_Management/helpers"
 	"github.com/rkmangalp/Restaurant_Management/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "users")
-
-func GetUsers() gin.HandlerFunc {
+func GetUsers(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		// Extract query parameters for pagination
@@ -39,63 +35,43 @@ func GetUsers() gin.HandlerFunc {
 		// calculate the starting index
 		startIndex := (page - 1) * recordPerPage
 
-		// mongoDB aggregation pipeline
-		matchStage := bson.D{{Key: "$match", Value: bson.D{}}}
-
-		groupStage := bson.D{
-			{Key: "$group", Value: bson.D{
-				{Key: "_id", Value: nil},                                      // Grouping all users
-				{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},  // Count total users
-				{Key: "data", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}}, // Push all users into "data"
-			}},
-		}
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0}, // Exclude _id
-				{Key: "total_count", Value: 1},
-				{Key: "users", Value: bson.D{
-					{Key: "$slice", Value: []any{"$data", startIndex, recordPerPage}}, // Apply pagination
-				}},
-			}},
-		}
-
-		// Execute the aggregation query
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage, groupStage, projectStage})
+		// fetch one page of users and the total count
+		users, total, err := store.Users.List(ctx, startIndex, recordPerPage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing user items"})
 			return
 		}
 
-		// Decode the result
-		var allUsers []bson.M
-		if err = result.All(ctx, &allUsers); err != nil {
-			log.Fatal(err)
-		}
-
 		// If no users found, return empty list
-		if len(allUsers) == 0 {
-			c.JSON(http.StatusOK, gin.H{"users": []bson.M{}})
+		if total == 0 {
+			c.JSON(http.StatusOK, gin.H{"users": []models.User{}})
 			return
 		}
 
 		// Return users with pagination info
-		c.JSON(http.StatusOK, gin.H{"users": allUsers[0]})
+		c.JSON(http.StatusOK, gin.H{"users": gin.H{
+			"total_count": total,
+			"users":       users,
+		}})
 
 	}
 }
 
-func GetUser() gin.HandlerFunc {
+func GetUser(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 
 		userId := c.Param("user_id")
-		var user models.User
 
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		user, err := store.Users.FindByID(ctx, userId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listin user Items"})
+			return
 		}
 
 		c.JSON(http.StatusOK, user)
@@ -103,9 +79,9 @@ func GetUser() gin.HandlerFunc {
 	}
 }
 
-func SignUp() gin.HandlerFunc {
+func SignUp(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 		var user models.User
 
@@ -114,13 +90,14 @@ func SignUp() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if user.UserType == "" {
-			user.UserType = "USER"
-		} else if user.UserType == "ADMIN" {
-			// ensure only ADMIN can create a another ADMIN
+		// USER is the old name of the CUSTOMER role
+		if user.UserType == "" || user.UserType == "USER" {
+			user.UserType = models.RoleCustomer
+		} else if user.UserType != models.RoleCustomer {
+			// ensure only ADMIN can create staff accounts
 			requestType, exists := c.Get("user_type") // get user role from JWT
-			if !exists || requestType != "ADMIN" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Only ADMIN can create another ADMIN"})
+			if !exists || requestType != models.RoleAdmin {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only ADMIN can create staff accounts"})
 				return
 			}
 		}
@@ -133,9 +110,8 @@ func SignUp() gin.HandlerFunc {
 		}
 
 		// check if the email has already been used by another user
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		emailCount, err := store.Users.CountByEmail(ctx, *user.Email)
 		if err != nil {
-			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while checking for user email"})
 			return
 		}
@@ -145,14 +121,13 @@ func SignUp() gin.HandlerFunc {
 		user.Password = &password
 
 		// you will also check if phone number is already used by another user
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+		phoneCount, err := store.Users.CountByPhone(ctx, *user.Phone)
 		if err != nil {
-			log.Panic(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while checking for user Phone number"})
 			return
 		}
 
-		if count > 0 {
+		if emailCount > 0 || phoneCount > 0 {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "this email or phone number already exists"})
 			return
 		}
@@ -163,18 +138,26 @@ func SignUp() gin.HandlerFunc {
 		user.ID = primitive.NewObjectID()
 		user.User_id = user.ID.Hex()
 
-		// generate and refresh token (generate all token function from helpers)
-		token, refreshtoken, _ := helpers.GenerateAllTokens(*user.Email, *user.First_name, *user.Last_name, user.User_id)
-		user.Token = &token
-		user.Refresh_token = &refreshtoken
-
 		// if all ok, then insert this new user into the user collection
-		_, insertErr := userCollection.InsertOne(ctx, user)
+		insertErr := store.Users.Insert(ctx, user)
 		if insertErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "User item not created"})
 			return
 		}
 
+		// the session is only started for a user that was saved
+		token, refreshtoken, err := helpers.IssueTokens(store.Sessions, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user was created, but not its tokens, please log in"})
+			return
+		}
+		if err := store.Users.UpdateTokens(ctx, user.User_id, token, refreshtoken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user was created, but not its tokens, please log in"})
+			return
+		}
+		user.Token = &token
+		user.Refresh_token = &refreshtoken
+
 		// return STATUSOK and send the result back
 		c.JSON(http.StatusOK, gin.H{
 			"message":       "User created sucessfully",
@@ -190,12 +173,11 @@ func SignUp() gin.HandlerFunc {
 	}
 }
 
-func Login() gin.HandlerFunc {
+func Login(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
 		defer cancel()
 		var user models.User
-		var foundUser models.User
 
 		// convert login json data to golang reable format
 		if err := c.BindJSON(&user); err != nil {
@@ -204,7 +186,11 @@ func Login() gin.HandlerFunc {
 		}
 
 		// find the user with the email and see if user exists
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+			return
+		}
+		foundUser, err := store.Users.FindByEmail(ctx, *user.Email)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found, login seems to be incorrect"})
 			return
@@ -213,20 +199,138 @@ func Login() gin.HandlerFunc {
 		// then verify the password
 		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
 		if passwordIsValid != true {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
 		}
 
 		//if all goes well, then you will generate tokens
-		token, refreshToken, _ := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, foundUser.User_id)
+		token, refreshToken, err := helpers.IssueTokens(store.Sessions, foundUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while generating tokens"})
+			return
+		}
 
 		// update the tokens - token and refresh token
-		helpers.UpdateAllTokens(token, refreshToken, foundUser.User_id)
+		if err := store.Users.UpdateTokens(ctx, foundUser.User_id, token, refreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving tokens"})
+			return
+		}
+		foundUser.Token = &token
+		foundUser.Refresh_token = &refreshToken
 
 		// return STATUSOK
 		c.JSON(http.StatusOK, foundUser)
 	}
 }
 
+// RefreshToken exchanges a refresh token for a new token pair. The refresh token
+// is rotated on every call; presenting one that was already exchanged means it
+// has leaked, so the whole session is revoked.
+func RefreshToken(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var body struct {
+			Refresh_token string `json:"refresh_token" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(body); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		claims, msg := helpers.ValidateRefreshToken(body.Refresh_token)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+
+		session, err := store.Sessions.FindSession(ctx, claims.Session_id)
+		if err != nil || session.Revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "the session has been revoked"})
+			return
+		}
+
+		// rotate the refresh token, an old token being replayed revokes the session
+		newRefreshTokenId := primitive.NewObjectID().Hex()
+		rotated := false
+		if session.Refresh_token_id == claims.Id {
+			rotated, err = store.Sessions.RotateRefreshToken(ctx, session.Session_id, claims.Id, newRefreshTokenId, helpers.RefreshTokenExpiry())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while rotating the refresh token"})
+				return
+			}
+		}
+		if !rotated {
+			if err := store.Sessions.RevokeSession(ctx, session.Session_id); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while revoking the session"})
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, the session has been revoked"})
+			return
+		}
+
+		// read the user again so a changed role ends up in the new token
+		foundUser, err := store.Users.FindByID(ctx, claims.Uid)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			return
+		}
+
+		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, foundUser.User_id, foundUser.UserType, session.Session_id, newRefreshTokenId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while generating tokens"})
+			return
+		}
+
+		if err := store.Users.UpdateTokens(ctx, foundUser.User_id, token, refreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while saving tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"token":         token,
+			"refresh_token": refreshToken,
+		})
+	}
+}
+
+// Logout revokes the session of the calling token, which also invalidates its refresh token.
+func Logout(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		if err := store.Sessions.RevokeSession(ctx, c.GetString("session_id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while logging out"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+	}
+}
+
+// RevokeUserSessions logs a user out everywhere, e.g. after a stolen token.
+func RevokeUserSessions(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		if err := store.Sessions.RevokeUserSessions(ctx, userId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while revoking the user sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked", "user_id": userId})
+	}
+}
+
 func HashPassword(password string) string {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	if err != nil {
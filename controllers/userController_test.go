@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,20 +12,38 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 // ✅ Helper function to create a test router
-func setupRouter() *gin.Engine {
+func setupRouter(store *repository.Store) *gin.Engine {
 	router := gin.Default()
-	router.POST("/users/signup", SignUp())
+	router.POST("/users/signup", SignUp(store))
+	router.POST("/users/login", Login(store))
+	router.POST("/users/refresh", RefreshToken(store))
 	return router
 }
 
+// ✅ Helper function to send a JSON request to a router
+func performRequest(router *gin.Engine, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer = &bytes.Buffer{}
+	if body != nil {
+		jsonValue, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(jsonValue)
+	}
+
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
 func TestSignupSuccess(t *testing.T) {
-	router := setupRouter()
+	router := setupRouter(repository.NewMemoryStore())
 
 	// ✅ Generate unique email & phone to avoid duplicate entry error
 	randomEmail := fmt.Sprintf("testuser%d@example.com", time.Now().UnixNano())
@@ -39,25 +58,122 @@ func TestSignupSuccess(t *testing.T) {
 		"user_type":  "USER",
 	}
 
-	// Convert struct to JSON
-	jsonValue, _ := json.Marshal(user)
-	req, _ := http.NewRequest("POST", "/users/signup", bytes.NewBuffer(jsonValue))
-	req.Header.Set("Content-Type", "application/json")
-
 	// Simulate HTTP request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := performRequest(router, "POST", "/users/signup", user)
 
 	// ✅ Check response status code
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// ✅ Check response contains success message
 	assert.Contains(t, w.Body.String(), "User created sucessfully")
+}
+
+func TestSignupRejectsDuplicateEmail(t *testing.T) {
+	router := setupRouter(repository.NewMemoryStore())
 
-	// ✅ Cleanup: Delete the test user from MongoDB
-	db := database.OpenCollection(database.Client, "users")
-	_, err := db.DeleteOne(context.TODO(), bson.M{"email": randomEmail})
-	if err != nil {
-		t.Fatalf("Failed to delete test user: %v", err)
+	user := map[string]string{
+		"first_name": "John",
+		"last_name":  "Doe",
+		"email":      "john@example.com",
+		"phone":      "9876500001",
+		"password":   "SecurePass123",
 	}
+	assert.Equal(t, http.StatusOK, performRequest(router, "POST", "/users/signup", user).Code)
+
+	user["phone"] = "9876500002"
+	w := performRequest(router, "POST", "/users/signup", user)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "already exists")
+}
+
+// failingUsers refuses to save any user.
+type failingUsers struct {
+	repository.UserRepository
+}
+
+func (failingUsers) Insert(ctx context.Context, user models.User) error {
+	return errors.New("write refused")
+}
+
+// countedSessions counts the sessions started.
+type countedSessions struct {
+	repository.SessionRepository
+	started int
+}
+
+func (s *countedSessions) StartSession(ctx context.Context, session models.Session) error {
+	s.started++
+	return s.SessionRepository.StartSession(ctx, session)
+}
+
+func TestSignupStartsNoSessionForUnsavedUser(t *testing.T) {
+	store := repository.NewMemoryStore()
+	sessions := &countedSessions{SessionRepository: store.Sessions}
+	store.Users = failingUsers{store.Users}
+	store.Sessions = sessions
+	router := setupRouter(store)
+
+	user := map[string]string{
+		"first_name": "John",
+		"last_name":  "Doe",
+		"email":      "john@example.com",
+		"phone":      "9876500001",
+		"password":   "SecurePass123",
+	}
+	w := performRequest(router, "POST", "/users/signup", user)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Zero(t, sessions.started)
+}
+
+func TestSignupStaffNeedsAdmin(t *testing.T) {
+	router := setupRouter(repository.NewMemoryStore())
+
+	user := map[string]string{
+		"first_name": "John",
+		"last_name":  "Doe",
+		"email":      "john@example.com",
+		"phone":      "9876500001",
+		"password":   "SecurePass123",
+		"user_type":  "MANAGER",
+	}
+
+	w := performRequest(router, "POST", "/users/signup", user)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	router := setupRouter(repository.NewMemoryStore())
+
+	user := map[string]string{
+		"first_name": "John",
+		"last_name":  "Doe",
+		"email":      "john@example.com",
+		"phone":      "9876500001",
+		"password":   "SecurePass123",
+	}
+	w := performRequest(router, "POST", "/users/signup", user)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var signedUp struct {
+		Refresh_token string `json:"refresh_token"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &signedUp))
+
+	// the first exchange rotates the refresh token
+	w = performRequest(router, "POST", "/users/refresh", gin.H{"refresh_token": signedUp.Refresh_token})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var refreshed struct {
+		Refresh_token string `json:"refresh_token"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.NotEqual(t, signedUp.Refresh_token, refreshed.Refresh_token)
+
+	// replaying the old token revokes the session, so the new one stops working too
+	w = performRequest(router, "POST", "/users/refresh", gin.H{"refresh_token": signedUp.Refresh_token})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "reuse detected")
+
+	w = performRequest(router, "POST", "/users/refresh", gin.H{"refresh_token": refreshed.Refresh_token})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
@@ -0,0 +1,522 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WaitingParty is a waitlist entry with its place in the queue and the wait,
+// in minutes, estimated now. Estimated_wait is only set while the party waits.
+type WaitingParty struct {
+	models.WaitlistEntry
+	Position       int  `json:"position"`
+	Estimated_wait *int `json:"estimated_wait"`
+}
+
+// WaitEstimate is the wait a new party would be quoted.
+type WaitEstimate struct {
+	Party_size     int `json:"party_size"`
+	Parties_ahead  int `json:"parties_ahead"`
+	Estimated_wait int `json:"estimated_wait"`
+	Turn_time      int `json:"turn_time"`
+}
+
+// floorState is what waits are estimated from: the tables, the open order
+// seated at each, the reservations holding them soon and the turn time.
+type floorState struct {
+	now          time.Time
+	tables       []models.Table
+	openOrders   map[string]models.Order
+	reservations map[string]time.Time
+	turn         time.Duration
+}
+
+// loadFloorState reads the tables and their orders and reservations. A
+// reservation starting within a turn keeps its table from walk-ins until the
+// reservation ends.
+func loadFloorState(ctx context.Context, store *repository.Store) (floorState, error) {
+	state := floorState{now: time.Now(), openOrders: map[string]models.Order{}, reservations: map[string]time.Time{}}
+
+	tables, err := store.Tables.List(ctx)
+	if err != nil {
+		return state, err
+	}
+	orders, err := store.Orders.List(ctx)
+	if err != nil {
+		return state, err
+	}
+	invoices, err := store.Invoices.List(ctx)
+	if err != nil {
+		return state, err
+	}
+	state.tables = tables
+	state.turn = helpers.TurnTime(turnTimes(orders, invoices))
+
+	for _, order := range orders {
+		if order.Table_id == nil || !orderOpen(order) {
+			continue
+		}
+		if seated, ok := state.openOrders[*order.Table_id]; !ok || order.Created_at.After(seated.Created_at) {
+			state.openOrders[*order.Table_id] = order
+		}
+	}
+
+	// the tables of a group are taken by the party seated at the group
+	groups, err := store.TableGroups.List(ctx)
+	if err != nil {
+		return state, err
+	}
+	for _, group := range groups {
+		order, seated := state.openOrders[group.Primary_table_id]
+		if !seated {
+			continue
+		}
+		for _, tableId := range group.Table_ids {
+			state.openOrders[tableId] = order
+		}
+	}
+
+	holding, err := store.Reservations.Holding(ctx, state.now, state.now.Add(state.turn))
+	if err != nil {
+		return state, err
+	}
+	for _, reservation := range holding {
+		if reservation.Table_id != nil && reservation.Blocked_until.After(state.reservations[*reservation.Table_id]) {
+			state.reservations[*reservation.Table_id] = reservation.Blocked_until
+		}
+	}
+	return state, nil
+}
+
+// orderOpen tells whether the party of the order is still at the table.
+func orderOpen(order models.Order) bool {
+	status := order.CurrentStatus()
+	return status != models.OrderPaid && status != models.OrderCancelled
+}
+
+// turnTimes returns how long the most recently paid orders kept their tables,
+// from the order to the payment. The payment time is the move to PAID, or
+// the last update of the paid invoice for orders that were not moved.
+func turnTimes(orders []models.Order, invoices []models.Invoice) []time.Duration {
+	paidInvoices := map[string]time.Time{}
+	for _, invoice := range invoices {
+		if invoice.Payment_status != nil && *invoice.Payment_status == models.PaymentPaid {
+			paidInvoices[invoice.Order_id] = invoice.Updated_at
+		}
+	}
+
+	type turn struct {
+		paidAt time.Time
+		length time.Duration
+	}
+	var turns []turn
+	for _, order := range orders {
+		if order.Table_id == nil {
+			continue
+		}
+		paidAt, paid := paidInvoices[order.Order_id]
+		for _, change := range order.Status_history {
+			if change.To == models.OrderPaid {
+				paidAt, paid = change.Changed_at, true
+			}
+		}
+		if paid && paidAt.After(order.Created_at) {
+			turns = append(turns, turn{paidAt: paidAt, length: paidAt.Sub(order.Created_at)})
+		}
+	}
+
+	sort.Slice(turns, func(i, j int) bool { return turns[i].paidAt.After(turns[j].paidAt) })
+	var lengths []time.Duration
+	for i := 0; i < len(turns) && i < helpers.TurnTimeSamples(); i++ {
+		lengths = append(lengths, turns[i].length)
+	}
+	return lengths
+}
+
+// waitTables returns the tables as helpers.EstimateWaits sees them. A table
+// with a party is expected to free up a turn after they ordered.
+func (s floorState) waitTables() []helpers.WaitTable {
+	tables := make([]helpers.WaitTable, 0, len(s.tables))
+	for _, table := range s.tables {
+		waitTable := helpers.WaitTable{Min_party: table.MinParty(), Max_party: table.MaxParty()}
+		if order, ok := s.openOrders[table.Table_id]; ok {
+			waitTable.Free_at = order.Created_at.Add(s.turn)
+		}
+		if reservedUntil, ok := s.reservations[table.Table_id]; ok && reservedUntil.After(waitTable.Free_at) {
+			waitTable.Free_at = reservedUntil
+		}
+		tables = append(tables, waitTable)
+	}
+	return tables
+}
+
+// freeTable returns the smallest table seating the party that nobody sits at
+// and no reservation needs soon.
+func (s floorState) freeTable(partySize int) (models.Table, bool) {
+	var best models.Table
+	found := false
+	for _, table := range s.tables {
+		if !table.Seats(partySize) || !s.tableFree(table.Table_id) {
+			continue
+		}
+		if !found || table.MaxParty() < best.MaxParty() {
+			best, found = table, true
+		}
+	}
+	return best, found
+}
+
+func (s floorState) tableFree(tableId string) bool {
+	_, seated := s.openOrders[tableId]
+	_, reserved := s.reservations[tableId]
+	return !seated && !reserved
+}
+
+// estimateWaits returns the estimated wait in minutes of each party, nil for
+// a party no table seats.
+func (s floorState) estimateWaits(partySizes []int) []*int {
+	waits := helpers.EstimateWaits(s.now, s.waitTables(), partySizes, s.turn)
+	minutes := make([]*int, len(waits))
+	for i, wait := range waits {
+		if wait < 0 {
+			continue
+		}
+		m := int(math.Ceil(wait.Minutes()))
+		minutes[i] = &m
+	}
+	return minutes
+}
+
+// waitingParties estimates the waits of the parties in the queue, and of
+// partySize joining it when it is more than 0.
+func waitingParties(ctx context.Context, store *repository.Store, partySize int) ([]WaitingParty, *int, floorState, error) {
+	state, err := loadFloorState(ctx, store)
+	if err != nil {
+		return nil, nil, state, err
+	}
+	waiting, err := store.Waitlist.List(ctx, []string{models.WaitlistWaiting})
+	if err != nil {
+		return nil, nil, state, err
+	}
+
+	sizes := make([]int, 0, len(waiting)+1)
+	for _, entry := range waiting {
+		sizes = append(sizes, *entry.Party_size)
+	}
+	if partySize > 0 {
+		sizes = append(sizes, partySize)
+	}
+	waits := state.estimateWaits(sizes)
+
+	parties := make([]WaitingParty, 0, len(waiting))
+	for i, entry := range waiting {
+		parties = append(parties, WaitingParty{WaitlistEntry: entry, Position: i + 1, Estimated_wait: waits[i]})
+	}
+	var newWait *int
+	if partySize > 0 {
+		newWait = waits[len(waiting)]
+	}
+	return parties, newWait, state, nil
+}
+
+// GetWaitlist lists the parties waiting, in order, with their estimated
+// waits. ?status= takes a comma separated list of statuses to look back at
+// seated parties or those who left.
+func GetWaitlist(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		if status := c.Query("status"); status != "" && status != models.WaitlistWaiting {
+			entries, err := store.Waitlist.List(ctx, strings.Split(status, ","))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the waitlist"})
+				return
+			}
+			parties := make([]WaitingParty, 0, len(entries))
+			for _, entry := range entries {
+				parties = append(parties, WaitingParty{WaitlistEntry: entry})
+			}
+			c.JSON(http.StatusOK, parties)
+			return
+		}
+
+		parties, _, _, err := waitingParties(ctx, store, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing the waitlist"})
+			return
+		}
+		c.JSON(http.StatusOK, parties)
+	}
+}
+
+func GetWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		entry, err := store.Waitlist.FindByID(ctx, c.Param("waitlist_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "waitlist entry not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the waitlist entry"})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// GetWaitEstimate tells the wait a party of ?party_size= would be quoted now.
+func GetWaitEstimate(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		partySize, err := strconv.Atoi(c.Query("party_size"))
+		if err != nil || partySize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "party_size must be a number of at least 1"})
+			return
+		}
+
+		parties, wait, state, err := waitingParties(ctx, store, partySize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while estimating the wait"})
+			return
+		}
+		if wait == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no table seats a party of " + strconv.Itoa(partySize)})
+			return
+		}
+		c.JSON(http.StatusOK, WaitEstimate{
+			Party_size:     partySize,
+			Parties_ahead:  len(parties),
+			Estimated_wait: *wait,
+			Turn_time:      int(math.Ceil(state.turn.Minutes())),
+		})
+	}
+}
+
+// AddToWaitlist puts a walk-in party at the end of the queue and quotes them
+// a wait.
+func AddToWaitlist(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var entry models.WaitlistEntry
+		if err := c.BindJSON(&entry); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(entry); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		parties, wait, _, err := waitingParties(ctx, store, *entry.Party_size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while estimating the wait"})
+			return
+		}
+		if wait == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no table seats a party of " + strconv.Itoa(*entry.Party_size)})
+			return
+		}
+
+		entry.Status = models.WaitlistWaiting
+		entry.Quoted_wait = *wait
+		entry.Table_id = nil
+		entry.Order_id = nil
+		entry.Seated_at = nil
+		entry.Left_at = nil
+		entry.Created_by = c.GetString("uid")
+		entry.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		entry.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		entry.ID = primitive.NewObjectID()
+		entry.Waitlist_id = entry.ID.Hex()
+
+		if err := store.Waitlist.Insert(ctx, entry); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the party was not added to the waitlist"})
+			return
+		}
+		party := WaitingParty{WaitlistEntry: entry, Position: len(parties) + 1, Estimated_wait: wait}
+		Events.Publish(events.TopicWaitlist, events.WaitlistAdded, party)
+		c.JSON(http.StatusOK, party)
+	}
+}
+
+// SeatWaitlistEntry seats a waiting party, at the table_id in the body or at
+// the smallest free table seating them, and opens a DRAFT order for the
+// table the same way CreateOrder does, unless a party sits there already.
+func SeatWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		waitlistId := c.Param("waitlist_id")
+
+		var body struct {
+			Table_id *string `json:"table_id"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		entry, err := store.Waitlist.FindByID(ctx, waitlistId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "waitlist entry not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the waitlist entry"})
+			return
+		}
+		if entry.Status != models.WaitlistWaiting {
+			c.JSON(http.StatusConflict, gin.H{"error": "the party is already " + entry.Status})
+			return
+		}
+
+		state, err := loadFloorState(ctx, store)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the tables"})
+			return
+		}
+		var table models.Table
+		if body.Table_id != nil {
+			table, err = store.Tables.FindByID(ctx, *body.Table_id)
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching table details"})
+				return
+			}
+			if !table.Seats(*entry.Party_size) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "the table does not seat the party"})
+				return
+			}
+			if _, seated := state.openOrders[table.Table_id]; seated {
+				c.JSON(http.StatusConflict, gin.H{"error": "another party sits at the table"})
+				return
+			}
+		} else {
+			var free bool
+			table, free = state.freeTable(*entry.Party_size)
+			if !free {
+				c.JSON(http.StatusConflict, gin.H{"error": "no table seating the party is free yet"})
+				return
+			}
+		}
+
+		// claim the party first so two hosts cannot seat it twice
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = store.Waitlist.UpdateStatus(ctx, waitlistId, models.WaitlistWaiting, bson.D{
+			{Key: "status", Value: models.WaitlistSeated},
+			{Key: "table_id", Value: table.Table_id},
+			{Key: "seated_at", Value: now},
+			{Key: "updated_at", Value: now},
+		})
+		if err == repository.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": "the party was seated or left in the meantime"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the waitlist entry"})
+			return
+		}
+
+		// the table is checked again as the order is written, another host may
+		// have seated a party there since the floor was read
+		tableId := table.Table_id
+		order, status, msg := newOrder(ctx, store, models.Order{Order_date: now, Table_id: &tableId}, c.GetString("uid"))
+		if msg == "" {
+			err = store.Orders.Seat(ctx, order)
+			if err == repository.ErrConflict {
+				status, msg = http.StatusConflict, "another party sits at the table"
+			} else if err != nil {
+				status, msg = http.StatusInternalServerError, "order item was not created"
+			}
+		}
+		if msg != "" {
+			errBody := gin.H{"error": msg}
+			revert := bson.D{
+				{Key: "status", Value: models.WaitlistWaiting},
+				{Key: "table_id", Value: nil},
+				{Key: "seated_at", Value: nil},
+			}
+			if err := store.Waitlist.UpdateStatus(ctx, waitlistId, models.WaitlistSeated, revert); err != nil {
+				errBody["detail"] = "the party could not be put back on the waitlist"
+			}
+			c.JSON(status, errBody)
+			return
+		}
+		publishOrderCreated(order)
+
+		err = store.Waitlist.UpdateStatus(ctx, waitlistId, models.WaitlistSeated, bson.D{{Key: "order_id", Value: order.Order_id}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the order " + order.Order_id + " was created but not linked to the waitlist entry"})
+			return
+		}
+
+		entry, err = store.Waitlist.FindByID(ctx, waitlistId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the waitlist entry"})
+			return
+		}
+		Events.Publish(events.TopicWaitlist, events.WaitlistSeated, entry)
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// LeaveWaitlist takes a party that gave up waiting off the queue.
+func LeaveWaitlist(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		waitlistId := c.Param("waitlist_id")
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := store.Waitlist.UpdateStatus(ctx, waitlistId, models.WaitlistWaiting, bson.D{
+			{Key: "status", Value: models.WaitlistLeft},
+			{Key: "left_at", Value: now},
+			{Key: "updated_at", Value: now},
+		})
+		if err == repository.ErrConflict {
+			if _, findErr := store.Waitlist.FindByID(ctx, waitlistId); findErr == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "waitlist entry not found"})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "the party is no longer waiting"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while updating the waitlist entry"})
+			return
+		}
+
+		entry, err := store.Waitlist.FindByID(ctx, waitlistId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the waitlist entry"})
+			return
+		}
+		Events.Publish(events.TopicWaitlist, events.WaitlistLeft, entry)
+		c.JSON(http.StatusOK, entry)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWaitlistRouter(store *repository.Store) *gin.Engine {
+	router := setupOrderRouter(store, models.RoleServer)
+	router.GET("/waitlist", GetWaitlist(store))
+	router.GET("/waitlist/estimate", GetWaitEstimate(store))
+	router.POST("/waitlist", AddToWaitlist(store))
+	router.POST("/waitlist/:waitlist_id/seat", SeatWaitlistEntry(store))
+	router.POST("/waitlist/:waitlist_id/leave", LeaveWaitlist(store))
+	return router
+}
+
+func joinWaitlist(t *testing.T, router *gin.Engine, name string, partySize int) WaitingParty {
+	w := performRequest(router, "POST", "/waitlist", gin.H{"guest_name": name, "phone": "5550100", "party_size": partySize})
+	require.Equal(t, http.StatusOK, w.Code)
+	return decode[WaitingParty](t, w.Body.Bytes())
+}
+
+func TestWaitlistFlow(t *testing.T) {
+	store := repository.NewMemoryStore()
+	router := setupWaitlistRouter(store)
+
+	burger := createTestFood(t, router, "Burger", "Mains", 10)
+	small := createTestTable(t, router, 2, 1)
+	large := createTestTable(t, router, 4, 2)
+	// the table for two is taken, for a turn of an hour as nothing was paid yet
+	createTestOrder(t, router, small, burger)
+
+	w := performRequest(router, "GET", "/waitlist/estimate?party_size=2", nil)
+	estimate := decode[WaitEstimate](t, w.Body.Bytes())
+	assert.Equal(t, 0, estimate.Estimated_wait)
+	assert.Equal(t, 60, estimate.Turn_time)
+
+	first := joinWaitlist(t, router, "Ada", 2)
+	assert.Equal(t, 0, first.Quoted_wait)
+	second := joinWaitlist(t, router, "Bob", 2)
+	assert.Equal(t, 60, second.Quoted_wait, "the table for four goes to Ada")
+	assert.Equal(t, 2, second.Position)
+
+	w = performRequest(router, "POST", "/waitlist", gin.H{"guest_name": "Cy", "phone": "5550100", "party_size": 6})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(router, "POST", "/waitlist/"+second.Waitlist_id+"/seat", gin.H{"table_id": small})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// seating opens an order at the free table
+	w = performRequest(router, "POST", "/waitlist/"+first.Waitlist_id+"/seat", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	seated := decode[models.WaitlistEntry](t, w.Body.Bytes())
+	assert.Equal(t, models.WaitlistSeated, seated.Status)
+	assert.Equal(t, large, *seated.Table_id)
+	require.NotNil(t, seated.Order_id)
+	order, err := store.Orders.FindByID(context.Background(), *seated.Order_id)
+	require.NoError(t, err)
+	assert.Equal(t, large, *order.Table_id)
+	assert.Equal(t, models.OrderDraft, order.CurrentStatus())
+
+	w = performRequest(router, "POST", "/waitlist/"+first.Waitlist_id+"/seat", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(router, "POST", "/waitlist/"+second.Waitlist_id+"/seat", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "every table is taken")
+
+	w = performRequest(router, "GET", "/waitlist", nil)
+	waiting := decode[[]WaitingParty](t, w.Body.Bytes())
+	require.Len(t, waiting, 1)
+	assert.Equal(t, second.Waitlist_id, waiting[0].Waitlist_id)
+	assert.Equal(t, 1, waiting[0].Position)
+	assert.Equal(t, 60, *waiting[0].Estimated_wait)
+
+	w = performRequest(router, "POST", "/waitlist/"+second.Waitlist_id+"/leave", nil)
+	assert.Equal(t, models.WaitlistLeft, decode[models.WaitlistEntry](t, w.Body.Bytes()).Status)
+	w = performRequest(router, "POST", "/waitlist/"+second.Waitlist_id+"/leave", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(router, "POST", "/waitlist/missing/leave", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performRequest(router, "GET", "/waitlist?status=SEATED,LEFT", nil)
+	assert.Len(t, decode[[]WaitingParty](t, w.Body.Bytes()), 2)
+}
+
+func TestTurnTimes(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	tableId := "t1"
+	paidOrder := func(id string, minutes int) models.Order {
+		return models.Order{Order_id: id, Table_id: &tableId, Created_at: start, Status_history: []models.OrderStatusChange{
+			{To: models.OrderPlaced, Changed_at: start},
+			{From: models.OrderServed, To: models.OrderPaid, Changed_at: start.Add(time.Duration(minutes) * time.Minute)},
+		}}
+	}
+	paid := models.PaymentPaid
+	orders := []models.Order{
+		paidOrder("o1", 30),
+		paidOrder("o2", 50),
+		// paid, though the order was never moved to PAID
+		{Order_id: "o3", Table_id: &tableId, Created_at: start},
+		// still at the table
+		{Order_id: "o4", Table_id: &tableId, Created_at: start},
+	}
+	invoices := []models.Invoice{{Order_id: "o3", Payment_status: &paid, Updated_at: start.Add(40 * time.Minute)}}
+
+	turns := turnTimes(orders, invoices)
+	assert.Len(t, turns, 3)
+	assert.Equal(t, 40*time.Minute, helpers.TurnTime(turns))
+}
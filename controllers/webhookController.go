@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/rkmangalp/Restaurant_Management/webhooks"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetWebhooks lists the webhooks. Secrets are only shown when a webhook is
+// created or given a new secret.
+func GetWebhooks(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		allWebhooks, err := store.Webhooks.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing webhooks"})
+			return
+		}
+		for i := range allWebhooks {
+			allWebhooks[i].Secret = nil
+		}
+		c.JSON(http.StatusOK, allWebhooks)
+	}
+}
+
+func GetWebhook(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		webhook, err := store.Webhooks.FindByID(ctx, c.Param("webhook_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the webhook"})
+			return
+		}
+		webhook.Secret = nil
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+// CreateWebhook subscribes a url to event types. Without a secret one is
+// generated; either way it is in the response, for the receiver to check the
+// signatures with.
+func CreateWebhook(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		var webhook models.Webhook
+		if err := c.BindJSON(&webhook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(webhook); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if msg := eventTypesError(webhook.Event_types); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		if webhook.Secret == nil {
+			secret, err := newWebhookSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook secret was not generated"})
+				return
+			}
+			webhook.Secret = &secret
+		}
+		if webhook.Active == nil {
+			active := true
+			webhook.Active = &active
+		}
+		webhook.Created_by = c.GetString("uid")
+		webhook.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		webhook.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		webhook.ID = primitive.NewObjectID()
+		webhook.Webhook_id = webhook.ID.Hex()
+
+		if err := store.Webhooks.Insert(ctx, webhook); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook was not created"})
+			return
+		}
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+// UpdateWebhook changes the url, event types, secret or active flag. A
+// paused webhook gets no new deliveries.
+func UpdateWebhook(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+		webhookId := c.Param("webhook_id")
+
+		var webhook models.Webhook
+		if err := c.BindJSON(&webhook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var updateObj primitive.D
+		if webhook.Url != nil {
+			if err := validate.Var(*webhook.Url, "url"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "url must be a valid url"})
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "url", Value: webhook.Url})
+		}
+		if webhook.Event_types != nil {
+			msg := eventTypesError(webhook.Event_types)
+			if len(webhook.Event_types) == 0 {
+				msg = "a webhook needs at least one event type"
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "event_types", Value: webhook.Event_types})
+		}
+		if webhook.Secret != nil {
+			if len(*webhook.Secret) < 16 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "secret must be at least 16 characters"})
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "secret", Value: webhook.Secret})
+		}
+		if webhook.Active != nil {
+			updateObj = append(updateObj, bson.E{Key: "active", Value: webhook.Active})
+		}
+
+		webhook.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: webhook.Updated_at})
+
+		err := store.Webhooks.Update(ctx, webhookId, updateObj)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook update failed"})
+			return
+		}
+
+		updatedWebhook, err := store.Webhooks.FindByID(ctx, webhookId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the webhook"})
+			return
+		}
+		if webhook.Secret == nil {
+			updatedWebhook.Secret = nil
+		}
+		c.JSON(http.StatusOK, updatedWebhook)
+	}
+}
+
+// DeleteWebhook removes the webhook. Its pending deliveries fail on their next
+// attempt.
+func DeleteWebhook(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		err := store.Webhooks.Delete(ctx, c.Param("webhook_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook was not deleted"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+	}
+}
+
+// GetWebhookDeliveries lists deliveries, newest first. ?webhook_id= limits
+// them to one webhook and ?status=FAILED gives the dead letter list.
+func GetWebhookDeliveries(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		deliveries, err := store.Deliveries.List(ctx, c.Query("webhook_id"), c.Query("status"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while listing webhook deliveries"})
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func GetWebhookDelivery(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		delivery, err := store.Deliveries.FindByID(ctx, c.Param("delivery_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook delivery not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error while fetching the webhook delivery"})
+			return
+		}
+		c.JSON(http.StatusOK, delivery)
+	}
+}
+
+// RedeliverWebhookDelivery sends a failed or delivered delivery again, once
+// the receiver is fixed.
+func RedeliverWebhookDelivery(dispatcher *webhooks.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), QueryTimeout)
+		defer cancel()
+
+		delivery, err := dispatcher.Redeliver(ctx, c.Param("delivery_id"))
+		switch err {
+		case nil:
+			c.JSON(http.StatusOK, delivery)
+		case webhooks.ErrDeliveryNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case webhooks.ErrDeliveryPending:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook delivery was not queued"})
+		}
+	}
+}
+
+func eventTypesError(eventTypes []string) string {
+	for _, eventType := range eventTypes {
+		if !events.KnownType(eventType) {
+			return fmt.Sprintf("unknown event type %q", eventType)
+		}
+	}
+	return ""
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/rkmangalp/Restaurant_Management/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageWebhooks(t *testing.T) {
+	store := repository.NewMemoryStore()
+	dispatcher := webhooks.NewDispatcher(config.Default().Webhooks, store, events.NewBroker())
+	defer dispatcher.Close()
+
+	router := setupOrderRouter(store, models.RoleAdmin)
+	router.GET("/webhooks", GetWebhooks(store))
+	router.GET("/webhooks/:webhook_id", GetWebhook(store))
+	router.POST("/webhooks", CreateWebhook(store))
+	router.PATCH("/webhooks/:webhook_id", UpdateWebhook(store))
+	router.DELETE("/webhooks/:webhook_id", DeleteWebhook(store))
+	router.POST("/webhook-deliveries/:delivery_id/redeliver", RedeliverWebhookDelivery(dispatcher))
+
+	w := performRequest(router, "POST", "/webhooks", gin.H{"url": "https://example.com/hook", "event_types": []string{"order.eaten"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/webhooks", gin.H{"url": "not a url", "event_types": []string{events.OrderCreated}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(router, "POST", "/webhooks", gin.H{
+		"url":         "https://accounting.example.com/hook",
+		"event_types": []string{events.OrderCreated, events.InvoicePaid},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	created := decode[models.Webhook](t, w.Body.Bytes())
+	require.NotNil(t, created.Secret, "the generated secret is shown once")
+	assert.Len(t, *created.Secret, 48)
+	assert.True(t, *created.Active)
+
+	w = performRequest(router, "GET", "/webhooks/"+created.Webhook_id, nil)
+	assert.Nil(t, decode[models.Webhook](t, w.Body.Bytes()).Secret)
+	w = performRequest(router, "GET", "/webhooks", nil)
+	listed := decode[[]models.Webhook](t, w.Body.Bytes())
+	require.Len(t, listed, 1)
+	assert.Nil(t, listed[0].Secret)
+
+	w = performRequest(router, "PATCH", "/webhooks/"+created.Webhook_id, gin.H{"active": false, "event_types": []string{}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "PATCH", "/webhooks/"+created.Webhook_id, gin.H{"active": false})
+	updated := decode[models.Webhook](t, w.Body.Bytes())
+	assert.False(t, *updated.Active)
+	assert.False(t, updated.Subscribed(events.OrderCreated))
+
+	w = performRequest(router, "DELETE", "/webhooks/"+created.Webhook_id, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(router, "DELETE", "/webhooks/"+created.Webhook_id, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performRequest(router, "POST", "/webhook-deliveries/missing/redeliver", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -4,32 +4,41 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
+	"github.com/rkmangalp/Restaurant_Management/config"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func DBinstance() *mongo.Client {
-	MongoDb := "mongodb://localhost:27017"
-	fmt.Print(MongoDb)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// DBinstance connects to MongoDB with the pool sizes and timeouts from cfg and
+// pings the server, so a wrong URI is reported at startup instead of on the
+// first request.
+func DBinstance(cfg config.MongoConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(MongoDb))
+	opts := options.Client().
+		ApplyURI(cfg.URI).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ConnectTimeout)
 
+	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
 	}
-	fmt.Println("connected to MongoDB")
-	return client
-}
 
-var Client *mongo.Client = DBinstance()
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("pinging MongoDB: %w", err)
+	}
+	log.Println("connected to MongoDB")
+	return client, nil
+}
 
-func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	var collection *mongo.Collection = client.Database("restaurant").Collection(collectionName)
+func OpenCollection(client *mongo.Client, databaseName string, collectionName string) *mongo.Collection {
+	var collection *mongo.Collection = client.Database(databaseName).Collection(collectionName)
 
 	return collection
 }
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IllegalOperation is returned by a standalone server for any transaction
+const illegalOperationCode = 20
+
+// WithTransaction runs fn inside a multi-document transaction. fn must use the
+// context it is given so its writes join the transaction. The driver retries
+// fn on transient transaction errors, so fn must be safe to run again.
+func WithTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// IsTransactionNotSupported reports whether err means the server is a standalone
+// mongod (e.g. a local test deployment), which cannot run transactions.
+func IsTransactionNotSupported(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	return serverErr.HasErrorCodeWithMessage(illegalOperationCode, "Transaction numbers are only allowed")
+}
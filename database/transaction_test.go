@@ -0,0 +1,24 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsTransactionNotSupported(t *testing.T) {
+	standalone := mongo.CommandError{
+		Code:    20,
+		Name:    "IllegalOperation",
+		Message: "Transaction numbers are only allowed on a replica set member or mongos",
+	}
+
+	assert.True(t, IsTransactionNotSupported(standalone))
+	assert.True(t, IsTransactionNotSupported(fmt.Errorf("insert order: %w", standalone)))
+	assert.False(t, IsTransactionNotSupported(mongo.CommandError{Code: 20, Message: "something else"}))
+	assert.False(t, IsTransactionNotSupported(errors.New("connection refused")))
+	assert.False(t, IsTransactionNotSupported(nil))
+}
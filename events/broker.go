@@ -0,0 +1,130 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is something that happened. Data is sent to subscribers as JSON.
+type Event struct {
+	ID    uint64      `json:"id"`
+	Topic string      `json:"topic"`
+	Type  string      `json:"type"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+const (
+	// subscriberBuffer is how many events a subscriber can fall behind before
+	// it is dropped. Publishing never waits for subscribers.
+	subscriberBuffer = 64
+	// historySize is how many past events are kept for clients that resume
+	historySize = 1000
+)
+
+// Broker delivers published events to the subscribers of their topic, and
+// keeps the latest ones so that a client can catch up after reconnecting.
+// Event ids start again at 1 when the service restarts.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	subscribers map[*Subscription]bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[*Subscription]bool{}}
+}
+
+// Subscription receives events on C until it is closed. C is also closed when
+// the subscriber fell too far behind.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	topics map[string]bool
+	broker *Broker
+}
+
+// Subscribe listens to the topics, or to every topic when none are given.
+func (b *Broker) Subscribe(topics ...string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.subscribe(topics)
+}
+
+// SubscribeSince subscribes like Subscribe and also returns the kept events of
+// the topics that came after lastID, so nothing falls between the two.
+// complete is false when some of those events are no longer kept, or lastID
+// is from before a restart.
+func (b *Broker) SubscribeSince(lastID uint64, topics ...string) (s *Subscription, missed []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s = b.subscribe(topics)
+	if lastID > b.lastID {
+		return s, nil, false
+	}
+	complete = len(b.history) == 0 || b.history[0].ID <= lastID+1
+	for _, event := range b.history {
+		if event.ID > lastID && s.wants(event.Topic) {
+			missed = append(missed, event)
+		}
+	}
+	return s, missed, complete
+}
+
+// subscribe adds a subscription. b.mu must be held.
+func (b *Broker) subscribe(topics []string) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: ch, ch: ch, topics: map[string]bool{}, broker: b}
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+	b.subscribers[s] = true
+	return s
+}
+
+func (s *Subscription) wants(topic string) bool {
+	return len(s.topics) == 0 || s.topics[topic]
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
+
+// Publish sends an event to the subscribers of topic and returns it.
+func (b *Broker) Publish(topic string, eventType string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Topic: topic, Type: eventType, Time: time.Now().UTC(), Data: data}
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, event)
+
+	for s := range b.subscribers {
+		if !s.wants(topic) {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			b.drop(s)
+		}
+	}
+	return event
+}
+
+// drop removes the subscription. b.mu must be held.
+func (b *Broker) drop(s *Subscription) {
+	if b.subscribers[s] {
+		delete(b.subscribers, s)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeToTopics(t *testing.T) {
+	broker := NewBroker()
+	kitchen := broker.Subscribe(TopicKitchen)
+	defer kitchen.Close()
+	everything := broker.Subscribe()
+	defer everything.Close()
+
+	broker.Publish(TopicOrders, OrderCreated, nil)
+	created := broker.Publish(TopicKitchen, TicketCreated, "ticket1")
+
+	assert.Equal(t, created, <-kitchen.C)
+	assert.Empty(t, kitchen.C)
+	assert.Equal(t, OrderCreated, (<-everything.C).Type)
+	assert.Equal(t, created.ID, (<-everything.C).ID)
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	broker := NewBroker()
+	slow := broker.Subscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		broker.Publish(TopicKitchen, TicketUpdated, i)
+	}
+	received := 0
+	for range slow.C {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+	slow.Close()
+}
+
+func TestSubscribeSince(t *testing.T) {
+	broker := NewBroker()
+	first := broker.Publish(TopicOrders, OrderCreated, nil)
+	broker.Publish(TopicTables, TableSeated, nil)
+	third := broker.Publish(TopicOrders, OrderItemAdded, nil)
+
+	sub, missed, complete := broker.SubscribeSince(first.ID, TopicOrders)
+	defer sub.Close()
+	assert.True(t, complete)
+	assert.Equal(t, []Event{third}, missed)
+
+	// after a restart the ids start again
+	restarted, missed, complete := broker.SubscribeSince(third.ID + 10)
+	defer restarted.Close()
+	assert.False(t, complete)
+	assert.Empty(t, missed)
+
+	for i := 0; i < historySize; i++ {
+		broker.Publish(TopicKitchen, TicketUpdated, i)
+	}
+	late, missed, complete := broker.SubscribeSince(first.ID)
+	defer late.Close()
+	assert.False(t, complete)
+	assert.Len(t, missed, historySize)
+}
@@ -0,0 +1,115 @@
+package events
+
+// topics
+const (
+	TopicOrders       = "orders"
+	TopicTables       = "tables"
+	TopicInvoices     = "invoices"
+	TopicKitchen      = "kitchen"
+	TopicReservations = "reservations"
+	TopicWaitlist     = "waitlist"
+)
+
+// Topics are the topics events are published on.
+var Topics = []string{TopicOrders, TopicTables, TopicInvoices, TopicKitchen, TopicReservations, TopicWaitlist}
+
+// order events
+const (
+	OrderCreated       = "order.created"
+	OrderUpdated       = "order.updated"
+	OrderItemAdded     = "order.item_added"
+	OrderItemUpdated   = "order.item_updated"
+	OrderStatusChanged = "order.status_changed"
+	// OrderTransferred is a party moving to another table with its order
+	OrderTransferred = "order.transferred"
+	// OrderItemsTransferred is items moving to the order of another table
+	OrderItemsTransferred = "order.items_transferred"
+	// guest orders wait for the staff to confirm them before they become
+	// orders
+	GuestOrderSubmitted = "guest_order.submitted"
+	GuestOrderConfirmed = "guest_order.confirmed"
+	GuestOrderRejected  = "guest_order.rejected"
+)
+
+// table events, a table is seated when an order is taken for it and its
+// status changes with the orders and invoices of the party
+const (
+	TableCreated       = "table.created"
+	TableUpdated       = "table.updated"
+	TableSeated        = "table.seated"
+	TableStatusChanged = "table.status_changed"
+	TablesMerged       = "table.merged"
+	TablesSplit        = "table.split"
+)
+
+// invoice events
+const (
+	InvoiceCreated         = "invoice.created"
+	InvoiceUpdated         = "invoice.updated"
+	InvoicePaymentRecorded = "invoice.payment_recorded"
+	InvoicePaid            = "invoice.paid"
+	InvoiceRefunded        = "invoice.refunded"
+)
+
+// kitchen events
+const (
+	TicketCreated = "ticket.created"
+	TicketUpdated = "ticket.updated"
+)
+
+// reservation events
+const (
+	ReservationCreated   = "reservation.created"
+	ReservationUpdated   = "reservation.updated"
+	ReservationCancelled = "reservation.cancelled"
+	ReservationSeated    = "reservation.seated"
+	ReservationNoShow    = "reservation.no_show"
+)
+
+// waitlist events
+const (
+	WaitlistAdded  = "waitlist.added"
+	WaitlistSeated = "waitlist.seated"
+	WaitlistLeft   = "waitlist.left"
+)
+
+// stream events are only sent on a stream and have no id
+const (
+	// StreamReset tells a resuming client that events were missed and it has
+	// to load the current state again
+	StreamReset = "stream.reset"
+	// TicketSnapshot starts a kitchen stream with the tickets on the screen
+	TicketSnapshot = "ticket.snapshot"
+)
+
+// Types are the event types published on the topics, the ones webhooks can
+// subscribe to.
+var Types = []string{
+	OrderCreated, OrderUpdated, OrderItemAdded, OrderItemUpdated, OrderStatusChanged, OrderTransferred, OrderItemsTransferred,
+	GuestOrderSubmitted, GuestOrderConfirmed, GuestOrderRejected,
+	TableCreated, TableUpdated, TableSeated, TableStatusChanged, TablesMerged, TablesSplit,
+	InvoiceCreated, InvoiceUpdated, InvoicePaymentRecorded, InvoicePaid, InvoiceRefunded,
+	TicketCreated, TicketUpdated,
+	ReservationCreated, ReservationUpdated, ReservationCancelled, ReservationSeated, ReservationNoShow,
+	WaitlistAdded, WaitlistSeated, WaitlistLeft,
+}
+
+// KnownType tells whether events of eventType are published.
+func KnownType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// KnownTopic tells whether events are published on topic.
+func KnownTopic(topic string) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package floor
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// storeTimeout limits the database work around one event
+const storeTimeout = 10 * time.Second
+
+// TableState is what a table status is worked out from: the orders seated at
+// the table and their invoices.
+type TableState struct {
+	Status   string
+	Order_id *string
+}
+
+// Status works out the status of a table from the orders seated at it. An
+// order is over once it is paid or cancelled, or its invoice is paid. Of the
+// orders still open the one furthest along decides: an unpaid invoice means
+// AWAITING_PAYMENT, a placed order ORDERED and a draft SEATED.
+func Status(orders []models.Order, invoices map[string][]models.Invoice) TableState {
+	rank := map[string]int{models.TableFree: 0, models.TableSeated: 1, models.TableOrdered: 2, models.TableAwaitingPayment: 3}
+	state := TableState{Status: models.TableFree}
+
+	for _, order := range orders {
+		status := order.CurrentStatus()
+		if status == models.OrderPaid || status == models.OrderCancelled {
+			continue
+		}
+		tableStatus := models.TableOrdered
+		if status == models.OrderDraft {
+			tableStatus = models.TableSeated
+		}
+		paid := false
+		for _, invoice := range invoices[order.Order_id] {
+			if invoice.Payment_status != nil && *invoice.Payment_status == models.PaymentPaid {
+				paid = true
+			} else {
+				tableStatus = models.TableAwaitingPayment
+			}
+		}
+		if paid {
+			continue
+		}
+		if rank[tableStatus] > rank[state.Status] {
+			orderId := order.Order_id
+			state = TableState{Status: tableStatus, Order_id: &orderId}
+		}
+	}
+	return state
+}
+
+// Tracker keeps the status of the tables in the store up to date with the
+// order and invoice events, and publishes a table.status_changed event when
+// one changes.
+type Tracker struct {
+	store  *repository.Store
+	broker *events.Broker
+
+	// orderTables remembers the table of each order, so the table an order
+	// left is updated too
+	orderTables map[string]string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTracker brings the statuses of all tables up to date and keeps them so
+// until Close is called.
+func NewTracker(store *repository.Store, broker *events.Broker) *Tracker {
+	t := &Tracker{
+		store:       store,
+		broker:      broker,
+		orderTables: map[string]string{},
+		done:        make(chan struct{}),
+	}
+	// subscribe before returning so no event published afterwards is missed
+	sub := broker.Subscribe(events.TopicOrders, events.TopicInvoices, events.TopicTables)
+	t.wg.Add(1)
+	go t.run(sub)
+	return t
+}
+
+// Close stops the tracker.
+func (t *Tracker) Close() {
+	close(t.done)
+	t.wg.Wait()
+}
+
+func (t *Tracker) run(sub *events.Subscription) {
+	defer t.wg.Done()
+	t.syncAll()
+	for {
+		select {
+		case <-t.done:
+			sub.Close()
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// dropped for falling behind, whatever was missed is caught up
+				// by looking at every table again
+				sub = t.broker.Subscribe(events.TopicOrders, events.TopicInvoices, events.TopicTables)
+				t.syncAll()
+				continue
+			}
+			t.handle(event)
+		}
+	}
+}
+
+// eventRefs are the ids an event refers to, whatever its data type.
+type eventRefs struct {
+	Order_id      string   `json:"order_id"`
+	Table_id      *string  `json:"table_id"`
+	From_table_id *string  `json:"from_table_id"`
+	Table_ids     []string `json:"table_ids"`
+}
+
+func (t *Tracker) handle(event events.Event) {
+	if event.Type == events.TableStatusChanged || event.Type == events.TableSeated {
+		return
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return
+	}
+	var refs eventRefs
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	tables := map[string]bool{}
+	for _, tableId := range refs.Table_ids {
+		tables[tableId] = true
+	}
+	if refs.Table_id != nil {
+		tables[*refs.Table_id] = true
+	}
+	if refs.From_table_id != nil {
+		tables[*refs.From_table_id] = true
+	}
+	if refs.Order_id != "" {
+		if previous, ok := t.orderTables[refs.Order_id]; ok {
+			tables[previous] = true
+		}
+		order, err := t.store.Orders.FindByID(ctx, refs.Order_id)
+		if err == nil && order.Table_id != nil {
+			tables[*order.Table_id] = true
+			t.orderTables[refs.Order_id] = *order.Table_id
+		}
+	}
+	for tableId := range tables {
+		if err := t.refresh(ctx, tableId); err != nil && err != repository.ErrNotFound {
+			log.Printf("table status of %s: %v", tableId, err)
+		}
+	}
+}
+
+func (t *Tracker) syncAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	tables, err := t.store.Tables.List(ctx)
+	if err != nil {
+		log.Printf("table statuses: %v", err)
+		return
+	}
+	for _, table := range tables {
+		if err := t.refresh(ctx, table.Table_id); err != nil {
+			log.Printf("table status of %s: %v", table.Table_id, err)
+		}
+	}
+}
+
+// refresh works out the status of the table again and saves it when it
+// changed. The tables of a group all take the status of the group's orders.
+func (t *Tracker) refresh(ctx context.Context, tableId string) error {
+	table, err := t.store.Tables.FindByID(ctx, tableId)
+	if err != nil {
+		return err
+	}
+	tables, err := t.groupTables(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	var orders []models.Order
+	invoices := map[string][]models.Invoice{}
+	for _, table := range tables {
+		tableOrders, err := t.store.Orders.FindByTable(ctx, table.Table_id)
+		if err != nil {
+			return err
+		}
+		for _, order := range tableOrders {
+			t.orderTables[order.Order_id] = table.Table_id
+			status := order.CurrentStatus()
+			if status == models.OrderPaid || status == models.OrderCancelled {
+				continue
+			}
+			if invoices[order.Order_id], err = t.store.Invoices.FindByOrder(ctx, order.Order_id); err != nil {
+				return err
+			}
+		}
+		orders = append(orders, tableOrders...)
+	}
+
+	state := Status(orders, invoices)
+	for _, table := range tables {
+		if err := t.save(ctx, table, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupTables returns the tables merged with table, or only the table when it
+// is on its own.
+func (t *Tracker) groupTables(ctx context.Context, table models.Table) ([]models.Table, error) {
+	if table.Group_id == nil {
+		return []models.Table{table}, nil
+	}
+	group, err := t.store.TableGroups.FindByID(ctx, *table.Group_id)
+	if err == repository.ErrNotFound {
+		return []models.Table{table}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []models.Table
+	for _, tableId := range group.Table_ids {
+		if tableId == table.Table_id {
+			tables = append(tables, table)
+			continue
+		}
+		grouped, err := t.store.Tables.FindByID(ctx, tableId)
+		if err == repository.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, grouped)
+	}
+	return tables, nil
+}
+
+// save sets the status of the table to state, and publishes the change.
+func (t *Tracker) save(ctx context.Context, table models.Table, state TableState) error {
+	from := table.CurrentStatus()
+	if state.Status == from && sameOrder(state.Order_id, table.Order_id) {
+		return nil
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	fields := bson.D{{Key: "status", Value: state.Status}, {Key: "order_id", Value: state.Order_id}}
+	if state.Status != from {
+		fields = append(fields, bson.E{Key: "status_changed_at", Value: now})
+	}
+	if err := t.store.Tables.Update(ctx, table.Table_id, fields); err != nil {
+		return err
+	}
+	if state.Status != from {
+		t.broker.Publish(events.TopicTables, events.TableStatusChanged, map[string]interface{}{
+			"table_id":     table.Table_id,
+			"table_number": table.Table_number,
+			"from":         from,
+			"to":           state.Status,
+			"order_id":     state.Order_id,
+			"changed_at":   now,
+		})
+	}
+	return nil
+}
+
+func sameOrder(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
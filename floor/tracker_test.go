@@ -0,0 +1,97 @@
+package floor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestStatus(t *testing.T) {
+	order := func(id string, status string) models.Order {
+		return models.Order{Order_id: id, Status: strPtr(status)}
+	}
+	invoice := func(status string) []models.Invoice {
+		return []models.Invoice{{Payment_status: strPtr(status)}}
+	}
+
+	assert.Equal(t, models.TableFree, Status(nil, nil).Status)
+	assert.Equal(t, models.TableFree, Status([]models.Order{order("o1", models.OrderPaid), order("o2", models.OrderCancelled)}, nil).Status)
+	assert.Equal(t, models.TableSeated, Status([]models.Order{order("o1", models.OrderDraft)}, nil).Status)
+
+	state := Status([]models.Order{order("o1", models.OrderDraft), order("o2", models.OrderInKitchen)}, nil)
+	assert.Equal(t, models.TableOrdered, state.Status)
+	assert.Equal(t, "o2", *state.Order_id)
+
+	unpaid := map[string][]models.Invoice{"o1": invoice(models.PaymentPartiallyPaid)}
+	assert.Equal(t, models.TableAwaitingPayment, Status([]models.Order{order("o1", models.OrderServed)}, unpaid).Status)
+
+	// guests who paid have left, even when the order was not moved on
+	paid := map[string][]models.Invoice{"o1": invoice(models.PaymentPaid)}
+	assert.Equal(t, models.TableFree, Status([]models.Order{order("o1", models.OrderReady)}, paid).Status)
+}
+
+func TestTrackerFollowsOrdersAndInvoices(t *testing.T) {
+	store := repository.NewMemoryStore()
+	broker := events.NewBroker()
+	ctx := context.Background()
+
+	table := func(id string) {
+		seats := 4
+		require.NoError(t, store.Tables.Insert(ctx, models.Table{ID: primitive.NewObjectID(), Table_id: id, Number_of_guests: &seats, Table_number: &seats}))
+	}
+	table("t1")
+	table("t2")
+	statusOf := func(id string) string {
+		found, err := store.Tables.FindByID(ctx, id)
+		require.NoError(t, err)
+		return found.CurrentStatus()
+	}
+
+	// a table left seated while the service was down is caught up at start
+	require.NoError(t, store.Orders.Insert(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "o1", Table_id: strPtr("t1"), Status: strPtr(models.OrderDraft)}))
+	tracker := NewTracker(store, broker)
+	defer tracker.Close()
+	assert.Eventually(t, func() bool { return statusOf("t1") == models.TableSeated }, time.Second, 5*time.Millisecond)
+
+	changes := broker.Subscribe(events.TopicTables)
+	defer changes.Close()
+
+	require.NoError(t, store.Orders.Update(ctx, "o1", bson.D{{Key: "status", Value: models.OrderPlaced}}))
+	broker.Publish(events.TopicOrders, events.OrderStatusChanged, map[string]interface{}{"order_id": "o1", "table_id": "t1"})
+	assert.Eventually(t, func() bool { return statusOf("t1") == models.TableOrdered }, time.Second, 5*time.Millisecond)
+
+	select {
+	case event := <-changes.C:
+		assert.Equal(t, events.TableStatusChanged, event.Type)
+		data := event.Data.(map[string]interface{})
+		assert.Equal(t, models.TableSeated, data["from"])
+		assert.Equal(t, models.TableOrdered, data["to"])
+	case <-time.After(time.Second):
+		t.Fatal("no table.status_changed event")
+	}
+
+	// the party moves to the other table
+	require.NoError(t, store.Orders.Update(ctx, "o1", bson.D{{Key: "table_id", Value: "t2"}}))
+	order, _ := store.Orders.FindByID(ctx, "o1")
+	broker.Publish(events.TopicOrders, events.OrderUpdated, order)
+	assert.Eventually(t, func() bool { return statusOf("t1") == models.TableFree && statusOf("t2") == models.TableOrdered }, time.Second, 5*time.Millisecond)
+
+	invoice := models.Invoice{ID: primitive.NewObjectID(), Invoice_id: "i1", Order_id: "o1", Payment_status: strPtr(models.PaymentPending)}
+	require.NoError(t, store.Invoices.Insert(ctx, invoice))
+	broker.Publish(events.TopicInvoices, events.InvoiceCreated, invoice)
+	assert.Eventually(t, func() bool { return statusOf("t2") == models.TableAwaitingPayment }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, store.Invoices.Update(ctx, "i1", bson.D{{Key: "payment_status", Value: models.PaymentPaid}}))
+	broker.Publish(events.TopicInvoices, events.InvoicePaid, map[string]interface{}{"invoice_id": "i1", "order_id": "o1"})
+	assert.Eventually(t, func() bool { return statusOf("t2") == models.TableFree }, time.Second, 5*time.Millisecond)
+}
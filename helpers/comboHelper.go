@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PrepareComboSlots gives the slots of a combo an id where they have none. It
+// returns a message when a slot offers no food.
+func PrepareComboSlots(slots []models.ComboSlot) ([]models.ComboSlot, string) {
+	slotIds := map[string]bool{}
+	for i := range slots {
+		slot := &slots[i]
+		if slot.Slot_id == "" {
+			slot.Slot_id = primitive.NewObjectID().Hex()
+		}
+		if slotIds[slot.Slot_id] {
+			return nil, fmt.Sprintf("combo slot %s is listed twice", slot.Slot_id)
+		}
+		slotIds[slot.Slot_id] = true
+
+		if len(slot.Food_ids) == 0 && (slot.Category == nil || *slot.Category == "") {
+			return nil, fmt.Sprintf("combo slot %q needs foods or a category", slot.Name)
+		}
+	}
+	return slots, ""
+}
+
+// FixedFood returns the food of a slot that offers only one.
+func FixedFood(slot models.ComboSlot) (string, bool) {
+	if len(slot.Food_ids) == 1 && (slot.Category == nil || *slot.Category == "") {
+		return slot.Food_ids[0], true
+	}
+	return "", false
+}
+
+// SlotOffers reports whether food may fill the slot. Categories match in
+// any case, as they do for taxes and kitchen stations.
+func SlotOffers(slot models.ComboSlot, food models.Food) bool {
+	if slot.Category != nil && *slot.Category != "" && strings.EqualFold(*slot.Category, food.Category) {
+		return true
+	}
+	return slices.Contains(slot.Food_ids, food.Food_id)
+}
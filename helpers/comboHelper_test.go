@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComboSlots(t *testing.T) {
+	drinks := "Drinks"
+
+	slots, msg := PrepareComboSlots([]models.ComboSlot{
+		{Name: "Main", Food_ids: []string{"burger"}},
+		{Name: "Drink", Category: &drinks},
+	})
+	assert.Empty(t, msg)
+	assert.NotEmpty(t, slots[0].Slot_id)
+	_, msg = PrepareComboSlots([]models.ComboSlot{{Name: "Side"}})
+	assert.Equal(t, `combo slot "Side" needs foods or a category`, msg)
+	_, msg = PrepareComboSlots([]models.ComboSlot{{Slot_id: "a", Name: "Main", Food_ids: []string{"burger"}}, {Slot_id: "a", Name: "Side", Food_ids: []string{"fries"}}})
+	assert.NotEmpty(t, msg)
+
+	food, fixed := FixedFood(slots[0])
+	assert.True(t, fixed)
+	assert.Equal(t, "burger", food)
+	_, fixed = FixedFood(slots[1])
+	assert.False(t, fixed)
+
+	assert.True(t, SlotOffers(slots[1], models.Food{Food_id: "cola", Category: "Drinks"}))
+	assert.False(t, SlotOffers(slots[1], models.Food{Food_id: "fries", Category: "Sides"}))
+	assert.True(t, SlotOffers(slots[0], models.Food{Food_id: "burger", Category: "Mains"}))
+}
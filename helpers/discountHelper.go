@@ -0,0 +1,150 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+)
+
+// DiscountableLine is one priced line of an order. Amount is the price of
+// all Quantity items of the line; a Quantity of 0 counts as one item.
+type DiscountableLine struct {
+	Category string
+	Amount   float64
+	Quantity int
+}
+
+// DiscountLine is a discount as shown on the bill.
+type DiscountLine struct {
+	Coupon_code string  `json:"coupon_code"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// CalculateDiscount returns what the coupon takes off each line, in the order of
+// lines, and the discount line for the bill. The validity window and usage limit
+// are checked when the coupon is applied; the minimum spend is checked again
+// here because items may have been changed since.
+func CalculateDiscount(coupon models.Coupon, lines []DiscountableLine) ([]float64, DiscountLine) {
+	perLine := make([]float64, len(lines))
+	discount := DiscountLine{Description: DescribeCoupon(coupon)}
+	if coupon.Code != nil {
+		discount.Coupon_code = *coupon.Code
+	}
+
+	var total float64
+	var eligible []int
+	for i, line := range lines {
+		total += line.Amount
+		if coupon.Category == nil || strings.EqualFold(*coupon.Category, line.Category) {
+			eligible = append(eligible, i)
+		}
+	}
+	if coupon.Min_spend != nil && roundCents(total) < *coupon.Min_spend {
+		return perLine, discount
+	}
+
+	var value float64
+	if coupon.Value != nil {
+		value = *coupon.Value
+	}
+
+	switch derefString(coupon.Type) {
+	case models.CouponPercentage:
+		for _, i := range eligible {
+			perLine[i] = roundCents(lines[i].Amount * value / 100)
+		}
+
+	case models.CouponFixed:
+		var eligibleTotal float64
+		weights := make([]float64, len(eligible))
+		for n, i := range eligible {
+			weights[n] = lines[i].Amount
+			eligibleTotal += lines[i].Amount
+		}
+		// spread over the lines by amount
+		for n, share := range AllocateTotal(min(value, eligibleTotal), weights) {
+			perLine[eligible[n]] = share
+		}
+
+	case models.CouponBuyXGetY:
+		if coupon.Buy_quantity == nil || coupon.Get_quantity == nil {
+			break
+		}
+		percent := 100.0
+		if coupon.Value != nil {
+			percent = value
+		}
+		// the items are counted one by one, so three burgers on one line
+		// count as three
+		type unit struct {
+			line  int
+			price float64
+		}
+		var byPrice []unit
+		for _, i := range eligible {
+			quantity := max(lines[i].Quantity, 1)
+			for n := 0; n < quantity; n++ {
+				byPrice = append(byPrice, unit{line: i, price: lines[i].Amount / float64(quantity)})
+			}
+		}
+		sort.SliceStable(byPrice, func(a, b int) bool {
+			return byPrice[a].price > byPrice[b].price
+		})
+		group := *coupon.Buy_quantity + *coupon.Get_quantity
+		for n := group; n <= len(byPrice); n += group {
+			for _, u := range byPrice[n-*coupon.Get_quantity : n] {
+				perLine[u.line] += u.price * percent / 100
+			}
+		}
+		for i := range perLine {
+			perLine[i] = roundCents(perLine[i])
+		}
+	}
+
+	for _, amount := range perLine {
+		discount.Amount += amount
+	}
+	discount.Amount = roundCents(discount.Amount)
+	return perLine, discount
+}
+
+// DescribeCoupon gives a short text for the bill like "10% off Drinks".
+func DescribeCoupon(coupon models.Coupon) string {
+	var value float64
+	if coupon.Value != nil {
+		value = *coupon.Value
+	}
+
+	var description string
+	switch derefString(coupon.Type) {
+	case models.CouponPercentage:
+		description = fmt.Sprintf("%g%% off", value)
+	case models.CouponFixed:
+		description = fmt.Sprintf("%.2f off", value)
+	case models.CouponBuyXGetY:
+		free := "free"
+		if coupon.Value != nil && value < 100 {
+			free = fmt.Sprintf("%g%% off", value)
+		}
+		var buy, get int
+		if coupon.Buy_quantity != nil && coupon.Get_quantity != nil {
+			buy, get = *coupon.Buy_quantity, *coupon.Get_quantity
+		}
+		description = fmt.Sprintf("buy %d get %d %s", buy, get, free)
+	}
+
+	if coupon.Category != nil {
+		description += " " + *coupon.Category
+	}
+	return description
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateDiscount(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	integer := func(i int) *int { return &i }
+	text := func(s string) *string { return &s }
+
+	lines := []DiscountableLine{
+		{Category: "Mains", Amount: 12},
+		{Category: "Drinks", Amount: 3},
+		{Category: "drinks", Amount: 5},
+		{Category: "Drinks", Amount: 4},
+	}
+
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		perLine []float64
+		total   float64
+	}{
+		{
+			name:    "percentage",
+			coupon:  models.Coupon{Type: text(models.CouponPercentage), Value: float(10)},
+			perLine: []float64{1.2, 0.3, 0.5, 0.4},
+			total:   2.4,
+		},
+		{
+			name:    "percentage of a category",
+			coupon:  models.Coupon{Type: text(models.CouponPercentage), Value: float(50), Category: text("DRINKS")},
+			perLine: []float64{0, 1.5, 2.5, 2},
+			total:   6,
+		},
+		{
+			name:    "fixed is spread by amount",
+			coupon:  models.Coupon{Type: text(models.CouponFixed), Value: float(6)},
+			perLine: []float64{3, 0.75, 1.25, 1},
+			total:   6,
+		},
+		{
+			name:    "fixed is capped at the eligible total",
+			coupon:  models.Coupon{Type: text(models.CouponFixed), Value: float(20), Category: text("drinks")},
+			perLine: []float64{0, 3, 5, 4},
+			total:   12,
+		},
+		{
+			name:    "buy 2 get 1 makes the cheapest free",
+			coupon:  models.Coupon{Type: text(models.CouponBuyXGetY), Buy_quantity: integer(2), Get_quantity: integer(1), Category: text("drinks")},
+			perLine: []float64{0, 3, 0, 0},
+			total:   3,
+		},
+		{
+			name:    "buy 1 get 1 half price",
+			coupon:  models.Coupon{Type: text(models.CouponBuyXGetY), Value: float(50), Buy_quantity: integer(1), Get_quantity: integer(1)},
+			perLine: []float64{0, 1.5, 2.5, 0},
+			total:   4,
+		},
+		{
+			name:    "below the minimum spend",
+			coupon:  models.Coupon{Type: text(models.CouponFixed), Value: float(5), Min_spend: float(25)},
+			perLine: []float64{0, 0, 0, 0},
+			total:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.coupon.Code = text("SAVE")
+			perLine, discount := CalculateDiscount(test.coupon, lines)
+			assert.Equal(t, test.perLine, perLine)
+			assert.Equal(t, test.total, discount.Amount)
+			assert.Equal(t, "SAVE", discount.Coupon_code)
+		})
+	}
+}
+
+func TestBuyXGetYCountsItems(t *testing.T) {
+	buy, get := 2, 1
+	coupon := models.Coupon{Type: func(s string) *string { return &s }(models.CouponBuyXGetY), Buy_quantity: &buy, Get_quantity: &get}
+
+	// three burgers on one line and a soda: the third burger is free, the soda
+	// is the fourth item and pays
+	perLine, discount := CalculateDiscount(coupon, []DiscountableLine{
+		{Category: "Mains", Amount: 30, Quantity: 3},
+		{Category: "Drinks", Amount: 2, Quantity: 1},
+	})
+	assert.Equal(t, []float64{10, 0}, perLine)
+	assert.Equal(t, 10.0, discount.Amount)
+}
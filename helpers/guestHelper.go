@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GuestToken is the token type of guest sessions. Guest tokens only open the
+// guest routes, and staff tokens do not open them.
+const GuestToken = "guest"
+
+var guestConfig = config.Default().Guest
+
+// ConfigureGuest sets where the table codes link to and how long guest
+// sessions last.
+func ConfigureGuest(cfg config.GuestConfig) {
+	guestConfig = cfg
+}
+
+// GuestClaims are the claims of a guest token: the table it orders for. The
+// token id is the guest session, which has its own cart.
+type GuestClaims struct {
+	Table_id   string
+	Token_type string
+	jwt.StandardClaims
+}
+
+// TableSignature signs the table id of an ordering link with the JWT secret,
+// so guests cannot order for a table they are not sitting at.
+func TableSignature(tableId string) string {
+	mac := hmac.New(sha256.New, []byte(SECRET_KEY))
+	mac.Write([]byte("guest-table:" + tableId))
+	// half the MAC keeps the link, and so the code, small
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ValidTableSignature tells whether signature is the signature of the table.
+func ValidTableSignature(tableId string, signature string) bool {
+	return hmac.Equal([]byte(TableSignature(tableId)), []byte(signature))
+}
+
+// GuestOrderURL is the ordering link of the table, the one its code holds.
+func GuestOrderURL(tableId string) (string, error) {
+	u, err := url.Parse(guestConfig.OrderURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("table", tableId)
+	query.Set("sig", TableSignature(tableId))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// GenerateGuestToken starts a guest session at the table and returns its
+// token, id and expiry.
+func GenerateGuestToken(tableId string) (signedToken string, sessionId string, expiresAt time.Time, err error) {
+	now := time.Now().Local()
+	expiresAt = now.Add(guestConfig.SessionTTL)
+	sessionId = primitive.NewObjectID().Hex()
+
+	claims := &GuestClaims{
+		Table_id:   tableId,
+		Token_type: GuestToken,
+		StandardClaims: jwt.StandardClaims{
+			Id:        sessionId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	signedToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	return
+}
+
+// ValidateGuestToken checks the signature, type and expiry of a guest token.
+func ValidateGuestToken(signedToken string) (claims *GuestClaims, msg string) {
+	token, err := jwt.ParseWithClaims(
+		signedToken,
+		&GuestClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return []byte(SECRET_KEY), nil
+		},
+	)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	claims, ok := token.Claims.(*GuestClaims)
+	if !ok || !token.Valid {
+		return nil, "the token is invalid"
+	}
+	if claims.Token_type != GuestToken {
+		return nil, fmt.Sprintf("expected a %s token", GuestToken)
+	}
+	if claims.ExpiresAt < time.Now().Local().Unix() {
+		return nil, "token is expired"
+	}
+	return claims, ""
+}
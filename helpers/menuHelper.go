@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/models"
+)
+
+var (
+	menuLocation = time.Local
+	menuHolidays = map[string]bool{}
+)
+
+// ConfigureMenus sets the timezone and holidays menu schedules are read with.
+// The config is validated, an unknown timezone falls back to local time.
+func ConfigureMenus(cfg config.MenuConfig) {
+	menuLocation = time.Local
+	if location, err := time.LoadLocation(cfg.Timezone); err == nil {
+		menuLocation = location
+	}
+	menuHolidays = map[string]bool{}
+	for _, holiday := range cfg.Holidays {
+		menuHolidays[holiday] = true
+	}
+}
+
+// MenuActiveAt reports whether the menu is served at t: within its start and
+// end dates and inside one of the windows of its schedule.
+func MenuActiveAt(menu models.Menu, t time.Time) bool {
+	if menu.Start_date != nil && t.Before(*menu.Start_date) {
+		return false
+	}
+	if menu.End_date != nil && t.After(*menu.End_date) {
+		return false
+	}
+	if len(menu.Schedule) == 0 {
+		return true
+	}
+
+	t = t.In(menuLocation)
+	now := minuteOfDay(t.Format("15:04"))
+	yesterday := t.AddDate(0, 0, -1)
+	for _, window := range menu.Schedule {
+		start, end := minuteOfDay(window.Start), minuteOfDay(window.End)
+		if start < end {
+			if servedOn(window, t) && start <= now && now < end {
+				return true
+			}
+			continue
+		}
+		// the window runs past midnight, from start today or until end
+		// after yesterday's start
+		if servedOn(window, t) && now >= start {
+			return true
+		}
+		if servedOn(window, yesterday) && now < end {
+			return true
+		}
+	}
+	return false
+}
+
+// servedOn reports whether the window applies on the day of t.
+func servedOn(window models.MenuWindow, t time.Time) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	if menuHolidays[t.Format("2006-01-02")] {
+		return slices.Contains(window.Days, "HOL")
+	}
+	return slices.Contains(window.Days, strings.ToUpper(t.Weekday().String()[:3]))
+}
+
+// minuteOfDay turns a validated 15:04 time into minutes after midnight.
+func minuteOfDay(clock string) int {
+	t, _ := time.Parse("15:04", clock)
+	return t.Hour()*60 + t.Minute()
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMenuActiveAt(t *testing.T) {
+	ConfigureMenus(config.MenuConfig{Timezone: "Europe/Berlin", Holidays: []string{"2026-12-25"}})
+	defer ConfigureMenus(config.MenuConfig{})
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(value string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", value, berlin)
+		return t
+	}
+
+	start, end := at("2026-12-01 00:00"), at("2026-12-31 23:59")
+	lunch := models.Menu{Start_date: &start, End_date: &end, Schedule: []models.MenuWindow{
+		{Days: []string{"MON", "TUE", "WED", "THU", "FRI"}, Start: "11:30", End: "15:00"},
+		{Days: []string{"HOL"}, Start: "12:00", End: "16:00"},
+	}}
+	late := models.Menu{Schedule: []models.MenuWindow{{Days: []string{"FRI", "SAT"}, Start: "22:00", End: "02:00"}}}
+
+	tests := []struct {
+		name string
+		menu models.Menu
+		at   time.Time
+		want bool
+	}{
+		{"weekday lunch", lunch, at("2026-12-16 12:00"), true},
+		{"ends before its end time", lunch, at("2026-12-16 15:00"), false},
+		{"not at the weekend", lunch, at("2026-12-19 12:00"), false},
+		{"holiday hours on a friday holiday", lunch, at("2026-12-25 15:30"), true},
+		{"weekday hours not on a holiday", lunch, at("2026-12-25 11:45"), false},
+		{"before the start date", lunch, at("2026-11-30 12:00"), false},
+		{"after the end date", lunch, at("2027-01-04 12:00"), false},
+		{"read in the configured timezone", lunch, at("2026-12-16 12:00").UTC(), true},
+		{"late on friday", late, at("2026-12-18 23:00"), true},
+		{"past midnight into saturday", late, at("2026-12-19 01:30"), true},
+		{"past midnight into sunday", late, at("2026-12-20 01:30"), true},
+		{"not past midnight into friday", late, at("2026-12-18 01:30"), false},
+		{"no schedule is all day", models.Menu{}, at("2026-12-18 04:00"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MenuActiveAt(tt.menu, tt.at))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PrepareModifierGroups gives the groups of a food and their modifiers an id
+// where they have none and rounds the price deltas. It returns a message when
+// the selection limits of a group cannot be met.
+func PrepareModifierGroups(groups []models.ModifierGroup) ([]models.ModifierGroup, string) {
+	groupIds := map[string]bool{}
+	for i := range groups {
+		group := &groups[i]
+		if group.Group_id == "" {
+			group.Group_id = primitive.NewObjectID().Hex()
+		}
+		if groupIds[group.Group_id] {
+			return nil, fmt.Sprintf("modifier group %s is listed twice", group.Group_id)
+		}
+		groupIds[group.Group_id] = true
+
+		if group.Max_select > 0 && group.Min_select > group.Max_select {
+			return nil, fmt.Sprintf("modifier group %q picks at least %d but at most %d", group.Name, group.Min_select, group.Max_select)
+		}
+		if group.Min_select > len(group.Modifiers) {
+			return nil, fmt.Sprintf("modifier group %q picks at least %d of %d modifiers", group.Name, group.Min_select, len(group.Modifiers))
+		}
+
+		modifierIds := map[string]bool{}
+		for j := range group.Modifiers {
+			modifier := &group.Modifiers[j]
+			if modifier.Modifier_id == "" {
+				modifier.Modifier_id = primitive.NewObjectID().Hex()
+			}
+			if modifierIds[modifier.Modifier_id] {
+				return nil, fmt.Sprintf("modifier %s is listed twice in group %q", modifier.Modifier_id, group.Name)
+			}
+			modifierIds[modifier.Modifier_id] = true
+			modifier.Price_delta = roundCents(modifier.Price_delta)
+		}
+	}
+	return groups, ""
+}
+
+// SelectModifiers checks the modifiers picked for an item of food against the
+// rules of its groups, and returns them with the names and price deltas of
+// the food. It returns a message for the first rule broken.
+func SelectModifiers(food models.Food, picked []models.SelectedModifier) ([]models.SelectedModifier, string) {
+	groups := map[string]models.ModifierGroup{}
+	for _, group := range food.Modifier_groups {
+		groups[group.Group_id] = group
+	}
+
+	selected := make([]models.SelectedModifier, 0, len(picked))
+	counts := map[string]int{}
+	seen := map[string]bool{}
+	for _, pick := range picked {
+		group, ok := groups[pick.Group_id]
+		if !ok {
+			return nil, fmt.Sprintf("the food has no modifier group %s", pick.Group_id)
+		}
+		key := pick.Group_id + "/" + pick.Modifier_id
+		if seen[key] {
+			return nil, fmt.Sprintf("modifier %s is picked twice", pick.Modifier_id)
+		}
+		seen[key] = true
+
+		found := false
+		for _, modifier := range group.Modifiers {
+			if modifier.Modifier_id == pick.Modifier_id {
+				selected = append(selected, models.SelectedModifier{
+					Group_id:    group.Group_id,
+					Modifier_id: modifier.Modifier_id,
+					Group_name:  group.Name,
+					Name:        modifier.Name,
+					Price_delta: modifier.Price_delta,
+				})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Sprintf("modifier group %q has no modifier %s", group.Name, pick.Modifier_id)
+		}
+		counts[group.Group_id]++
+	}
+
+	for _, group := range food.Modifier_groups {
+		least := group.Min_select
+		if group.Required {
+			least = max(least, 1)
+		}
+		count := counts[group.Group_id]
+		if count < least {
+			return nil, fmt.Sprintf("pick at least %d from %q", least, group.Name)
+		}
+		if group.Max_select > 0 && count > group.Max_select {
+			return nil, fmt.Sprintf("pick at most %d from %q", group.Max_select, group.Name)
+		}
+	}
+	return selected, ""
+}
+
+// ModifierTotal is what the modifiers add to the price of an item.
+func ModifierTotal(selected []models.SelectedModifier) float64 {
+	var total float64
+	for _, modifier := range selected {
+		total += modifier.Price_delta
+	}
+	return roundCents(total)
+}
@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareModifierGroups(t *testing.T) {
+	groups, msg := PrepareModifierGroups([]models.ModifierGroup{{
+		Name:       "Extras",
+		Max_select: 2,
+		Modifiers:  []models.Modifier{{Name: "Cheese", Price_delta: 1.499}, {Name: "Bacon", Price_delta: 2}},
+	}})
+	assert.Empty(t, msg)
+	assert.NotEmpty(t, groups[0].Group_id)
+	assert.NotEmpty(t, groups[0].Modifiers[0].Modifier_id)
+	assert.NotEqual(t, groups[0].Modifiers[0].Modifier_id, groups[0].Modifiers[1].Modifier_id)
+	assert.Equal(t, 1.5, groups[0].Modifiers[0].Price_delta)
+
+	_, msg = PrepareModifierGroups([]models.ModifierGroup{{Name: "Sauce", Min_select: 2, Max_select: 1, Modifiers: groups[0].Modifiers}})
+	assert.NotEmpty(t, msg)
+	_, msg = PrepareModifierGroups([]models.ModifierGroup{{Name: "Sauce", Min_select: 3, Modifiers: groups[0].Modifiers}})
+	assert.NotEmpty(t, msg)
+}
+
+func TestSelectModifiers(t *testing.T) {
+	food := models.Food{Modifier_groups: []models.ModifierGroup{
+		{Group_id: "sauce", Name: "Sauce", Required: true, Max_select: 1, Modifiers: []models.Modifier{
+			{Modifier_id: "ketchup", Name: "Ketchup"},
+			{Modifier_id: "mayo", Name: "Mayo", Price_delta: 0.5},
+		}},
+		{Group_id: "extras", Name: "Extras", Max_select: 2, Modifiers: []models.Modifier{
+			{Modifier_id: "cheese", Name: "Extra cheese", Price_delta: 1.5},
+			{Modifier_id: "bacon", Name: "Bacon", Price_delta: 2},
+			{Modifier_id: "onions", Name: "No onions"},
+		}},
+	}}
+	pick := func(picks ...string) []models.SelectedModifier {
+		var selected []models.SelectedModifier
+		for i := 0; i < len(picks); i += 2 {
+			selected = append(selected, models.SelectedModifier{Group_id: picks[i], Modifier_id: picks[i+1]})
+		}
+		return selected
+	}
+
+	selected, msg := SelectModifiers(food, pick("sauce", "mayo", "extras", "cheese", "extras", "onions"))
+	assert.Empty(t, msg)
+	assert.Equal(t, "Sauce", selected[0].Group_name)
+	assert.Equal(t, "Extra cheese", selected[1].Name)
+	assert.Equal(t, 2.0, ModifierTotal(selected))
+
+	tests := []struct {
+		name  string
+		picks []models.SelectedModifier
+	}{
+		{"required group left out", pick("extras", "cheese")},
+		{"too many from a group", pick("sauce", "mayo", "sauce", "ketchup")},
+		{"over the maximum", pick("sauce", "mayo", "extras", "cheese", "extras", "bacon", "extras", "onions")},
+		{"picked twice", pick("sauce", "mayo", "extras", "cheese", "extras", "cheese")},
+		{"unknown group", pick("sauce", "mayo", "sides", "fries")},
+		{"modifier of another group", pick("sauce", "cheese")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, msg := SelectModifiers(food, tt.picks)
+			assert.NotEmpty(t, msg)
+		})
+	}
+
+	// a food without groups takes no modifiers
+	selected, msg = SelectModifiers(models.Food{}, nil)
+	assert.Empty(t, msg)
+	assert.Empty(t, selected)
+}
@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TextLine is one line of a monospaced document such as a receipt.
+type TextLine struct {
+	Text string
+	Bold bool
+}
+
+const (
+	pdfFontSize = 8.0
+	pdfLeading  = 10.0
+	pdfMargin   = 12.0
+	// a PDF page may not be taller than 14400pt, longer documents get more pages
+	pdfMaxLines = 1000
+)
+
+// WriteTextPDF writes lines as a PDF in the Courier fonts every PDF reader
+// has built in, so nothing has to be embedded. Pages are columns characters
+// wide and as tall as their lines, like a till roll.
+func WriteTextPDF(w io.Writer, title string, lines []TextLine, columns int) error {
+	var pages [][]TextLine
+	for len(lines) > pdfMaxLines {
+		pages = append(pages, lines[:pdfMaxLines])
+		lines = lines[pdfMaxLines:]
+	}
+	pages = append(pages, lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) int {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		return len(offsets)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	catalog := object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		// every page is followed by its contents, pages start at object 5
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	// Courier characters are 600/1000 of the font size wide
+	width := float64(columns)*pdfFontSize*0.6 + 2*pdfMargin
+	for _, page := range pages {
+		height := float64(len(page))*pdfLeading + 2*pdfMargin
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n%.2f TL\n%.2f %.2f Td\n", pdfLeading, pdfMargin, height-pdfMargin-pdfFontSize)
+		current := ""
+		for _, line := range page {
+			font := "/F1"
+			if line.Bold {
+				font = "/F2"
+			}
+			if font != current {
+				current = font
+				fmt.Fprintf(&content, "%s %.1f Tf\n", font, pdfFontSize)
+			}
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(line.Text))
+		}
+		content.WriteString("ET")
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			width, height, len(offsets)+2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+	info := object(fmt.Sprintf("<< /Title (%s) /Producer (Restaurant_Management) /CreationDate (D:%s) >>",
+		pdfString(title), time.Now().UTC().Format("20060102150405Z")))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, catalog, info, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfString escapes text for a PDF string literal in WinAnsiEncoding. Runes
+// that encoding does not have are printed as '?'.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString("\\200")
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
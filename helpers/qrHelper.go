@@ -0,0 +1,505 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// ErrQRTooLong is returned for text that does not fit the largest QR code
+// EncodeQR makes.
+var ErrQRTooLong = errors.New("text is too long for a QR code")
+
+// qrQuietZone is the light border, in modules, scanners need around a code
+const qrQuietZone = 4
+
+// qrVersion is the layout of one QR code size at error correction level M,
+// which survives about 15% of the code being damaged or dirty.
+type qrVersion struct {
+	ecPerBlock int
+	// blocks lists the number of data codewords of each block
+	blocks []int
+	// alignment are the centre rows and columns of the alignment patterns
+	alignment []int
+}
+
+// qrVersions are versions 1 to 10, enough for 213 bytes of text.
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// QRCode is a QR code as a square of dark and light modules, without the
+// quiet zone.
+type QRCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// EncodeQR encodes text in byte mode at error correction level M, in the
+// smallest version it fits, with the mask the standard's penalty rules find
+// easiest to scan.
+func EncodeQR(text string) (QRCode, error) {
+	data := []byte(text)
+	for v := range qrVersions {
+		version := v + 1
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		capacity := 0
+		for _, block := range qrVersions[v].blocks {
+			capacity += block
+		}
+		if 4+countBits+8*len(data) > 8*capacity {
+			continue
+		}
+		return newQRCode(version, qrCodewords(qrVersions[v], data, countBits, capacity)), nil
+	}
+	return QRCode{}, ErrQRTooLong
+}
+
+// Size is the number of modules on each side, without the quiet zone.
+func (q QRCode) Size() int {
+	return q.size
+}
+
+// Dark tells whether the module in column x of row y is dark.
+func (q QRCode) Dark(x int, y int) bool {
+	return q.modules[y][x]
+}
+
+// PNG draws the code scale pixels per module, with the quiet zone.
+func (q QRCode) PNG(scale int) ([]byte, error) {
+	side := (q.size + 2*qrQuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+qrQuietZone)*scale+dx, (y+qrQuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG draws the code scale units per module, with the quiet zone. The dark
+// modules are one path, so the drawing stays small.
+func (q QRCode) SVG(scale int) string {
+	side := q.size + 2*qrQuietZone
+	var path strings.Builder
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		side*scale, side*scale, side, side, path.String())
+}
+
+// qrCodewords returns the data in byte mode padded to capacity codewords,
+// split into blocks with their error correction and interleaved.
+func qrCodewords(version qrVersion, data []byte, countBits int, capacity int) []byte {
+	var bits qrBits
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, 8*capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	codewords := bits.bytes()
+	for pad := 0; len(codewords) < capacity; pad++ {
+		codewords = append(codewords, []byte{0xec, 0x11}[pad%2])
+	}
+
+	divisor := qrDivisor(version.ecPerBlock)
+	var blocks, ecBlocks [][]byte
+	for _, length := range version.blocks {
+		block := codewords[:length]
+		codewords = codewords[length:]
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, qrRemainder(block, divisor))
+	}
+
+	var result []byte
+	longest := version.blocks[len(version.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < version.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return result
+}
+
+// qrMultiply multiplies in GF(2^8) modulo the QR polynomial x^8+x^4+x^3+x^2+1.
+func qrMultiply(x byte, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z&0x80 != 0
+		z <<= 1
+		if carry {
+			z ^= 0x1d
+		}
+		if y>>i&1 == 1 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+// qrDivisor returns the Reed-Solomon generator polynomial of degree, without
+// its leading 1, highest power first.
+func qrDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	var root byte = 1
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrMultiply(root, 2)
+	}
+	return result
+}
+
+// qrRemainder returns the error correction codewords of data.
+func qrRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= qrMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// newQRCode lays out the codewords in a code of version with its best mask.
+func newQRCode(version int, codewords []byte) QRCode {
+	size := 17 + 4*version
+	q := QRCode{size: size, modules: qrGrid(size), function: qrGrid(size)}
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q
+}
+
+func qrGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+func (q *QRCode) set(x int, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *QRCode) drawFunctionPatterns(version int) {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, corner := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x < 0 || x >= q.size || y < 0 || y >= q.size {
+					continue
+				}
+				distance := max(abs(dx), abs(dy))
+				q.set(x, y, distance != 2 && distance != 4)
+			}
+		}
+	}
+
+	alignment := qrVersions[version-1].alignment
+	last := len(alignment) - 1
+	for i, cy := range alignment {
+		for j, cx := range alignment {
+			// the corners with a finder pattern have no alignment pattern
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas, drawn once the mask is known
+	q.drawFormat(0)
+
+	if version >= 7 {
+		bits := qrVersionBits(version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information for level M and
+// mask, and the module that is always dark.
+func (q *QRCode) drawFormat(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// qrFormatBits returns the 15 format bits of level M with mask.
+func qrFormatBits(mask int) int {
+	// level M is 00
+	data := mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// qrVersionBits returns the 18 version bits codes from version 7 carry.
+func qrVersionBits(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1f25
+	}
+	return version<<12 | remainder
+}
+
+// drawCodewords fills the modules not taken by function patterns in the
+// zigzag of two columns wide, from the bottom right corner.
+func (q *QRCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < q.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if upward {
+					y = q.size - 1 - vertical
+				}
+				if q.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules the mask selects. Applying it twice
+// undoes it.
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the code by the four rules of the standard: long runs of
+// one colour, 2x2 blocks, patterns that look like finders and an unbalanced
+// number of dark modules.
+func (q *QRCode) penalty() int {
+	penalty := 0
+	finderLike := []bool{true, false, true, true, true, false, true}
+
+	line := make([]bool, q.size)
+	for horizontal := 0; horizontal < 2; horizontal++ {
+		for a := 0; a < q.size; a++ {
+			for b := 0; b < q.size; b++ {
+				if horizontal == 0 {
+					line[b] = q.modules[a][b]
+				} else {
+					line[b] = q.modules[b][a]
+				}
+			}
+
+			run := 1
+			for b := 1; b <= q.size; b++ {
+				if b < q.size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for b := 0; b+len(finderLike) <= q.size; b++ {
+				matches := true
+				for k, dark := range finderLike {
+					if line[b+k] != dark {
+						matches = false
+						break
+					}
+				}
+				if matches && (qrLight(line, b-4, b) || qrLight(line, b+7, b+11)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				colour := q.modules[y][x]
+				if q.modules[y][x+1] == colour && q.modules[y+1][x] == colour && q.modules[y+1][x+1] == colour {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	deviation := abs(dark*20-total*10) / total
+	penalty += deviation * 10
+	return penalty
+}
+
+// qrLight tells whether the modules of line from up to, not including, to are
+// light. Modules outside the code are in the light quiet zone.
+func qrLight(line []bool, from int, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
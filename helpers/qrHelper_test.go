@@ -0,0 +1,160 @@
+package helpers
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRErrorCorrection(t *testing.T) {
+	// "HELLO WORLD" at 1-M, the worked example of the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, qrRemainder(data, qrDivisor(10)))
+
+	assert.Equal(t, 0b101010000010010, qrFormatBits(0))
+	assert.Equal(t, 0b100010111111001, qrFormatBits(4))
+	assert.Equal(t, 0b000111110010010100, qrVersionBits(7))
+}
+
+func TestEncodeQR(t *testing.T) {
+	for _, text := range []string{
+		"hi",
+		"https://order.example.com/?table=652f1c2e9b1e8a0012345678&sig=Xk3v9QpL2mN8rT5wYz1aBc",
+		strings.Repeat("long text ", 20),
+	} {
+		code, err := EncodeQR(text)
+		require.NoError(t, err)
+		assert.Equal(t, text, decodeTestQR(t, code), "%d bytes", len(text))
+	}
+
+	code, _ := EncodeQR("hi")
+	assert.Equal(t, 21, code.Size())
+	// the finder pattern of the top left corner
+	for i := 0; i < 7; i++ {
+		assert.True(t, code.Dark(i, 0))
+		assert.True(t, code.Dark(0, i))
+	}
+	assert.False(t, code.Dark(1, 1))
+	assert.True(t, code.Dark(3, 3))
+
+	_, err := EncodeQR(strings.Repeat("x", 214))
+	assert.ErrorIs(t, err, ErrQRTooLong)
+}
+
+func TestQRImages(t *testing.T) {
+	code, err := EncodeQR("https://example.com")
+	require.NoError(t, err)
+
+	data, err := code.PNG(4)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, (code.Size()+8)*4, img.Bounds().Dx())
+
+	svg := code.SVG(4)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `viewBox="0 0 33 33"`)
+}
+
+// decodeTestQR reads the text back out of a code: the mask from the format
+// information, the codewords from the zigzag, the data blocks from the
+// interleaving and the text from the byte mode segment.
+func decodeTestQR(t *testing.T, code QRCode) string {
+	version := (code.size - 17) / 4
+	layout := qrVersions[version-1]
+
+	formatBits := 0
+	for i := 0; i < 8; i++ {
+		if code.Dark(code.size-1-i, 8) {
+			formatBits |= 1 << i
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if code.Dark(8, code.size-15+i) {
+			formatBits |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatBits(m) == formatBits {
+			mask = m
+		}
+	}
+	require.NotEqual(t, -1, mask, "the second copy of the format information is level M")
+
+	unmasked := QRCode{size: code.size, modules: qrGrid(code.size), function: qrGrid(code.size)}
+	unmasked.drawFunctionPatterns(version)
+	for y := range code.modules {
+		copy(unmasked.modules[y], code.modules[y])
+	}
+	unmasked.applyMask(mask)
+
+	var bits qrBits
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < code.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vertical
+				}
+				if !unmasked.function[y][x] {
+					bits = append(bits, unmasked.modules[y][x])
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	blocks := make([][]byte, len(layout.blocks))
+	i := 0
+	for k := 0; k < layout.blocks[len(layout.blocks)-1]; k++ {
+		for b, length := range layout.blocks {
+			if k < length {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		ec := make([]byte, layout.ecPerBlock)
+		for k := range ec {
+			ec[k] = codewords[i+k*len(blocks)+b]
+		}
+		assert.Equal(t, qrRemainder(block, qrDivisor(layout.ecPerBlock)), ec, "error correction of block %d", b)
+		data = append(data, block...)
+	}
+
+	var stream qrBits
+	for _, b := range data {
+		stream.append(int(b), 8)
+	}
+	read := func(n int) int {
+		value := 0
+		for _, bit := range stream[:n] {
+			value <<= 1
+			if bit {
+				value |= 1
+			}
+		}
+		stream = stream[n:]
+		return value
+	}
+	require.Equal(t, 0b0100, read(4), "byte mode")
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	text := make([]byte, read(countBits))
+	for k := range text {
+		text[k] = byte(read(8))
+	}
+	return string(text)
+}
@@ -0,0 +1,252 @@
+package helpers
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+// ReceiptColumns is how many characters fit on a line of an 80mm till roll.
+const ReceiptColumns = 42
+
+var receiptConfig = config.Default().Receipt
+
+// ConfigureReceipt sets the restaurant details printed on receipts.
+func ConfigureReceipt(cfg config.ReceiptConfig) {
+	receiptConfig = cfg
+}
+
+// Receipt is an invoice as it is printed for the guest.
+type Receipt struct {
+	Invoice_id     string
+	Order_id       string
+	Table_number   *int
+	Issued_at      time.Time
+	Payment_status string
+	Items          []ReceiptItem
+	Totals         []ReceiptTotal
+	Payments       []ReceiptPayment
+}
+
+type ReceiptItem struct {
+	Name     string
+	Quantity int
+	Price    float64
+	Amount   float64
+	// Note marks items that are not charged, like VOID or COMP
+	Note string
+}
+
+// ReceiptTotal is a line under the items, subtotal, a tax, the total and so on.
+type ReceiptTotal struct {
+	Label  string
+	Amount float64
+	Bold   bool
+}
+
+type ReceiptPayment struct {
+	Method string
+	Amount float64
+	Tip    float64
+	Change float64
+}
+
+// ReceiptText lays the receipt out in lines of at most columns characters.
+func ReceiptText(receipt Receipt, columns int) []TextLine {
+	var lines []TextLine
+	add := func(text string, bold bool) {
+		lines = append(lines, TextLine{Text: text, Bold: bold})
+	}
+	center := func(text string, bold bool) {
+		for _, part := range WrapText(text, columns) {
+			add(strings.Repeat(" ", (columns-utf8.RuneCountInString(part))/2)+part, bold)
+		}
+	}
+	rule := func() { add(strings.Repeat("-", columns), false) }
+
+	center(receiptConfig.Name, true)
+	for _, line := range receiptConfig.Address {
+		center(line, false)
+	}
+	if receiptConfig.Phone != "" {
+		center("Tel "+receiptConfig.Phone, false)
+	}
+	if receiptConfig.TaxNumber != "" {
+		center(receiptConfig.TaxNumber, false)
+	}
+	rule()
+
+	add(twoColumns("Invoice", receipt.Invoice_id, columns), false)
+	if receipt.Table_number != nil {
+		add(twoColumns("Table", fmt.Sprint(*receipt.Table_number), columns), false)
+	}
+	add(twoColumns("Date", receipt.Issued_at.Format("2006-01-02 15:04"), columns), false)
+	rule()
+
+	for _, item := range receipt.Items {
+		amount := formatMoney(item.Amount)
+		if item.Note != "" {
+			amount = item.Note
+		}
+		name := fmt.Sprintf("%d x %s", item.Quantity, item.Name)
+		// the name wraps in the space left of the amount
+		parts := WrapText(name, columns-len(amount)-1)
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				add(twoColumns(part, amount, columns), false)
+			} else {
+				add(part, false)
+			}
+		}
+		if item.Quantity > 1 {
+			add("    @ "+formatMoney(item.Price), false)
+		}
+	}
+	rule()
+
+	for _, total := range receipt.Totals {
+		add(twoColumns(total.Label, formatMoney(total.Amount), columns), total.Bold)
+	}
+
+	if len(receipt.Payments) > 0 {
+		rule()
+		for _, payment := range receipt.Payments {
+			add(twoColumns("Paid by "+payment.Method, formatMoney(payment.Amount), columns), false)
+			if payment.Tip != 0 {
+				add(twoColumns("  Tip", formatMoney(payment.Tip), columns), false)
+			}
+			if payment.Change != 0 {
+				add(twoColumns("  Change", formatMoney(payment.Change), columns), false)
+			}
+		}
+	}
+	if receipt.Payment_status != "" {
+		add(twoColumns("Status", receipt.Payment_status, columns), true)
+	}
+
+	if len(receiptConfig.Footer) > 0 {
+		rule()
+		for _, line := range receiptConfig.Footer {
+			center(line, false)
+		}
+	}
+	return lines
+}
+
+// WriteReceiptPDF writes the receipt as a till roll sized PDF.
+func WriteReceiptPDF(w io.Writer, receipt Receipt) error {
+	return WriteTextPDF(w, "Invoice "+receipt.Invoice_id, ReceiptText(receipt, ReceiptColumns), ReceiptColumns)
+}
+
+// WriteReceiptHTML writes the receipt as a page that can be shown or printed
+// from a browser.
+func WriteReceiptHTML(w io.Writer, receipt Receipt) error {
+	return receiptTemplate.Execute(w, struct {
+		Restaurant config.ReceiptConfig
+		Receipt
+	}{receiptConfig, receipt})
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money": formatMoney,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Invoice_id}}</title>
+<style>
+body { font-family: "Courier New", monospace; font-size: 13px; max-width: 80mm; margin: 0 auto; padding: 8px; }
+header, footer { text-align: center; }
+h1 { font-size: 16px; margin: 0 0 4px; }
+p { margin: 0; }
+table { width: 100%; border-collapse: collapse; margin: 8px 0; border-top: 1px dashed #000; }
+td { padding: 2px 0; vertical-align: top; }
+td.amount { text-align: right; white-space: nowrap; }
+tr.bold td { font-weight: bold; }
+@media print { body { padding: 0; } }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Restaurant.Name}}</h1>
+{{range .Restaurant.Address}}<p>{{.}}</p>
+{{end}}{{with .Restaurant.Phone}}<p>Tel {{.}}</p>
+{{end}}{{with .Restaurant.TaxNumber}}<p>{{.}}</p>
+{{end}}</header>
+<table>
+<tr><td>Invoice</td><td class="amount">{{.Invoice_id}}</td></tr>
+{{with .Table_number}}<tr><td>Table</td><td class="amount">{{.}}</td></tr>
+{{end}}<tr><td>Date</td><td class="amount">{{.Issued_at.Format "2006-01-02 15:04"}}</td></tr>
+</table>
+<table>
+{{range .Items}}<tr><td>{{.Quantity}} x {{.Name}}{{if gt .Quantity 1}} @ {{money .Price}}{{end}}</td><td class="amount">{{if .Note}}{{.Note}}{{else}}{{money .Amount}}{{end}}</td></tr>
+{{end}}</table>
+<table>
+{{range .Totals}}<tr{{if .Bold}} class="bold"{{end}}><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}</table>
+{{if .Payments}}<table>
+{{range .Payments}}<tr><td>Paid by {{.Method}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{if .Tip}}<tr><td>&nbsp;&nbsp;Tip</td><td class="amount">{{money .Tip}}</td></tr>
+{{end}}{{if .Change}}<tr><td>&nbsp;&nbsp;Change</td><td class="amount">{{money .Change}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}{{with .Payment_status}}<table>
+<tr class="bold"><td>Status</td><td class="amount">{{.}}</td></tr>
+</table>
+{{end}}<footer>
+{{range .Restaurant.Footer}}<p>{{.}}</p>
+{{end}}</footer>
+</body>
+</html>
+`))
+
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// twoColumns puts left and right on the ends of a line, cutting left short
+// when both do not fit.
+func twoColumns(left string, right string, columns int) string {
+	space := columns - utf8.RuneCountInString(right) - 1
+	if space < 0 {
+		space = 0
+	}
+	if utf8.RuneCountInString(left) > space {
+		left = string([]rune(left)[:space])
+	}
+	pad := max(columns-utf8.RuneCountInString(left)-utf8.RuneCountInString(right), 1)
+	return left + strings.Repeat(" ", pad) + right
+}
+
+// WrapText breaks text at spaces into lines of at most columns characters.
+// Words longer than a line are cut.
+func WrapText(text string, columns int) []string {
+	if columns < 1 {
+		columns = 1
+	}
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > columns {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		for len(w) > columns {
+			lines = append(lines, string(w[:columns]))
+			w = w[columns:]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testReceipt() Receipt {
+	table := 7
+	return Receipt{
+		Invoice_id:     "inv1",
+		Table_number:   &table,
+		Issued_at:      time.Date(2026, 10, 16, 19, 30, 0, 0, time.UTC),
+		Payment_status: "PAID",
+		Items: []ReceiptItem{
+			{Name: "Margherita (large) with extra buffalo mozzarella", Quantity: 2, Price: 9.5, Amount: 19},
+			{Name: "Tiramisu", Quantity: 1, Price: 6, Amount: 6, Note: "COMP"},
+		},
+		Totals: []ReceiptTotal{
+			{Label: "Subtotal", Amount: 19},
+			{Label: "TOTAL", Amount: 19.95, Bold: true},
+		},
+		Payments: []ReceiptPayment{{Method: "CASH", Amount: 19.95, Tip: 2, Change: 8.05}},
+	}
+}
+
+func TestReceiptText(t *testing.T) {
+	ConfigureReceipt(config.ReceiptConfig{Name: "Chez Test", Phone: "555", Footer: []string{"Merci"}})
+	defer ConfigureReceipt(config.Default().Receipt)
+
+	var text []string
+	for _, line := range ReceiptText(testReceipt(), 32) {
+		assert.LessOrEqual(t, len(line.Text), 32)
+		text = append(text, line.Text)
+	}
+	assert.Equal(t, []string{
+		"           Chez Test",
+		"            Tel 555",
+		"--------------------------------",
+		"Invoice                     inv1",
+		"Table                          7",
+		"Date            2026-10-16 19:30",
+		"--------------------------------",
+		"2 x Margherita (large)",
+		"with extra buffalo",
+		"mozzarella                 19.00",
+		"    @ 9.50",
+		"1 x Tiramisu                COMP",
+		"--------------------------------",
+		"Subtotal                   19.00",
+		"TOTAL                      19.95",
+		"--------------------------------",
+		"Paid by CASH               19.95",
+		"  Tip                       2.00",
+		"  Change                    8.05",
+		"Status                      PAID",
+		"--------------------------------",
+		"             Merci",
+	}, text)
+}
+
+func TestWriteReceiptPDF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReceiptPDF(&buf, testReceipt()))
+	pdf := buf.String()
+
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, "(1 x Tiramisu")
+	assert.Contains(t, pdf, `Margherita \(large\)`)
+
+	// startxref and every entry of the cross-reference table point at what
+	// a reader expects to find there
+	xref, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)[1])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pdf[xref:], "xref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf[xref:], -1)
+	assert.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(pdf[offset:], strconv.Itoa(i+1)+" 0 obj"), "object %d", i+1)
+	}
+}
+
+func TestWriteReceiptHTML(t *testing.T) {
+	receipt := testReceipt()
+	receipt.Items[1].Name = "<script>"
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReceiptHTML(&buf, receipt))
+	html := buf.String()
+	assert.Contains(t, html, "<h1>Restaurant</h1>")
+	assert.Contains(t, html, "2 x Margherita (large) with extra buffalo mozzarella @ 9.50")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.Contains(t, html, "Thank you for dining with us!")
+}
+
+func TestPDFString(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\ caf\351 \200 ?`, pdfString("a(b)\\ café € ✓"))
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+var reservationConfig = config.Default().Reservations
+
+// ConfigureReservations sets how long bookings hold their tables.
+func ConfigureReservations(cfg config.ReservationConfig) {
+	reservationConfig = cfg
+}
+
+// ReservationWindow returns when a party starting at start is expected to
+// leave, and until when the table is held for it.
+func ReservationWindow(start time.Time) (endsAt time.Time, blockedUntil time.Time) {
+	endsAt = start.Add(reservationConfig.DiningDuration)
+	return endsAt, endsAt.Add(reservationConfig.Buffer)
+}
+
+// NoShowAfter is the time from which a party booked for start that has not
+// arrived can be marked a no-show.
+func NoShowAfter(start time.Time) time.Time {
+	return start.Add(reservationConfig.NoShowGrace)
+}
+
+// NearbySlots returns the start times within an hour of at, one slot
+// interval apart, nearest first and earlier before later on a tie.
+func NearbySlots(at time.Time) []time.Time {
+	var slots []time.Time
+	step := reservationConfig.SlotInterval
+	for offset := step; offset <= time.Hour; offset += step {
+		slots = append(slots, at.Add(-offset), at.Add(offset))
+	}
+	return slots
+}
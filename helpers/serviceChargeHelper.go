@@ -0,0 +1,30 @@
+package helpers
+
+import "github.com/rkmangalp/Restaurant_Management/config"
+
+var serviceChargeConfig config.ServiceChargeConfig
+
+// ConfigureServiceCharge sets the automatic service charge. Without it there is none.
+func ConfigureServiceCharge(cfg config.ServiceChargeConfig) {
+	serviceChargeConfig = cfg
+}
+
+// ServiceChargeLine is the service charge as shown on the bill.
+type ServiceChargeLine struct {
+	Name    string  `json:"name"`
+	Percent float64 `json:"percent"`
+	Amount  float64 `json:"amount"`
+}
+
+// ServiceCharge returns the service charge on subtotal for a table of guests,
+// or nil when the party is too small or there is no service charge.
+func ServiceCharge(guests int, subtotal float64) *ServiceChargeLine {
+	if serviceChargeConfig.Percent <= 0 || guests < serviceChargeConfig.MinGuests {
+		return nil
+	}
+	return &ServiceChargeLine{
+		Name:    serviceChargeConfig.Name,
+		Percent: serviceChargeConfig.Percent,
+		Amount:  roundCents(subtotal * serviceChargeConfig.Percent / 100),
+	}
+}
@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+var kitchenConfig config.KitchenConfig
+
+// ConfigureKitchen sets the kitchen stations. Without it there is one station.
+func ConfigureKitchen(cfg config.KitchenConfig) {
+	kitchenConfig = cfg
+}
+
+// defaultStation is the whole kitchen when no stations are configured
+var defaultStation = config.StationConfig{Name: "Kitchen"}
+
+// KitchenStation returns the station that prepares food of category: the one
+// listing the category, else the one without categories, else the first one.
+func KitchenStation(category string) config.StationConfig {
+	var catchAll *config.StationConfig
+	for i, station := range kitchenConfig.Stations {
+		if len(station.Categories) == 0 && catchAll == nil {
+			catchAll = &kitchenConfig.Stations[i]
+		}
+		for _, c := range station.Categories {
+			if strings.EqualFold(c, category) {
+				return station
+			}
+		}
+	}
+	if catchAll != nil {
+		return *catchAll
+	}
+	if len(kitchenConfig.Stations) > 0 {
+		return kitchenConfig.Stations[0]
+	}
+	return defaultStation
+}
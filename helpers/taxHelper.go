@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"math"
+	"strings"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+var taxConfig config.TaxConfig
+
+// ConfigureTax sets the tax rates used by CalculateTax. Without it nothing is taxed.
+func ConfigureTax(cfg config.TaxConfig) {
+	taxConfig = cfg
+}
+
+// TaxableLine is one priced line of an order, Amount is the price as charged
+// on the menu, so it includes the tax when pricing is inclusive.
+type TaxableLine struct {
+	Category string
+	Amount   float64
+}
+
+// TaxLine is the tax collected at one rate.
+type TaxLine struct {
+	Name      string  `json:"name"`
+	Percent   float64 `json:"percent"`
+	Inclusive bool    `json:"inclusive"`
+	Taxable   float64 `json:"taxable_amount"`
+	Tax       float64 `json:"tax_amount"`
+}
+
+// TaxBreakdown splits the amount of an order into its net subtotal and taxes.
+// Grand_total is Subtotal plus Tax_total.
+type TaxBreakdown struct {
+	Subtotal    float64   `json:"subtotal"`
+	Taxes       []TaxLine `json:"taxes"`
+	Tax_total   float64   `json:"tax_total"`
+	Grand_total float64   `json:"grand_total"`
+}
+
+// taxRuleFor returns the rate for a food category and whether it is included in the price.
+func taxRuleFor(category string) (config.TaxRate, bool) {
+	for _, rule := range taxConfig.Rules {
+		if strings.EqualFold(rule.Category, category) {
+			inclusive := taxConfig.Inclusive
+			if rule.Inclusive != nil {
+				inclusive = *rule.Inclusive
+			}
+			return rule.TaxRate, inclusive
+		}
+	}
+	return taxConfig.Default, taxConfig.Inclusive
+}
+
+// CalculateTax groups the lines by tax rate and computes the tax of each group.
+// Tax is rounded to cents once per rate rather than per line, so a bill does not
+// drift from what the rate says because of many small items.
+func CalculateTax(lines []TaxableLine) TaxBreakdown {
+	breakdown := TaxBreakdown{Taxes: []TaxLine{}}
+	index := map[TaxLine]int{}
+	var gross []float64
+
+	for _, line := range lines {
+		rate, inclusive := taxRuleFor(line.Category)
+		key := TaxLine{Name: rate.Name, Percent: rate.Percent, Inclusive: inclusive}
+		i, ok := index[key]
+		if !ok {
+			i = len(breakdown.Taxes)
+			index[key] = i
+			breakdown.Taxes = append(breakdown.Taxes, key)
+			gross = append(gross, 0)
+		}
+		gross[i] += line.Amount
+	}
+
+	for i := range breakdown.Taxes {
+		taxLine := &breakdown.Taxes[i]
+		amount := roundCents(gross[i])
+		if taxLine.Inclusive {
+			taxLine.Tax = roundCents(amount - amount/(1+taxLine.Percent/100))
+			taxLine.Taxable = roundCents(amount - taxLine.Tax)
+		} else {
+			taxLine.Taxable = amount
+			taxLine.Tax = roundCents(amount * taxLine.Percent / 100)
+		}
+		breakdown.Subtotal += taxLine.Taxable
+		breakdown.Tax_total += taxLine.Tax
+	}
+
+	// untaxed lines only matter for the subtotal
+	taxes := breakdown.Taxes[:0]
+	for _, taxLine := range breakdown.Taxes {
+		if taxLine.Percent > 0 || taxLine.Name != "" {
+			taxes = append(taxes, taxLine)
+		}
+	}
+	breakdown.Taxes = taxes
+
+	breakdown.Subtotal = roundCents(breakdown.Subtotal)
+	breakdown.Tax_total = roundCents(breakdown.Tax_total)
+	breakdown.Grand_total = roundCents(breakdown.Subtotal + breakdown.Tax_total)
+	return breakdown
+}
+
+// GrossAmount is what a line of amount costs including its tax, before rounding.
+func GrossAmount(category string, amount float64) float64 {
+	rate, inclusive := taxRuleFor(category)
+	if inclusive {
+		return amount
+	}
+	return amount * (1 + rate.Percent/100)
+}
+
+// AllocateTotal splits total over the lines in proportion to weights, rounded
+// to cents, with the rounding rest on the last line so the shares add up to total.
+func AllocateTotal(total float64, weights []float64) []float64 {
+	shares := make([]float64, len(weights))
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+	remaining := roundCents(total)
+	for i, weight := range weights {
+		if i == len(weights)-1 {
+			shares[i] = remaining
+			break
+		}
+		if sum > 0 {
+			shares[i] = roundCents(total * weight / sum)
+		}
+		remaining = roundCents(remaining - shares[i])
+	}
+	return shares
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateTax(t *testing.T) {
+	inclusive := true
+	ConfigureTax(config.TaxConfig{
+		Default: config.TaxRate{Name: "GST", Percent: 5},
+		Rules: []config.TaxRule{
+			{Category: "alcohol", TaxRate: config.TaxRate{Name: "VAT", Percent: 20}},
+			{Category: "takeaway", TaxRate: config.TaxRate{Name: "GST takeaway", Percent: 10}, Inclusive: &inclusive},
+			{Category: "water", TaxRate: config.TaxRate{}},
+		},
+	})
+	defer ConfigureTax(config.TaxConfig{})
+
+	breakdown := CalculateTax([]TaxableLine{
+		{Category: "Pizza", Amount: 10},
+		{Category: "pasta", Amount: 10},
+		{Category: "ALCOHOL", Amount: 7.5},
+		{Category: "takeaway", Amount: 11},
+		{Category: "water", Amount: 2},
+	})
+
+	assert.Equal(t, []TaxLine{
+		{Name: "GST", Percent: 5, Taxable: 20, Tax: 1},
+		{Name: "VAT", Percent: 20, Taxable: 7.5, Tax: 1.5},
+		{Name: "GST takeaway", Percent: 10, Inclusive: true, Taxable: 10, Tax: 1},
+	}, breakdown.Taxes)
+	assert.Equal(t, 39.5, breakdown.Subtotal)
+	assert.Equal(t, 3.5, breakdown.Tax_total)
+	assert.Equal(t, 43.0, breakdown.Grand_total)
+}
+
+func TestCalculateTaxWithoutRules(t *testing.T) {
+	breakdown := CalculateTax([]TaxableLine{{Category: "food", Amount: 12.25}})
+
+	assert.Empty(t, breakdown.Taxes)
+	assert.Equal(t, 12.25, breakdown.Subtotal)
+	assert.Equal(t, 12.25, breakdown.Grand_total)
+}
@@ -3,16 +3,19 @@ package helpers
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"github.com/rkmangalp/Restaurant_Management/database"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// token types, so a refresh token cannot be used as an access token and the other way round
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
 )
 
 type SignedDetails struct {
@@ -20,74 +23,128 @@ type SignedDetails struct {
 	First_name string
 	Last_name  string
 	Uid        string
+	User_type  string
+	Session_id string
+	Token_type string
 	jwt.StandardClaims
 }
 
-var userCollectioon *mongo.Collection = database.OpenCollection(database.Client, "user")
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+var SECRET_KEY string
+
+var accessTokenTTL = 24 * time.Hour
+var refreshTokenTTL = 128 * time.Hour
+
+// Configure sets the signing secret and token lifetimes, it must be called
+// before any token is issued or validated.
+func Configure(cfg config.JWTConfig) {
+	SECRET_KEY = cfg.Secret
+	accessTokenTTL = cfg.AccessTokenTTL
+	refreshTokenTTL = cfg.RefreshTokenTTL
+}
+
+// GenerateAllTokens signs an access and a refresh token for an existing session.
+// refreshTokenId becomes the id of the refresh token and must be stored on the
+// session, see StartSession and RotateRefreshToken.
+func GenerateAllTokens(email string, firstName string, lastName string, uid string, userType string, sessionId string, refreshTokenId string) (signedToken string, signedrefeshToken string, err error) {
+	now := time.Now().Local()
 
-func GenerateAllTokens(email string, firstName string, lastName string, uid string) (signedToken string, signedrefeshToken string, err error) {
 	claims := &SignedDetails{
 		Email:      email,
 		First_name: firstName,
 		Last_name:  lastName,
 		Uid:        uid,
+		User_type:  userType,
+		Session_id: sessionId,
+		Token_type: AccessToken,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
+			Id:        primitive.NewObjectID().Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTokenTTL).Unix(),
 		},
 	}
 
 	refreshClaims := &SignedDetails{
+		Uid:        uid,
+		Session_id: sessionId,
+		Token_type: RefreshToken,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(128)).Unix(),
+			Id:        refreshTokenId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(refreshTokenTTL).Unix(),
 		},
 	}
 
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	if err != nil {
+		return
+	}
 	refeshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
-
 	if err != nil {
-		log.Panic(err)
 		return
 	}
 
 	return token, refeshToken, nil
 }
 
-func UpdateAllTokens(signedToken string, signedrefeshToken string, userId string) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+// IssueTokens starts a new session for the user and returns its token pair.
+func IssueTokens(sessions repository.SessionRepository, user models.User) (signedToken string, signedrefeshToken string, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var updateObj primitive.D
+	now := time.Now()
+	session := models.Session{
+		ID:               primitive.NewObjectID(),
+		User_id:          user.User_id,
+		Refresh_token_id: primitive.NewObjectID().Hex(),
+		Expires_at:       now.Add(refreshTokenTTL),
+		Created_at:       now,
+		Updated_at:       now,
+	}
+	session.Session_id = session.ID.Hex()
+
+	signedToken, signedrefeshToken, err = GenerateAllTokens(*user.Email, *user.First_name, *user.Last_name, user.User_id, user.UserType, session.Session_id, session.Refresh_token_id)
+	if err != nil {
+		return
+	}
 
-	updateObj = append(updateObj, bson.E{"token", signedToken})
-	updateObj = append(updateObj, bson.E{"refresh_token", signedrefeshToken})
+	err = sessions.StartSession(ctx, session)
+	return
+}
 
-	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, bson.E{"updated_at", Updated_at})
+// RefreshTokenExpiry is when a refresh token issued now stops being accepted.
+func RefreshTokenExpiry() time.Time {
+	return time.Now().Add(refreshTokenTTL)
+}
 
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
+// ValidateToken checks an access token, including whether its session has been
+// revoked in the sessions repository, which is the revocation store.
+func ValidateToken(sessions repository.SessionRepository, signedToken string) (claims *SignedDetails, msg string) {
+	claims, msg = parseToken(signedToken, AccessToken)
+	if msg != "" {
+		return nil, msg
 	}
 
-	_, err := userCollectioon.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revoked, err := sessions.IsRevoked(ctx, claims.Session_id)
 	if err != nil {
-		log.Panic(err)
-		return
+		return nil, "could not check the token session"
 	}
-	return
+	if revoked {
+		return nil, "the token has been revoked"
+	}
+
+	return claims, msg
+}
+
+// ValidateRefreshToken checks the signature, type and expiry of a refresh token.
+// Whether it is still the current token of its session is up to the caller.
+func ValidateRefreshToken(signedToken string) (claims *SignedDetails, msg string) {
+	return parseToken(signedToken, RefreshToken)
 }
 
-func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
+func parseToken(signedToken string, tokenType string) (claims *SignedDetails, msg string) {
 
 	token, err := jwt.ParseWithClaims(
 		signedToken,
@@ -97,19 +154,27 @@ func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 		},
 	)
 
-	// if the tokin if invalid
-	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
-		msg = fmt.Sprintf("the token is valid")
+	// if the token is invalid
+	if err != nil {
 		msg = err.Error()
-		return
+		return nil, msg
+	}
+
+	claims, ok := token.Claims.(*SignedDetails)
+	if !ok || !token.Valid {
+		msg = fmt.Sprintf("the token is invalid")
+		return nil, msg
+	}
+
+	if claims.Token_type != tokenType {
+		msg = fmt.Sprintf("expected a %s token", tokenType)
+		return nil, msg
 	}
 
 	// if token is expired
 	if claims.ExpiresAt < time.Now().Local().Unix() {
 		msg = fmt.Sprintf("token is expired")
-		msg = err.Error()
-		return
+		return nil, msg
 	}
 
 	return claims, msg
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func testUser(uid string) models.User {
+	email, firstName, lastName := "test@example.com", "Test", "User"
+	return models.User{
+		Email:      &email,
+		First_name: &firstName,
+		Last_name:  &lastName,
+		User_id:    uid,
+		UserType:   models.RoleServer,
+	}
+}
+
+func TestValidateTokenChecksTypeAndRevocation(t *testing.T) {
+	sessions := repository.NewMemoryStore().Sessions
+
+	token, refreshToken, err := IssueTokens(sessions, testUser("user-1"))
+	assert.NoError(t, err)
+
+	claims, msg := ValidateToken(sessions, token)
+	assert.Empty(t, msg)
+	assert.Equal(t, models.RoleServer, claims.User_type)
+
+	// a refresh token is not an access token
+	_, msg = ValidateToken(sessions, refreshToken)
+	assert.NotEmpty(t, msg)
+
+	refreshClaims, msg := ValidateRefreshToken(refreshToken)
+	assert.Empty(t, msg)
+	assert.Equal(t, claims.Session_id, refreshClaims.Session_id)
+
+	assert.NoError(t, sessions.RevokeSession(context.Background(), claims.Session_id))
+	_, msg = ValidateToken(sessions, token)
+	assert.Equal(t, "the token has been revoked", msg)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	sessions := repository.NewMemoryStore().Sessions
+
+	first, _, _ := IssueTokens(sessions, testUser("user-1"))
+	second, _, _ := IssueTokens(sessions, testUser("user-1"))
+	other, _, _ := IssueTokens(sessions, testUser("user-2"))
+
+	assert.NoError(t, sessions.RevokeUserSessions(context.Background(), "user-1"))
+
+	_, msg := ValidateToken(sessions, first)
+	assert.NotEmpty(t, msg)
+	_, msg = ValidateToken(sessions, second)
+	assert.NotEmpty(t, msg)
+	_, msg = ValidateToken(sessions, other)
+	assert.Empty(t, msg)
+}
+
+func TestRotateRefreshTokenOnlyOnce(t *testing.T) {
+	sessions := repository.NewMemoryStore().Sessions
+	ctx := context.Background()
+
+	_, refreshToken, _ := IssueTokens(sessions, testUser("user-1"))
+	claims, _ := ValidateRefreshToken(refreshToken)
+
+	rotated, err := sessions.RotateRefreshToken(ctx, claims.Session_id, claims.Id, "next", RefreshTokenExpiry())
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+
+	// replaying the same refresh token loses the compare-and-swap
+	rotated, err = sessions.RotateRefreshToken(ctx, claims.Session_id, claims.Id, "other", RefreshTokenExpiry())
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+)
+
+// PrepareVariants rounds the prices of the variants of a food. It returns a
+// message when two variants share a name, as items name the variant they are.
+func PrepareVariants(variants []models.FoodVariant) ([]models.FoodVariant, string) {
+	names := map[string]bool{}
+	for i := range variants {
+		name := strings.ToLower(variants[i].Name)
+		if names[name] {
+			return nil, fmt.Sprintf("variant %q is listed twice", variants[i].Name)
+		}
+		names[name] = true
+		price := roundCents(*variants[i].Price)
+		variants[i].Price = &price
+	}
+	return variants, ""
+}
+
+// VariantPrice returns the variant of food an item is ordered in, named as on
+// the food, and its price. A food with variants is only ordered in one of
+// them, a food without is ordered at its price. It returns a message when the
+// variant does not fit the food.
+func VariantPrice(food models.Food, variant *string) (*string, float64, string) {
+	if len(food.Variants) == 0 {
+		if variant != nil && *variant != "" {
+			return nil, 0, "the food has no variants"
+		}
+		var price float64
+		if food.Price != nil {
+			price = *food.Price
+		}
+		return nil, price, ""
+	}
+
+	if variant == nil || *variant == "" {
+		names := make([]string, 0, len(food.Variants))
+		for _, v := range food.Variants {
+			names = append(names, v.Name)
+		}
+		return nil, 0, "pick a variant: " + strings.Join(names, ", ")
+	}
+	for _, v := range food.Variants {
+		if strings.EqualFold(v.Name, *variant) {
+			name := v.Name
+			return &name, *v.Price, ""
+		}
+	}
+	return nil, 0, fmt.Sprintf("the food has no variant %q", *variant)
+}
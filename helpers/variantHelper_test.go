@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantPrice(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+	text := func(s string) *string { return &s }
+
+	variants, msg := PrepareVariants([]models.FoodVariant{{Name: "Half", Price: float(6.499)}, {Name: "Full", Price: float(11)}})
+	assert.Empty(t, msg)
+	assert.Equal(t, 6.5, *variants[0].Price)
+	_, msg = PrepareVariants([]models.FoodVariant{{Name: "Half", Price: float(6)}, {Name: "half", Price: float(7)}})
+	assert.NotEmpty(t, msg)
+
+	pasta := models.Food{Price: float(11), Variants: variants}
+	name, price, msg := VariantPrice(pasta, text("half"))
+	assert.Empty(t, msg)
+	assert.Equal(t, "Half", *name)
+	assert.Equal(t, 6.5, price)
+
+	_, _, msg = VariantPrice(pasta, nil)
+	assert.Equal(t, "pick a variant: Half, Full", msg)
+	_, _, msg = VariantPrice(pasta, text("Large"))
+	assert.NotEmpty(t, msg)
+
+	burger := models.Food{Price: float(10)}
+	name, price, msg = VariantPrice(burger, nil)
+	assert.Empty(t, msg)
+	assert.Nil(t, name)
+	assert.Equal(t, 10.0, price)
+	_, _, msg = VariantPrice(burger, text("Large"))
+	assert.NotEmpty(t, msg)
+}
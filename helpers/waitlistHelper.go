@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+var waitlistConfig = config.Default().Waitlist
+
+// ConfigureWaitlist sets how waits are estimated.
+func ConfigureWaitlist(cfg config.WaitlistConfig) {
+	waitlistConfig = cfg
+}
+
+// TurnTimeSamples is how many recent turn times TurnTime looks at.
+func TurnTimeSamples() int {
+	return waitlistConfig.TurnTimeSamples
+}
+
+// TurnTime is the median of the turn times, how long parties keep a table,
+// or the configured default when there are none.
+func TurnTime(turns []time.Duration) time.Duration {
+	if len(turns) == 0 {
+		return waitlistConfig.DefaultTurnTime
+	}
+	sorted := append([]time.Duration(nil), turns...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// WaitTable is a table as far as waits go: the parties it takes and when it
+// is expected to be free, the zero time if it is free now.
+type WaitTable struct {
+	Min_party int
+	Max_party int
+	Free_at   time.Time
+}
+
+// EstimateWaits returns the wait of each party in the queue, by party size in
+// the order they arrived. Parties are seated in turn at the table seating
+// them that frees up first, and hold it for turn. A party no table seats
+// gets -1.
+func EstimateWaits(now time.Time, tables []WaitTable, parties []int, turn time.Duration) []time.Duration {
+	freeAt := make([]time.Time, len(tables))
+	for i, table := range tables {
+		freeAt[i] = table.Free_at
+		if freeAt[i].Before(now) {
+			freeAt[i] = now
+		}
+	}
+
+	waits := make([]time.Duration, len(parties))
+	for p, size := range parties {
+		best := -1
+		for i, table := range tables {
+			if size < table.Min_party || size > table.Max_party {
+				continue
+			}
+			// the smallest of the tables freeing up first
+			if best < 0 || freeAt[i].Before(freeAt[best]) || (freeAt[i].Equal(freeAt[best]) && table.Max_party < tables[best].Max_party) {
+				best = i
+			}
+		}
+		if best < 0 {
+			waits[p] = -1
+			continue
+		}
+		waits[p] = freeAt[best].Sub(now)
+		freeAt[best] = freeAt[best].Add(turn)
+	}
+	return waits
+}
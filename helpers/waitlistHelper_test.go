@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTurnTime(t *testing.T) {
+	ConfigureWaitlist(config.WaitlistConfig{DefaultTurnTime: time.Hour, TurnTimeSamples: 10})
+	defer ConfigureWaitlist(config.Default().Waitlist)
+
+	assert.Equal(t, time.Hour, TurnTime(nil))
+	assert.Equal(t, 40*time.Minute, TurnTime([]time.Duration{50 * time.Minute, 30 * time.Minute, 40 * time.Minute}))
+	assert.Equal(t, 45*time.Minute, TurnTime([]time.Duration{50 * time.Minute, 30 * time.Minute, 40 * time.Minute, 3 * time.Hour}))
+}
+
+func TestEstimateWaits(t *testing.T) {
+	now := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	tables := []WaitTable{
+		{Min_party: 1, Max_party: 2, Free_at: now.Add(10 * time.Minute)},
+		{Min_party: 3, Max_party: 4, Free_at: now.Add(30 * time.Minute)},
+		{Min_party: 1, Max_party: 2},
+	}
+
+	waits := EstimateWaits(now, tables, []int{2, 2, 4, 2, 6}, time.Hour)
+
+	assert.Equal(t, []time.Duration{
+		0,                // the free table for two
+		10 * time.Minute, // the other table for two
+		30 * time.Minute, // only the table for four seats four
+		time.Hour,        // the first table for two, once more
+		-1,               // no table seats six
+	}, waits)
+}
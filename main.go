@@ -1,36 +1,94 @@
 package main
 
 import (
+	"log"
+	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/controllers"
 	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/floor"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
 	middleware "github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/printing"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 	routes "github.com/rkmangalp/Restaurant_Management/routes"
-
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/rkmangalp/Restaurant_Management/webhooks"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8000"
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	if cfg.Log.Level == config.LogDebug {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	helpers.Configure(cfg.JWT)
+	helpers.ConfigureTax(cfg.Tax)
+	helpers.ConfigureServiceCharge(cfg.ServiceCharge)
+	helpers.ConfigureReceipt(cfg.Receipt)
+	helpers.ConfigureKitchen(cfg.Kitchen)
+	helpers.ConfigureReservations(cfg.Reservations)
+	helpers.ConfigureWaitlist(cfg.Waitlist)
+	helpers.ConfigureGuest(cfg.Guest)
+	helpers.ConfigureMenus(cfg.Menus)
+	controllers.QueryTimeout = cfg.Mongo.QueryTimeout
+
+	client, err := database.DBinstance(cfg.Mongo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := repository.NewMongoStore(client, client.Database(cfg.Mongo.Database))
+
+	spooler, err := printing.NewSpooler(cfg.Printing)
+	if err != nil {
+		log.Fatal(err)
 	}
+	dispatcher := webhooks.NewDispatcher(cfg.Webhooks, store, controllers.Events)
+	floor.NewTracker(store, controllers.Events)
 
 	router := gin.New()
-	router.Use(gin.Logger())
-	routes.UserRoutes(router)
-	router.Use(middleware.Authentication())
+	// request lines are info level, warn and error only log failures
+	if cfg.Log.Level == config.LogDebug || cfg.Log.Level == config.LogInfo {
+		router.Use(gin.Logger())
+	}
+	router.Use(gin.Recovery())
+	router.Use(middleware.CORS(cfg.CORS))
+	routes.UserRoutes(router, store)
+	routes.GuestRoutes(router, store)
+	router.Use(middleware.Authentication(store.Sessions))
 	router.SetTrustedProxies(nil)
 
-	routes.FoodRoutes(router)
-	routes.MenuRoutes(router)
-	routes.TableRoutes(router)
-	routes.OrderRoutes(router)
-	routes.OrderItemRoutes(router)
-	routes.InvoiceRoutes(router)
+	routes.FoodRoutes(router, store)
+	routes.MenuRoutes(router, store)
+	routes.ComboRoutes(router, store)
+	routes.TableRoutes(router, store)
+	routes.OrderRoutes(router, store)
+	routes.OrderItemRoutes(router, store)
+	routes.InvoiceRoutes(router, store)
+	routes.CouponRoutes(router, store)
+	routes.AdjustmentRoutes(router, store)
+	routes.PrintRoutes(router, store, spooler)
+	routes.KitchenRoutes(router, store)
+	routes.EventRoutes(router)
+	routes.WebhookRoutes(router, store, dispatcher)
+	routes.ReservationRoutes(router, store)
+	routes.WaitlistRoutes(router, store)
+	routes.GuestOrderRoutes(router, store)
+	routes.ReportRoutes(router, store)
 
-	router.Run(":" + port)
+	server := &http.Server{
+		Addr:         ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	log.Fatal(server.ListenAndServe())
 }
@@ -6,28 +6,77 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func Authentication() gin.HandlerFunc {
+func Authentication(sessions repository.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientToken := c.Request.Header.Get("token")
 		if clientToken == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("no autherization header provided")})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("no autherization header provided")})
 			c.Abort()
 			return
 		}
 
-		claims, err := helpers.ValidateToken(clientToken)
+		claims, err := helpers.ValidateToken(sessions, clientToken)
 		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
 			c.Abort()
 			return
 		}
 
-		c.Set("email", claims.Email)
-		c.Set("first_name", claims.First_name)
-		c.Set("last_name", claims.Last_name)
-		c.Set("uid", claims.Uid)
+		setClaims(c, claims)
+	}
+}
+
+// OptionalAuthentication sets the caller details when a token is sent, but lets
+// anonymous requests through. Handlers decide what an anonymous caller may do.
+func OptionalAuthentication(sessions repository.SessionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientToken := c.Request.Header.Get("token")
+		if clientToken == "" {
+			return
+		}
+
+		claims, err := helpers.ValidateToken(sessions, clientToken)
+		if err != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+			c.Abort()
+			return
+		}
+
+		setClaims(c, claims)
+	}
+}
+
+func setClaims(c *gin.Context, claims *helpers.SignedDetails) {
+	c.Set("email", claims.Email)
+	c.Set("first_name", claims.First_name)
+	c.Set("last_name", claims.Last_name)
+	c.Set("uid", claims.Uid)
+	c.Set("user_type", claims.User_type)
+	c.Set("session_id", claims.Session_id)
+}
+
+// GuestAuthentication admits guest tokens only, and sets the table and guest
+// session they were issued for.
+func GuestAuthentication() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientToken := c.Request.Header.Get("token")
+		if clientToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no autherization header provided"})
+			c.Abort()
+			return
+		}
+
+		claims, err := helpers.ValidateGuestToken(clientToken)
+		if err != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+			c.Abort()
+			return
+		}
 
+		c.Set("table_id", claims.Table_id)
+		c.Set("guest_session_id", claims.Id)
 	}
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy lists the roles allowed on each route. Keys are the HTTP method and the
+// route template as registered in gin, e.g. "PATCH /invoices/:invoice_id".
+type Policy map[string][]string
+
+// Authorize must run after Authentication. Routes missing from the policy are
+// denied, so a new route has to be given roles before anyone can call it.
+func Authorize(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userType := c.GetString("user_type")
+
+		roles, ok := policy[c.Request.Method+" "+c.FullPath()]
+		if !ok || !hasRole(roles, userType) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you are not allowed to access this resource"})
+			c.Abort()
+			return
+		}
+	}
+}
+
+func hasRole(roles []string, userType string) bool {
+	for _, role := range roles {
+		if role == userType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// adjustment types
+const (
+	// AdjustmentVoid takes an item off the bill that should not have been on it
+	AdjustmentVoid = "VOID"
+	// AdjustmentComp gives an item for free, it stays on the bill at no charge
+	AdjustmentComp = "COMP"
+	// AdjustmentRefund pays money back on an invoice
+	AdjustmentRefund = "REFUND"
+)
+
+// adjustment statuses, only APPROVED adjustments change a bill
+const (
+	AdjustmentPending  = "PENDING"
+	AdjustmentApproved = "APPROVED"
+	AdjustmentRejected = "REJECTED"
+)
+
+// reason codes, reported on
+const (
+	ReasonCustomerComplaint = "CUSTOMER_COMPLAINT"
+	ReasonKitchenError      = "KITCHEN_ERROR"
+	ReasonServerError       = "SERVER_ERROR"
+	ReasonQuality           = "QUALITY"
+	ReasonLongWait          = "LONG_WAIT"
+	ReasonHospitality       = "HOSPITALITY"
+	ReasonOther             = "OTHER"
+)
+
+// Adjustment is a void, comp or refund. The order item or payment it corrects
+// is never changed; an approved adjustment is a reversing entry of Amount
+// against it. Staff request adjustments, a manager approves or rejects them.
+type Adjustment struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Adjustment_id string             `json:"adjustment_id"`
+	Type          *string            `json:"type" validate:"required,eq=VOID|eq=COMP|eq=REFUND"`
+	Order_id      string             `json:"order_id"`
+	Order_item_id *string            `json:"order_item_id"`
+	Invoice_id    *string            `json:"invoice_id"`
+	Amount        *float64           `json:"amount" validate:"omitempty,gt=0"`
+	Refund_method *string            `json:"refund_method" validate:"omitempty,eq=CARD|eq=CASH"`
+	Reason_code   *string            `json:"reason_code" validate:"required,eq=CUSTOMER_COMPLAINT|eq=KITCHEN_ERROR|eq=SERVER_ERROR|eq=QUALITY|eq=LONG_WAIT|eq=HOSPITALITY|eq=OTHER"`
+	Note          *string            `json:"note" validate:"omitempty,max=500"`
+	Status        string             `json:"status"`
+	Requested_by  string             `json:"requested_by"`
+	Requested_at  time.Time          `json:"requested_at"`
+	Decided_by    *string            `json:"decided_by"`
+	Decided_at    *time.Time         `json:"decided_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Combo is a bundle of foods sold at one price, a burger with fries and a
+// drink or a tasting menu of set courses. Every slot of the combo is filled
+// with one food when it is ordered.
+type Combo struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Combo_id   string             `json:"combo_id"`
+	Name       *string            `json:"name" validate:"required,min=2,max=100"`
+	Price      *float64           `json:"price" validate:"required,min=0"`
+	Slots      []ComboSlot        `json:"slots" validate:"required,min=1,dive"`
+	Created_at time.Time          `json:"created_at"`
+	Updated_at time.Time          `json:"updated_at"`
+}
+
+// ComboSlot is one part of a combo, filled with one of Food_ids or with any
+// food of Category. A slot offering a single food and no category is fixed,
+// it needs no choice.
+type ComboSlot struct {
+	Slot_id  string   `json:"slot_id"`
+	Name     string   `json:"name" validate:"required,max=100"`
+	Food_ids []string `json:"food_ids"`
+	Category *string  `json:"category"`
+}
+
+// ComboOrder is a combo as it is ordered, with the food picked for its slots.
+type ComboOrder struct {
+	Combo_id *string       `json:"combo_id" validate:"required"`
+	Quantity *int          `json:"quantity" validate:"required,min=1"`
+	Choices  []ComboChoice `json:"choices" validate:"omitempty,dive"`
+}
+
+// ComboChoice is the food picked for a slot of a combo, in the variant and
+// with the modifiers the guest asked for. Fixed slots need no choice.
+type ComboChoice struct {
+	Slot_id   string             `json:"slot_id" validate:"required"`
+	Food_id   *string            `json:"food_id"`
+	Variant   *string            `json:"variant" validate:"omitempty,max=50"`
+	Modifiers []SelectedModifier `json:"modifiers" validate:"omitempty,dive"`
+}
+
+// OrderItemCombo ties an order item to the combo it was ordered in. The items
+// of one ordered combo share Line_id, and the names are copied from the combo
+// so later menu changes leave the bill alone.
+type OrderItemCombo struct {
+	Combo_id   string `json:"combo_id"`
+	Combo_name string `json:"combo_name"`
+	Line_id    string `json:"line_id"`
+	Slot_name  string `json:"slot_name"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// coupon types
+const (
+	// CouponPercentage takes Value percent off the eligible items
+	CouponPercentage = "PERCENTAGE"
+	// CouponFixed takes Value off the eligible items, at most their total
+	CouponFixed = "FIXED"
+	// CouponBuyXGetY makes Get_quantity of every Buy_quantity+Get_quantity
+	// eligible items free, the cheapest ones first. Value, when set, is the
+	// percentage taken off those items instead of 100.
+	CouponBuyXGetY = "BUY_X_GET_Y"
+)
+
+type Coupon struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Coupon_id    string             `json:"coupon_id"`
+	Code         *string            `json:"code" validate:"required,min=3,max=32"`
+	Type         *string            `json:"type" validate:"required,eq=PERCENTAGE|eq=FIXED|eq=BUY_X_GET_Y"`
+	Value        *float64           `json:"value" validate:"omitempty,gt=0"`
+	Category     *string            `json:"category"`
+	Buy_quantity *int               `json:"buy_quantity" validate:"omitempty,min=1"`
+	Get_quantity *int               `json:"get_quantity" validate:"omitempty,min=1"`
+	Min_spend    *float64           `json:"min_spend" validate:"omitempty,gte=0"`
+	Valid_from   *time.Time         `json:"valid_from"`
+	Valid_until  *time.Time         `json:"valid_until"`
+	Max_uses     *int               `json:"max_uses" validate:"omitempty,min=1"`
+	Used_count   int                `json:"used_count"`
+	Created_at   time.Time          `json:"created_at"`
+	Updated_at   time.Time          `json:"updated_at"`
+}
+
+// ValidAt reports whether t falls inside the validity window of the coupon.
+func (coupon Coupon) ValidAt(t time.Time) bool {
+	if coupon.Valid_from != nil && t.Before(*coupon.Valid_from) {
+		return false
+	}
+	if coupon.Valid_until != nil && t.After(*coupon.Valid_until) {
+		return false
+	}
+	return true
+}
+
+// UsedUp reports whether the coupon reached its usage limit.
+func (coupon Coupon) UsedUp() bool {
+	return coupon.Max_uses != nil && coupon.Used_count >= *coupon.Max_uses
+}
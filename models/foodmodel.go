@@ -16,4 +16,16 @@ type Food struct {
 	Updated_at time.Time          `json:"updated_at"`
 	Food_id    string             `json:"food_id"`
 	Menu_id    *string            `json:"menu_id"`
+	// Variants are the portions or sizes the food is ordered in, each with
+	// its own price. A food without variants is ordered at Price.
+	Variants []FoodVariant `json:"variants" validate:"omitempty,dive"`
+	// Modifier_groups are the choices offered with the food
+	Modifier_groups []ModifierGroup `json:"modifier_groups" validate:"omitempty,dive"`
+}
+
+// FoodVariant is a portion or size of a food, a half portion or a large
+// pizza, with its own price.
+type FoodVariant struct {
+	Name  string   `json:"name" validate:"required,max=50"`
+	Price *float64 `json:"price" validate:"required,min=0"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// guest order statuses
+const (
+	GuestOrderCart      = "CART"
+	GuestOrderSubmitted = "SUBMITTED"
+	GuestOrderConfirmed = "CONFIRMED"
+	GuestOrderRejected  = "REJECTED"
+)
+
+// GuestOrderItem is a food a guest put in their cart.
+type GuestOrderItem struct {
+	Item_id   string             `json:"item_id"`
+	Food_id   *string            `json:"food_id" validate:"required"`
+	Quantity  *int               `json:"quantity" validate:"required,min=1"`
+	Variant   *string            `json:"variant" validate:"omitempty,max=50"`
+	Modifiers []SelectedModifier `json:"modifiers" validate:"omitempty,dive"`
+}
+
+// GuestOrder is what a guest ordered at their table with its code. It starts
+// as the cart of their session and waits for the staff once submitted; only a
+// confirmed guest order becomes an order, Order_id, and reaches the kitchen.
+type GuestOrder struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Guest_order_id string             `json:"guest_order_id"`
+	Table_id       string             `json:"table_id"`
+	Session_id     string             `json:"session_id"`
+	Items          []GuestOrderItem   `json:"items"`
+	Note           *string            `json:"note" validate:"omitempty,max=500"`
+	Status         string             `json:"status"`
+	Order_id       *string            `json:"order_id"`
+	Reviewed_by    *string            `json:"reviewed_by"`
+	Reject_reason  *string            `json:"reject_reason"`
+	Created_at     time.Time          `json:"created_at"`
+	Updated_at     time.Time          `json:"updated_at"`
+	Submitted_at   *time.Time         `json:"submitted_at"`
+	Reviewed_at    *time.Time         `json:"reviewed_at"`
+}
@@ -10,8 +10,9 @@ type Invoice struct {
 	ID               primitive.ObjectID `bson:"_id"`
 	Invoice_id       string             `json:"invoice_id"`
 	Order_id         string             `json:"order_id"`
-	Payment_method   *string            `json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
-	Payment_status   *string            `json:"payement_status" validate:"required,eq=PENDING|eq=PAID"`
+	Payment_method   *string            `json:"payment_method" validate:"omitempty,eq=CARD|eq=CASH"`
+	Payment_status   *string            `json:"payement_status" validate:"required,eq=PENDING|eq=PARTIALLY_PAID|eq=PAID"`
+	Amount_paid      float64            `json:"amount_paid"`
 	Payment_due_date time.Time          `json:"payment_due-date"`
 	Created_at       time.Time          `json:"created_at"`
 	Updated_at       time.Time          `json:"updated_at"`
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// kitchen ticket statuses, a BUMPED ticket is off the kitchen screens
+const (
+	TicketNew        = "NEW"
+	TicketInProgress = "IN_PROGRESS"
+	TicketReady      = "READY"
+	TicketBumped     = "BUMPED"
+)
+
+// kitchen screen actions on a ticket
+const (
+	TicketStart  = "start"
+	TicketMark   = "ready"
+	TicketBump   = "bump"
+	TicketRecall = "recall"
+)
+
+// ActiveTicketStatuses are the statuses shown on the kitchen screens.
+var ActiveTicketStatuses = []string{TicketNew, TicketInProgress, TicketReady}
+
+// KitchenTicket is what one kitchen station has to prepare for an order. It is
+// written when the items are ordered and worked through on the kitchen screens.
+type KitchenTicket struct {
+	ID           primitive.ObjectID  `bson:"_id"`
+	Ticket_id    string              `json:"ticket_id"`
+	Order_id     string              `json:"order_id"`
+	Station      string              `json:"station"`
+	Table_number *int                `json:"table_number"`
+	Items        []KitchenTicketItem `json:"items"`
+	Status       string              `json:"status"`
+	Created_at   time.Time           `json:"created_at"`
+	Started_at   *time.Time          `json:"started_at"`
+	Ready_at     *time.Time          `json:"ready_at"`
+	Bumped_at    *time.Time          `json:"bumped_at"`
+	Updated_at   time.Time           `json:"updated_at"`
+}
+
+type KitchenTicketItem struct {
+	Order_item_id string   `json:"order_item_id"`
+	Food_name     string   `json:"food_name"`
+	Quantity      int      `json:"quantity"`
+	Modifiers     []string `json:"modifiers"`
+}
+
+// ticketActions maps an action to the statuses it can be taken from and the
+// status it leads to.
+var ticketActions = map[string]struct {
+	from []string
+	to   string
+}{
+	TicketStart:  {from: []string{TicketNew}, to: TicketInProgress},
+	TicketMark:   {from: []string{TicketNew, TicketInProgress}, to: TicketReady},
+	TicketBump:   {from: ActiveTicketStatuses, to: TicketBumped},
+	TicketRecall: {from: []string{TicketBumped}, to: TicketReady},
+}
+
+// TicketTransition returns the status action moves a ticket with status from
+// to. known is false for unknown actions, allowed is false when the action
+// cannot be taken from that status.
+func TicketTransition(action string, from string) (to string, known bool, allowed bool) {
+	move, ok := ticketActions[action]
+	if !ok {
+		return "", false, false
+	}
+	for _, status := range move.from {
+		if status == from {
+			return move.to, true, true
+		}
+	}
+	return move.to, true, false
+}
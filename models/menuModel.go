@@ -15,4 +15,18 @@ type Menu struct {
 	Created_at time.Time          `json:"created_at"`
 	Updated_at time.Time          `json:"updated_at"`
 	Menu_id    string             `json:"menu_id"`
+	// Schedule is when the menu is served between Start_date and End_date.
+	// A menu without a schedule is served all day.
+	Schedule []MenuWindow `json:"schedule" validate:"omitempty,dive"`
+}
+
+// MenuWindow is a time of day the menu is served on some days, lunch from
+// 11:30 to 15:00 on weekdays. Days are MON to SUN, and HOL for the holidays
+// of the restaurant: on a holiday only the windows listing HOL apply. A window
+// without days applies every day. A window ending before it starts runs past
+// midnight into the next day.
+type MenuWindow struct {
+	Days  []string `json:"days" validate:"omitempty,dive,oneof=MON TUE WED THU FRI SAT SUN HOL"`
+	Start string   `json:"start" validate:"required,datetime=15:04"`
+	End   string   `json:"end" validate:"required,datetime=15:04"`
 }
@@ -0,0 +1,33 @@
+package models
+
+// ModifierGroup is a choice offered with a food, like the sauce of a burger or
+// its extras. Guests pick from Min_select to Max_select of its modifiers, and
+// at least one from a Required group even when Min_select is 0. A Max_select
+// of 0 puts no limit on the picks.
+type ModifierGroup struct {
+	Group_id   string     `json:"group_id"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	Required   bool       `json:"required"`
+	Min_select int        `json:"min_select" validate:"min=0"`
+	Max_select int        `json:"max_select" validate:"min=0"`
+	Modifiers  []Modifier `json:"modifiers" validate:"required,min=1,dive"`
+}
+
+// Modifier is one option of a group, "no onions" or "extra cheese", and what
+// it adds to the price of the food. Price_delta may be negative.
+type Modifier struct {
+	Modifier_id string  `json:"modifier_id"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	Price_delta float64 `json:"price_delta"`
+}
+
+// SelectedModifier is a modifier picked for an order item. The names and the
+// price delta are copied from the food when the item is ordered, so later menu
+// changes leave the bill alone.
+type SelectedModifier struct {
+	Group_id    string  `json:"group_id" validate:"required"`
+	Modifier_id string  `json:"modifier_id" validate:"required"`
+	Group_name  string  `json:"group_name"`
+	Name        string  `json:"name"`
+	Price_delta float64 `json:"price_delta"`
+}
@@ -3,16 +3,54 @@ package models
 import (
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type OrderItem struct {
-	ID            primitive.ObjectID `bson:"_id"`
-	Quantity      *string            `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
-	Unit_price    *float64           `json:"unit_price" validate:"required"`
-	Created_at    time.Time          `json:"created_at"`
-	Updated_at    time.Time          `json:"updated_at"`
-	Food_id       *string            `json:"food_id" validate:"required"`
-	Order_item_id string             `json:"order_item_id"`
-	Order_id      string             `json:"order_id" validate:"required"`
+	ID       primitive.ObjectID `bson:"_id"`
+	Quantity *int               `json:"quantity" validate:"required,min=1"`
+	// Variant is the portion or size ordered, one of the variants of the food
+	Variant *string `json:"variant" validate:"omitempty,max=50"`
+	// Unit_price is the price of one item with its variant and modifiers. It
+	// is set from the food when the item is ordered.
+	Unit_price    *float64  `json:"unit_price"`
+	Created_at    time.Time `json:"created_at"`
+	Updated_at    time.Time `json:"updated_at"`
+	Food_id       *string   `json:"food_id" validate:"required"`
+	Order_item_id string    `json:"order_item_id"`
+	Order_id      string    `json:"order_id" validate:"required"`
+	// Modifiers are the modifiers picked from the groups of the food
+	Modifiers []SelectedModifier `json:"modifiers" validate:"omitempty,dive"`
+	// Combo is set on the items of an ordered combo, whose unit prices are
+	// their share of the combo price
+	Combo *OrderItemCombo `json:"combo"`
+}
+
+// UnmarshalBSON reads order items saved while the quantity was the size S, M
+// or L as one item of that size.
+func (o *OrderItem) UnmarshalBSON(data []byte) error {
+	type plain OrderItem
+
+	if quantity, err := bson.Raw(data).LookupErr("quantity"); err != nil || quantity.Type != bsontype.String {
+		return bson.Unmarshal(data, (*plain)(o))
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for i, field := range doc {
+		if field.Key == "quantity" {
+			doc[i].Value = 1
+			doc = append(doc, bson.E{Key: "variant", Value: field.Value})
+			break
+		}
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, (*plain)(o))
 }
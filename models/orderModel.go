@@ -6,11 +6,88 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// order statuses, see orderTransitions for the allowed moves
+const (
+	OrderDraft     = "DRAFT"
+	OrderPlaced    = "PLACED"
+	OrderInKitchen = "IN_KITCHEN"
+	OrderReady     = "READY"
+	OrderServed    = "SERVED"
+	OrderPaid      = "PAID"
+	OrderCancelled = "CANCELLED"
+)
+
+// ClosedOrderStatuses are the statuses of orders whose party left the table.
+var ClosedOrderStatuses = []string{OrderPaid, OrderCancelled}
+
+// Order is what a table ordered. Server_id is the user looking after it, the
+// tips paid on its invoice go to them.
 type Order struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Order_date time.Time          `json:"order_date" validate:"required"`
-	Created_at time.Time          `json:"created_at"`
-	Updated_at time.Time          `json:"updated_at"`
-	Order_id   string             `json:"order_id"`
-	Table_id   *string            `json:"table_id" validate:"required"`
+	ID             primitive.ObjectID  `bson:"_id"`
+	Order_date     time.Time           `json:"order_date" validate:"required"`
+	Created_at     time.Time           `json:"created_at"`
+	Updated_at     time.Time           `json:"updated_at"`
+	Order_id       string              `json:"order_id"`
+	Table_id       *string             `json:"table_id" validate:"required"`
+	Status         *string             `json:"status" validate:"omitempty,eq=DRAFT|eq=PLACED|eq=IN_KITCHEN|eq=READY|eq=SERVED|eq=PAID|eq=CANCELLED"`
+	Status_history []OrderStatusChange `json:"status_history"`
+	Coupon_id      *string             `json:"coupon_id"`
+	Server_id      *string             `json:"server_id"`
+}
+
+// OrderStatusChange records who moved an order to another status and when.
+type OrderStatusChange struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Changed_by string    `json:"changed_by"`
+	Changed_at time.Time `json:"changed_at"`
+}
+
+// orderTransitions maps a status to the statuses it may move to, and for each
+// move the roles allowed to make it.
+var orderTransitions = map[string]map[string][]string{
+	OrderDraft: {
+		OrderPlaced:    {RoleAdmin, RoleManager, RoleServer},
+		OrderCancelled: {RoleAdmin, RoleManager, RoleServer},
+	},
+	OrderPlaced: {
+		OrderInKitchen: {RoleAdmin, RoleManager, RoleServer, RoleKitchen},
+		OrderCancelled: {RoleAdmin, RoleManager, RoleServer},
+	},
+	OrderInKitchen: {
+		OrderReady:     {RoleAdmin, RoleManager, RoleKitchen},
+		OrderCancelled: {RoleAdmin, RoleManager},
+	},
+	OrderReady: {
+		OrderServed: {RoleAdmin, RoleManager, RoleServer},
+	},
+	OrderServed: {
+		OrderPaid: {RoleAdmin, RoleManager, RoleCashier, RoleServer},
+	},
+	OrderPaid:      {},
+	OrderCancelled: {},
+}
+
+// CurrentStatus returns the order status. Orders stored before statuses existed
+// were always placed straight away, so they count as PLACED.
+func (o Order) CurrentStatus() string {
+	if o.Status == nil || *o.Status == "" {
+		return OrderPlaced
+	}
+	return *o.Status
+}
+
+// CanTransitionOrder reports whether the move is in the transition table, and
+// whether userType is allowed to make it.
+func CanTransitionOrder(from string, to string, userType string) (legal bool, permitted bool) {
+	roles, ok := orderTransitions[from][to]
+	if !ok {
+		return false, false
+	}
+	for _, role := range roles {
+		if role == userType {
+			return true, true
+		}
+	}
+	return true, false
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to, role   string
+		legal, permitted bool
+	}{
+		{OrderDraft, OrderPlaced, RoleServer, true, true},
+		{OrderPlaced, OrderInKitchen, RoleKitchen, true, true},
+		{OrderInKitchen, OrderReady, RoleKitchen, true, true},
+		{OrderInKitchen, OrderReady, RoleServer, true, false},
+		{OrderReady, OrderServed, RoleServer, true, true},
+		{OrderServed, OrderPaid, RoleCashier, true, true},
+		{OrderPlaced, OrderCancelled, RoleServer, true, true},
+		{OrderInKitchen, OrderCancelled, RoleServer, true, false},
+
+		// skipping steps or leaving a final status is never allowed
+		{OrderDraft, OrderServed, RoleAdmin, false, false},
+		{OrderPlaced, OrderPaid, RoleAdmin, false, false},
+		{OrderPaid, OrderPlaced, RoleAdmin, false, false},
+		{OrderCancelled, OrderPlaced, RoleAdmin, false, false},
+		{OrderReady, "UNKNOWN", RoleAdmin, false, false},
+	}
+
+	for _, tt := range tests {
+		legal, permitted := CanTransitionOrder(tt.from, tt.to, tt.role)
+		assert.Equal(t, tt.legal, legal, "%s -> %s legal", tt.from, tt.to)
+		assert.Equal(t, tt.permitted, permitted, "%s -> %s as %s", tt.from, tt.to, tt.role)
+	}
+}
+
+func TestCurrentStatusDefaultsToPlaced(t *testing.T) {
+	assert.Equal(t, OrderPlaced, Order{}.CurrentStatus())
+
+	status := OrderReady
+	assert.Equal(t, OrderReady, Order{Status: &status}.CurrentStatus())
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// invoice payment statuses, set from the sum of the payments
+const (
+	PaymentPending       = "PENDING"
+	PaymentPartiallyPaid = "PARTIALLY_PAID"
+	PaymentPaid          = "PAID"
+)
+
+// payment methods
+const (
+	PaymentCard = "CARD"
+	PaymentCash = "CASH"
+)
+
+// Payment is one tender towards an invoice. Amount is what it pays off the
+// invoice and Tip what the guest adds on top, credited to Server_id. For cash
+// Tendered is what the guest handed over and Change_due what goes back.
+// Order_item_ids lists the items paid for when the bill is split by item.
+// Refunds are payments with a negative Amount, written when the refund
+// adjustment Adjustment_id is approved.
+type Payment struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Payment_id     string             `json:"payment_id"`
+	Invoice_id     string             `json:"invoice_id"`
+	Method         *string            `json:"method" validate:"required,eq=CARD|eq=CASH"`
+	Amount         *float64           `json:"amount" validate:"omitempty,gt=0"`
+	Tip            *float64           `json:"tip" validate:"omitempty,gte=0"`
+	Tendered       *float64           `json:"tendered" validate:"omitempty,gt=0"`
+	Change_due     float64            `json:"change_due"`
+	Order_item_ids []string           `json:"order_item_ids"`
+	Server_id      string             `json:"server_id"`
+	Adjustment_id  *string            `json:"adjustment_id"`
+	Created_by     string             `json:"created_by"`
+	Created_at     time.Time          `json:"created_at"`
+}
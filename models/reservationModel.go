@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reservation statuses, BOOKED and SEATED reservations hold their table
+const (
+	ReservationBooked    = "BOOKED"
+	ReservationSeated    = "SEATED"
+	ReservationCancelled = "CANCELLED"
+	ReservationNoShow    = "NO_SHOW"
+)
+
+// HoldingReservationStatuses are the statuses that keep a table taken.
+var HoldingReservationStatuses = []string{ReservationBooked, ReservationSeated}
+
+// Reservation books a table for a party from Starts_at. The table is held
+// until Blocked_until, the expected end plus time to reset the table.
+// Previous_no_shows counts the no-shows on the phone number when booking.
+type Reservation struct {
+	ID                primitive.ObjectID `bson:"_id"`
+	Reservation_id    string             `json:"reservation_id"`
+	Guest_name        *string            `json:"guest_name" validate:"required,min=1,max=100"`
+	Phone             *string            `json:"phone" validate:"required,min=5,max=20"`
+	Party_size        *int               `json:"party_size" validate:"required,min=1"`
+	Starts_at         *time.Time         `json:"starts_at" validate:"required"`
+	Ends_at           time.Time          `json:"ends_at"`
+	Blocked_until     time.Time          `json:"blocked_until"`
+	Table_id          *string            `json:"table_id"`
+	Table_number      *int               `json:"table_number"`
+	Note              *string            `json:"note" validate:"omitempty,max=500"`
+	Status            string             `json:"status"`
+	Previous_no_shows int                `json:"previous_no_shows"`
+	Created_by        string             `json:"created_by"`
+	Created_at        time.Time          `json:"created_at"`
+	Updated_at        time.Time          `json:"updated_at"`
+	Seated_at         *time.Time         `json:"seated_at"`
+	Cancelled_at      *time.Time         `json:"cancelled_at"`
+}
+
+// Holds tells whether the reservation keeps its table taken at some point
+// between from and to.
+func (r Reservation) Holds(from time.Time, to time.Time) bool {
+	if r.Status != ReservationBooked && r.Status != ReservationSeated {
+		return false
+	}
+	return r.Starts_at != nil && r.Starts_at.Before(to) && r.Blocked_until.After(from)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one login. Every access and refresh token carries its Session_id,
+// and the current refresh token id is rotated on each refresh so a replayed
+// refresh token can be detected.
+type Session struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	Session_id       string             `json:"session_id"`
+	User_id          string             `json:"user_id"`
+	Refresh_token_id string             `json:"refresh_token_id"`
+	Revoked          bool               `json:"revoked"`
+	Revoked_at       *time.Time         `json:"revoked_at"`
+	Expires_at       time.Time          `json:"expires_at"`
+	Created_at       time.Time          `json:"created_at"`
+	Updated_at       time.Time          `json:"updated_at"`
+}
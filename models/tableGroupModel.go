@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TableGroup is tables pushed together for a large party. Orders for any of
+// its tables are seated at Primary_table_id, and the tables share its status.
+// Number_of_guests and Max_capacity are the seats and capacity of the tables
+// together.
+type TableGroup struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	Group_id         string             `json:"group_id"`
+	Table_ids        []string           `json:"table_ids"`
+	Primary_table_id string             `json:"primary_table_id"`
+	Number_of_guests int                `json:"number_of_guests"`
+	Max_capacity     int                `json:"max_capacity"`
+	Created_by       string             `json:"created_by"`
+	Created_at       time.Time          `json:"created_at"`
+}
@@ -6,11 +6,75 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// table statuses, kept up to date from the order and invoice events
+const (
+	TableFree            = "FREE"
+	TableSeated          = "SEATED"
+	TableOrdered         = "ORDERED"
+	TableAwaitingPayment = "AWAITING_PAYMENT"
+)
+
+// table shapes on the floor plan
+const (
+	TableRound     = "ROUND"
+	TableSquare    = "SQUARE"
+	TableRectangle = "RECTANGLE"
+)
+
+// Table is a table on the floor. Number_of_guests is the seats it has;
+// Min_capacity and Max_capacity, when set, are the smallest party worth
+// giving it and the most it takes with chairs pulled up. Section, Position_x,
+// Position_y and Shape place it on the floor plan. Status is not set by
+// clients, it follows the orders seated at the table. Group_id is set while
+// the table is merged with others.
 type Table struct {
-	ID               primitive.ObjectID `bson:"_id"`
-	Number_of_guests *int               `json:"number_of_guests" validate:"required"`
-	Table_number     *int               `json:"table_number" validate:"required"`
-	Created_at       time.Time          `json:"created_at"`
-	Updated_at       time.Time          `json:"updated_at"`
-	Table_id         string             `json:"table_id"`
+	ID                primitive.ObjectID `bson:"_id"`
+	Number_of_guests  *int               `json:"number_of_guests" validate:"required"`
+	Table_number      *int               `json:"table_number" validate:"required"`
+	Min_capacity      *int               `json:"min_capacity" validate:"omitempty,min=1"`
+	Max_capacity      *int               `json:"max_capacity" validate:"omitempty,min=1"`
+	Section           *string            `json:"section" validate:"omitempty,max=50"`
+	Position_x        *float64           `json:"position_x"`
+	Position_y        *float64           `json:"position_y"`
+	Shape             *string            `json:"shape" validate:"omitempty,eq=ROUND|eq=SQUARE|eq=RECTANGLE"`
+	Status            string             `json:"status"`
+	Order_id          *string            `json:"order_id"`
+	Status_changed_at *time.Time         `json:"status_changed_at"`
+	Group_id          *string            `json:"group_id"`
+	Created_at        time.Time          `json:"created_at"`
+	Updated_at        time.Time          `json:"updated_at"`
+	Table_id          string             `json:"table_id"`
+}
+
+// Seats tells whether a party of partySize can be given the table.
+func (t Table) Seats(partySize int) bool {
+	return partySize >= t.MinParty() && partySize <= t.MaxParty()
+}
+
+// MinParty is the smallest party the table is given, 1 unless set.
+func (t Table) MinParty() int {
+	if t.Min_capacity != nil {
+		return *t.Min_capacity
+	}
+	return 1
+}
+
+// MaxParty is the largest party the table takes, its seats unless set.
+func (t Table) MaxParty() int {
+	if t.Max_capacity != nil {
+		return *t.Max_capacity
+	}
+	if t.Number_of_guests != nil {
+		return *t.Number_of_guests
+	}
+	return 0
+}
+
+// CurrentStatus returns the table status. Tables from before statuses were
+// kept count as FREE.
+func (t Table) CurrentStatus() string {
+	if t.Status == "" {
+		return TableFree
+	}
+	return t.Status
 }
@@ -6,6 +6,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// roles a user can hold, carried in the JWT and checked by the route policies
+const (
+	RoleAdmin    = "ADMIN"
+	RoleManager  = "MANAGER"
+	RoleServer   = "SERVER"
+	RoleKitchen  = "KITCHEN"
+	RoleCashier  = "CASHIER"
+	RoleCustomer = "CUSTOMER"
+)
+
+var AllRoles = []string{RoleAdmin, RoleManager, RoleServer, RoleKitchen, RoleCashier, RoleCustomer}
+
+// IsManager reports whether the role may approve what needs a manager.
+func IsManager(userType string) bool {
+	return userType == RoleAdmin || userType == RoleManager
+}
+
 type User struct {
 	ID            primitive.ObjectID `bson:"_id"`
 	First_name    *string            `json:"first_name" validate:"required,min=2,max=100"`
@@ -19,5 +36,5 @@ type User struct {
 	Created_at    time.Time          `json:"created_at"`
 	Updated_at    time.Time          `json:"updated_at"`
 	User_id       string             `json:"user_id"`
-	UserType      string             `json:"user_type" validate:"required,eq=ADMIN|eq=USER"`
+	UserType      string             `json:"user_type" validate:"required,eq=ADMIN|eq=MANAGER|eq=SERVER|eq=KITCHEN|eq=CASHIER|eq=CUSTOMER"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// waitlist statuses
+const (
+	WaitlistWaiting = "WAITING"
+	WaitlistSeated  = "SEATED"
+	WaitlistLeft    = "LEFT"
+)
+
+// WaitlistEntry is a walk-in party waiting for a table. Quoted_wait is the
+// wait in minutes they were told when added; once seated, Table_id and
+// Order_id say where.
+type WaitlistEntry struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Waitlist_id string             `json:"waitlist_id"`
+	Guest_name  *string            `json:"guest_name" validate:"required,min=1,max=100"`
+	Phone       *string            `json:"phone" validate:"required,min=5,max=20"`
+	Party_size  *int               `json:"party_size" validate:"required,min=1"`
+	Note        *string            `json:"note" validate:"omitempty,max=500"`
+	Status      string             `json:"status"`
+	Quoted_wait int                `json:"quoted_wait"`
+	Table_id    *string            `json:"table_id"`
+	Order_id    *string            `json:"order_id"`
+	Created_by  string             `json:"created_by"`
+	Created_at  time.Time          `json:"created_at"`
+	Updated_at  time.Time          `json:"updated_at"`
+	Seated_at   *time.Time         `json:"seated_at"`
+	Left_at     *time.Time         `json:"left_at"`
+}
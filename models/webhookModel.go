@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook sends the events of Event_types to Url, signed with Secret.
+type Webhook struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Webhook_id  string             `json:"webhook_id"`
+	Url         *string            `json:"url" validate:"required,url"`
+	Event_types []string           `json:"event_types" validate:"required,min=1"`
+	Secret      *string            `json:"secret,omitempty" validate:"omitempty,min=16"`
+	Active      *bool              `json:"active"`
+	Created_by  string             `json:"created_by"`
+	Created_at  time.Time          `json:"created_at"`
+	Updated_at  time.Time          `json:"updated_at"`
+}
+
+// Subscribed tells whether the webhook wants events of eventType.
+func (w Webhook) Subscribed(eventType string) bool {
+	if w.Active != nil && !*w.Active {
+		return false
+	}
+	for _, t := range w.Event_types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhook delivery statuses, FAILED deliveries are the dead letter list
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryFailed    = "FAILED"
+)
+
+// WebhookDelivery is one event on its way to one webhook. Payload is the exact
+// body that is signed and sent, so a redelivery sends the same bytes.
+type WebhookDelivery struct {
+	ID              primitive.ObjectID `bson:"_id"`
+	Delivery_id     string             `json:"delivery_id"`
+	Webhook_id      string             `json:"webhook_id"`
+	Event_id        uint64             `json:"event_id"`
+	Event_type      string             `json:"event_type"`
+	Payload         string             `json:"payload"`
+	Status          string             `json:"status"`
+	Attempts        int                `json:"attempts"`
+	Response_code   *int               `json:"response_code"`
+	Last_error      *string            `json:"last_error"`
+	Next_attempt_at *time.Time         `json:"next_attempt_at"`
+	Delivered_at    *time.Time         `json:"delivered_at"`
+	Created_at      time.Time          `json:"created_at"`
+	Updated_at      time.Time          `json:"updated_at"`
+}
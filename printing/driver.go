@@ -0,0 +1,75 @@
+package printing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+)
+
+// Driver delivers a rendered job to a printer.
+type Driver interface {
+	Print(ctx context.Context, data []byte) error
+}
+
+// TCPDriver sends jobs to the raw printing port of a network printer.
+type TCPDriver struct {
+	Address string
+	Timeout time.Duration
+}
+
+func (d TCPDriver) Print(ctx context.Context, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(data); err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// FileDriver appends jobs to a file, a printer device such as /dev/usb/lp0 or
+// a plain file to look at while testing.
+type FileDriver struct {
+	Path string
+}
+
+func (d FileDriver) Print(ctx context.Context, data []byte) error {
+	f, err := os.OpenFile(d.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// NewDriver returns the driver a printer is configured with.
+func NewDriver(cfg config.PrinterConfig) (Driver, error) {
+	switch cfg.Driver {
+	case config.PrinterTCP:
+		timeout := cfg.Timeout
+		if timeout == 0 {
+			timeout = 5 * time.Second
+		}
+		return TCPDriver{Address: cfg.Address, Timeout: timeout}, nil
+	case config.PrinterFile:
+		return FileDriver{Path: cfg.Address}, nil
+	}
+	return nil, fmt.Errorf("printer %s: unknown driver %q", cfg.Name, cfg.Driver)
+}
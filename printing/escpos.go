@@ -0,0 +1,167 @@
+package printing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+)
+
+// ESC/POS commands understood by practically every thermal receipt printer.
+var (
+	escInit        = []byte{0x1b, '@'}
+	escBoldOn      = []byte{0x1b, 'E', 1}
+	escBoldOff     = []byte{0x1b, 'E', 0}
+	escAlignLeft   = []byte{0x1b, 'a', 0}
+	escAlignCenter = []byte{0x1b, 'a', 1}
+	escSizeNormal  = []byte{0x1d, '!', 0x00}
+	escSizeDouble  = []byte{0x1d, '!', 0x11}
+	escSizeTall    = []byte{0x1d, '!', 0x01}
+	// feed three lines so the cut does not go through the last line, then cut
+	escFeedAndCut = []byte{0x1b, 'd', 3, 0x1d, 'V', 66, 0}
+)
+
+// Encoder builds an ESC/POS byte stream.
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+func NewEncoder() *Encoder {
+	e := &Encoder{}
+	e.buf.Write(escInit)
+	return e
+}
+
+func (e *Encoder) Bold(on bool) *Encoder {
+	if on {
+		e.buf.Write(escBoldOn)
+	} else {
+		e.buf.Write(escBoldOff)
+	}
+	return e
+}
+
+func (e *Encoder) Center(on bool) *Encoder {
+	if on {
+		e.buf.Write(escAlignCenter)
+	} else {
+		e.buf.Write(escAlignLeft)
+	}
+	return e
+}
+
+// Double prints twice as wide and twice as high, Tall only twice as high.
+func (e *Encoder) Double(on bool) *Encoder {
+	if on {
+		e.buf.Write(escSizeDouble)
+	} else {
+		e.buf.Write(escSizeNormal)
+	}
+	return e
+}
+
+func (e *Encoder) Tall(on bool) *Encoder {
+	if on {
+		e.buf.Write(escSizeTall)
+	} else {
+		e.buf.Write(escSizeNormal)
+	}
+	return e
+}
+
+// Line prints text and a line feed. Printers start up in code page 437, runes
+// outside of ASCII are printed as '?'.
+func (e *Encoder) Line(text string) *Encoder {
+	for _, r := range text {
+		if r >= 0x20 && r < 0x7f {
+			e.buf.WriteRune(r)
+		} else {
+			e.buf.WriteByte('?')
+		}
+	}
+	e.buf.WriteByte('\n')
+	return e
+}
+
+// Cut feeds the paper past the cutter and cuts it.
+func (e *Encoder) Cut() *Encoder {
+	e.buf.Write(escFeedAndCut)
+	return e
+}
+
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// EncodeReceipt prints the receipt laid out for the width of the printer.
+func EncodeReceipt(receipt helpers.Receipt, columns int) []byte {
+	e := NewEncoder()
+	bold := false
+	for _, line := range helpers.ReceiptText(receipt, columns) {
+		if line.Bold != bold {
+			bold = line.Bold
+			e.Bold(bold)
+		}
+		e.Line(line.Text)
+	}
+	return e.Bold(false).Cut().Bytes()
+}
+
+// KitchenTicket is what one kitchen station has to prepare for an order.
+type KitchenTicket struct {
+	Station      string
+	Order_id     string
+	Table_number *int
+	Server_name  string
+	Created_at   time.Time
+	Items        []TicketItem
+}
+
+type TicketItem struct {
+	Name     string
+	Quantity int
+	Note     string
+}
+
+// EncodeKitchenTicket prints the ticket with the table and the items large
+// enough to be read from across the kitchen.
+func EncodeKitchenTicket(ticket KitchenTicket, columns int) []byte {
+	e := NewEncoder()
+	e.Center(true).Bold(true).Line(strings.ToUpper(ticket.Station)).Bold(false)
+	if ticket.Table_number != nil {
+		e.Double(true).Line(fmt.Sprintf("TABLE %d", *ticket.Table_number)).Double(false)
+	}
+	e.Center(false)
+	e.Line(fmt.Sprintf("Order %s", ticket.Order_id))
+	if ticket.Server_name != "" {
+		e.Line("Server " + ticket.Server_name)
+	}
+	e.Line(ticket.Created_at.Format("2006-01-02 15:04"))
+	e.Line(strings.Repeat("=", columns))
+
+	e.Tall(true)
+	for _, item := range ticket.Items {
+		prefix := fmt.Sprintf("%2d x ", item.Quantity)
+		indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+		for i, part := range helpers.WrapText(item.Name, columns-len(prefix)) {
+			if i == 0 {
+				e.Line(prefix + part)
+			} else {
+				e.Line(indent + part)
+			}
+		}
+		if item.Note != "" {
+			e.Tall(false).Bold(true)
+			for _, part := range helpers.WrapText(item.Note, columns-len(indent)-2) {
+				e.Line(indent + "* " + part)
+			}
+			e.Bold(false).Tall(true)
+		}
+	}
+	e.Tall(false)
+	e.Line(strings.Repeat("=", columns))
+	return e.Cut().Bytes()
+}
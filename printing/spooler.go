@@ -0,0 +1,294 @@
+package printing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// job kinds
+const (
+	JobKitchenTicket = "KITCHEN_TICKET"
+	JobReceipt       = "RECEIPT"
+)
+
+// job statuses
+const (
+	JobQueued   = "QUEUED"
+	JobPrinting = "PRINTING"
+	JobDone     = "DONE"
+	JobFailed   = "FAILED"
+)
+
+var (
+	ErrUnknownPrinter = errors.New("printer is not configured")
+	ErrQueueFull      = errors.New("print queue is full")
+	ErrJobNotFound    = errors.New("print job not found")
+	ErrJobNotFailed   = errors.New("only failed print jobs can be retried")
+)
+
+// Job is one ticket or receipt on its way to a printer. Reference is the order
+// or invoice it was printed for.
+type Job struct {
+	Job_id     string    `json:"job_id"`
+	Printer    string    `json:"printer"`
+	Kind       string    `json:"kind"`
+	Reference  string    `json:"reference"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	Last_error string    `json:"last_error,omitempty"`
+	Created_at time.Time `json:"created_at"`
+	Updated_at time.Time `json:"updated_at"`
+
+	data []byte
+}
+
+const (
+	// queueSize is how many jobs can wait for one printer
+	queueSize = 100
+	// keptJobs is how many jobs are remembered; the oldest finished ones are
+	// forgotten first
+	keptJobs = 1000
+)
+
+type printer struct {
+	name    string
+	columns int
+	driver  Driver
+	queue   chan string
+}
+
+// Spooler queues print jobs per printer. Every printer has a worker that
+// prints its jobs one after the other, trying each up to MaxAttempts times
+// with a growing delay. Jobs are kept in memory only.
+type Spooler struct {
+	cfg      config.PrintingConfig
+	printers map[string]*printer
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	ids  []string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSpooler starts a worker for every configured printer. Call Close to stop them.
+func NewSpooler(cfg config.PrintingConfig) (*Spooler, error) {
+	drivers := map[string]Driver{}
+	for _, p := range cfg.Printers {
+		driver, err := NewDriver(p)
+		if err != nil {
+			return nil, err
+		}
+		drivers[p.Name] = driver
+	}
+	return newSpooler(cfg, drivers), nil
+}
+
+func newSpooler(cfg config.PrintingConfig, drivers map[string]Driver) *Spooler {
+	s := &Spooler{
+		cfg:      cfg,
+		printers: map[string]*printer{},
+		jobs:     map[string]*Job{},
+		done:     make(chan struct{}),
+	}
+	if s.cfg.MaxAttempts < 1 {
+		s.cfg.MaxAttempts = 1
+	}
+	for _, p := range cfg.Printers {
+		columns := p.Columns
+		if columns == 0 {
+			columns = helpers.ReceiptColumns
+		}
+		s.printers[p.Name] = &printer{name: p.Name, columns: columns, driver: drivers[p.Name], queue: make(chan string, queueSize)}
+	}
+	for _, p := range s.printers {
+		s.wg.Add(1)
+		go s.work(p)
+	}
+	return s
+}
+
+// Close stops the workers. Jobs that were not printed yet stay QUEUED.
+func (s *Spooler) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// PrintKitchenTicket queues the ticket on printerName.
+func (s *Spooler) PrintKitchenTicket(ticket KitchenTicket, printerName string) (Job, error) {
+	p, ok := s.printers[printerName]
+	if !ok {
+		return Job{}, ErrUnknownPrinter
+	}
+	return s.submit(p, JobKitchenTicket, ticket.Order_id, EncodeKitchenTicket(ticket, p.columns))
+}
+
+// PrintReceipt queues the receipt on printerName, or on the receipt printer
+// when printerName is empty.
+func (s *Spooler) PrintReceipt(receipt helpers.Receipt, printerName string) (Job, error) {
+	if printerName == "" {
+		printerName = s.cfg.ReceiptPrinter
+	}
+	p, ok := s.printers[printerName]
+	if !ok {
+		return Job{}, ErrUnknownPrinter
+	}
+	return s.submit(p, JobReceipt, receipt.Invoice_id, EncodeReceipt(receipt, p.columns))
+}
+
+// Jobs lists the remembered jobs, newest first.
+func (s *Spooler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.ids))
+	for i := len(s.ids) - 1; i >= 0; i-- {
+		jobs = append(jobs, *s.jobs[s.ids[i]])
+	}
+	return jobs
+}
+
+func (s *Spooler) Job(jobId string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobId]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// Retry queues a failed job again, with a fresh set of attempts.
+func (s *Spooler) Retry(jobId string) (Job, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobId]
+	if !ok {
+		s.mu.Unlock()
+		return Job{}, ErrJobNotFound
+	}
+	if job.Status != JobFailed {
+		s.mu.Unlock()
+		return Job{}, ErrJobNotFailed
+	}
+	p := s.printers[job.Printer]
+	select {
+	case p.queue <- jobId:
+	default:
+		s.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	job.Status = JobQueued
+	job.Attempts = 0
+	job.Updated_at = time.Now()
+	retried := *job
+	s.mu.Unlock()
+	return retried, nil
+}
+
+func (s *Spooler) submit(p *printer, kind string, reference string, data []byte) (Job, error) {
+	now := time.Now()
+	job := &Job{
+		Job_id:     primitive.NewObjectID().Hex(),
+		Printer:    p.name,
+		Kind:       kind,
+		Reference:  reference,
+		Status:     JobQueued,
+		Created_at: now,
+		Updated_at: now,
+		data:       data,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the job is known before the worker can pick it up
+	s.jobs[job.Job_id] = job
+	select {
+	case p.queue <- job.Job_id:
+	default:
+		delete(s.jobs, job.Job_id)
+		return Job{}, ErrQueueFull
+	}
+	s.ids = append(s.ids, job.Job_id)
+	s.forgetOldJobs()
+	return *job, nil
+}
+
+// forgetOldJobs drops the oldest finished jobs once more than keptJobs are
+// remembered. s.mu must be held.
+func (s *Spooler) forgetOldJobs() {
+	excess := len(s.ids) - keptJobs
+	if excess <= 0 {
+		return
+	}
+	kept := s.ids[:0]
+	for _, id := range s.ids {
+		status := s.jobs[id].Status
+		if excess > 0 && (status == JobDone || status == JobFailed) {
+			delete(s.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.ids = kept
+}
+
+func (s *Spooler) work(p *printer) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case jobId := <-p.queue:
+			s.print(p, jobId)
+		}
+	}
+}
+
+func (s *Spooler) print(p *printer, jobId string) {
+	for {
+		s.mu.Lock()
+		job := s.jobs[jobId]
+		job.Status = JobPrinting
+		job.Attempts++
+		job.Updated_at = time.Now()
+		attempt, data := job.Attempts, job.data
+		s.mu.Unlock()
+
+		err := p.driver.Print(context.Background(), data)
+
+		s.mu.Lock()
+		job.Updated_at = time.Now()
+		switch {
+		case err == nil:
+			job.Status = JobDone
+			job.Last_error = ""
+			job.data = nil
+		case attempt >= s.cfg.MaxAttempts:
+			job.Status = JobFailed
+			job.Last_error = err.Error()
+		default:
+			job.Status = JobQueued
+			job.Last_error = err.Error()
+		}
+		status := job.Status
+		s.mu.Unlock()
+
+		if status != JobQueued {
+			return
+		}
+		select {
+		case <-time.After(s.cfg.RetryDelay * time.Duration(attempt)):
+		case <-s.done:
+			return
+		}
+	}
+}
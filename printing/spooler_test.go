@@ -0,0 +1,171 @@
+package printing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePrinter listens like the raw port of a network printer and keeps what
+// every connection sent.
+type fakePrinter struct {
+	listener net.Listener
+	mu       sync.Mutex
+	received [][]byte
+}
+
+func newFakePrinter(t *testing.T) *fakePrinter {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	p := &fakePrinter{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			p.mu.Lock()
+			p.received = append(p.received, data)
+			p.mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return p
+}
+
+func (p *fakePrinter) jobs() [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]byte(nil), p.received...)
+}
+
+// flakyDriver fails the first failures prints.
+type flakyDriver struct {
+	mu       sync.Mutex
+	failures int
+	printed  [][]byte
+}
+
+func (d *flakyDriver) Print(ctx context.Context, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failures > 0 {
+		d.failures--
+		return errors.New("paper out")
+	}
+	d.printed = append(d.printed, data)
+	return nil
+}
+
+func waitForStatus(t *testing.T, s *Spooler, jobId string, status string) Job {
+	var job Job
+	assert.Eventually(t, func() bool {
+		job, _ = s.Job(jobId)
+		return job.Status == status
+	}, 2*time.Second, 5*time.Millisecond, "job %s never got %s", jobId, status)
+	return job
+}
+
+func TestKitchenTicketOverTCP(t *testing.T) {
+	kitchen := newFakePrinter(t)
+	bar := newFakePrinter(t)
+	spooler, err := NewSpooler(config.PrintingConfig{
+		Printers: []config.PrinterConfig{
+			{Name: "kitchen", Driver: config.PrinterTCP, Address: kitchen.listener.Addr().String(), Columns: 32},
+			{Name: "bar", Driver: config.PrinterTCP, Address: bar.listener.Addr().String()},
+		},
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+	defer spooler.Close()
+
+	table := 5
+	job, err := spooler.PrintKitchenTicket(KitchenTicket{
+		Station:      "Kitchen",
+		Order_id:     "order1",
+		Table_number: &table,
+		Items:        []TicketItem{{Name: "Steak", Quantity: 2, Note: "medium rare"}},
+	}, "kitchen")
+	require.NoError(t, err)
+	assert.Equal(t, JobKitchenTicket, job.Kind)
+	assert.Equal(t, "order1", job.Reference)
+
+	job = waitForStatus(t, spooler, job.Job_id, JobDone)
+	assert.Equal(t, 1, job.Attempts)
+	require.Len(t, kitchen.jobs(), 1)
+	assert.Empty(t, bar.jobs())
+
+	ticket := kitchen.jobs()[0]
+	assert.True(t, bytes.HasPrefix(ticket, escInit))
+	assert.True(t, bytes.HasSuffix(ticket, escFeedAndCut))
+	assert.Contains(t, string(ticket), "TABLE 5")
+	assert.Contains(t, string(ticket), " 2 x Steak\n")
+	assert.Contains(t, string(ticket), "     * medium rare\n")
+}
+
+func TestFailedJobsAreRetried(t *testing.T) {
+	driver := &flakyDriver{failures: 4}
+	spooler := newSpooler(config.PrintingConfig{
+		Printers:       []config.PrinterConfig{{Name: "counter"}},
+		ReceiptPrinter: "counter",
+		MaxAttempts:    3,
+		RetryDelay:     time.Millisecond,
+	}, map[string]Driver{"counter": driver})
+	defer spooler.Close()
+
+	job, err := spooler.PrintReceipt(helpers.Receipt{Invoice_id: "inv1"}, "")
+	require.NoError(t, err)
+
+	job = waitForStatus(t, spooler, job.Job_id, JobFailed)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "paper out", job.Last_error)
+
+	// the printer has paper again after one more failure
+	_, err = spooler.Retry(job.Job_id)
+	require.NoError(t, err)
+	job = waitForStatus(t, spooler, job.Job_id, JobDone)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Len(t, driver.printed, 1)
+
+	_, err = spooler.Retry(job.Job_id)
+	assert.ErrorIs(t, err, ErrJobNotFailed)
+	_, err = spooler.PrintReceipt(helpers.Receipt{}, "office")
+	assert.ErrorIs(t, err, ErrUnknownPrinter)
+}
+
+func TestFileDriver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "printer.bin")
+	driver := FileDriver{Path: path}
+	require.NoError(t, driver.Print(context.Background(), []byte("one")))
+	require.NoError(t, driver.Print(context.Background(), []byte("two")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "onetwo", string(data))
+}
+
+func TestEncodeReceipt(t *testing.T) {
+	data := EncodeReceipt(helpers.Receipt{
+		Invoice_id: "inv1",
+		Items:      []helpers.ReceiptItem{{Name: "Crème brûlée", Quantity: 1, Amount: 7}},
+		Totals:     []helpers.ReceiptTotal{{Label: "TOTAL", Amount: 7, Bold: true}},
+	}, 32)
+
+	assert.Contains(t, string(data), "1 x Cr?me br?l?e            7.00\n")
+	// the total is printed in bold
+	assert.Contains(t, string(data), string(escBoldOn)+"TOTAL                       7.00\n")
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AdjustmentRepository interface {
+	// List returns the adjustments with the status, or all of them for "".
+	List(ctx context.Context, status string) ([]models.Adjustment, error)
+	FindByID(ctx context.Context, adjustmentId string) (models.Adjustment, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.Adjustment, error)
+	// FindBetween returns the adjustments requested from from up to, not including, to.
+	FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Adjustment, error)
+	Insert(ctx context.Context, adjustment models.Adjustment) error
+	Update(ctx context.Context, adjustmentId string, fields primitive.D) error
+	// Decide approves or rejects a pending adjustment, and returns ErrConflict
+	// when it was decided already.
+	Decide(ctx context.Context, adjustmentId string, status string, decidedBy string, decidedAt time.Time) error
+}
+
+type mongoAdjustmentRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoAdjustmentRepository) List(ctx context.Context, status string) ([]models.Adjustment, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return mongoFind[models.Adjustment](ctx, r.collection, filter)
+}
+
+func (r *mongoAdjustmentRepository) FindByID(ctx context.Context, adjustmentId string) (models.Adjustment, error) {
+	return mongoFindOne[models.Adjustment](ctx, r.collection, bson.M{"adjustment_id": adjustmentId})
+}
+
+func (r *mongoAdjustmentRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Adjustment, error) {
+	return mongoFind[models.Adjustment](ctx, r.collection, bson.M{"order_id": orderId})
+}
+
+func (r *mongoAdjustmentRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Adjustment, error) {
+	return mongoFind[models.Adjustment](ctx, r.collection, bson.M{"requested_at": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *mongoAdjustmentRepository) Insert(ctx context.Context, adjustment models.Adjustment) error {
+	_, err := r.collection.InsertOne(ctx, adjustment)
+	return err
+}
+
+func (r *mongoAdjustmentRepository) Update(ctx context.Context, adjustmentId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"adjustment_id": adjustmentId}, fields)
+}
+
+func (r *mongoAdjustmentRepository) Decide(ctx context.Context, adjustmentId string, status string, decidedBy string, decidedAt time.Time) error {
+	filter := bson.M{"adjustment_id": adjustmentId, "status": models.AdjustmentPending}
+	err := mongoSet(ctx, r.collection, filter, bson.D{
+		{Key: "status", Value: status},
+		{Key: "decided_by", Value: decidedBy},
+		{Key: "decided_at", Value: decidedAt},
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryAdjustmentRepository struct {
+	adjustments *memoryCollection[models.Adjustment]
+}
+
+func (r *memoryAdjustmentRepository) List(ctx context.Context, status string) ([]models.Adjustment, error) {
+	return r.adjustments.find(func(a models.Adjustment) bool { return status == "" || a.Status == status })
+}
+
+func (r *memoryAdjustmentRepository) FindByID(ctx context.Context, adjustmentId string) (models.Adjustment, error) {
+	return r.adjustments.findOne(func(a models.Adjustment) bool { return a.Adjustment_id == adjustmentId })
+}
+
+func (r *memoryAdjustmentRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Adjustment, error) {
+	return r.adjustments.find(func(a models.Adjustment) bool { return a.Order_id == orderId })
+}
+
+func (r *memoryAdjustmentRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Adjustment, error) {
+	return r.adjustments.find(func(a models.Adjustment) bool {
+		return !a.Requested_at.Before(from) && a.Requested_at.Before(to)
+	})
+}
+
+func (r *memoryAdjustmentRepository) Insert(ctx context.Context, adjustment models.Adjustment) error {
+	return r.adjustments.insert(adjustment)
+}
+
+func (r *memoryAdjustmentRepository) Update(ctx context.Context, adjustmentId string, fields primitive.D) error {
+	matched, err := r.adjustments.set(func(a models.Adjustment) bool { return a.Adjustment_id == adjustmentId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryAdjustmentRepository) Decide(ctx context.Context, adjustmentId string, status string, decidedBy string, decidedAt time.Time) error {
+	match := func(a models.Adjustment) bool {
+		return a.Adjustment_id == adjustmentId && a.Status == models.AdjustmentPending
+	}
+	matched, err := r.adjustments.update(match, func(a *models.Adjustment) error {
+		a.Status = status
+		a.Decided_by = &decidedBy
+		a.Decided_at = &decidedAt
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
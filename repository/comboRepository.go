@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ComboRepository interface {
+	List(ctx context.Context) ([]models.Combo, error)
+	FindByID(ctx context.Context, comboId string) (models.Combo, error)
+	FindByIDs(ctx context.Context, comboIds []string) ([]models.Combo, error)
+	Insert(ctx context.Context, combo models.Combo) error
+	Update(ctx context.Context, comboId string, fields primitive.D) error
+}
+
+type mongoComboRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoComboRepository) List(ctx context.Context) ([]models.Combo, error) {
+	return mongoFind[models.Combo](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoComboRepository) FindByID(ctx context.Context, comboId string) (models.Combo, error) {
+	return mongoFindOne[models.Combo](ctx, r.collection, bson.M{"combo_id": comboId})
+}
+
+func (r *mongoComboRepository) FindByIDs(ctx context.Context, comboIds []string) ([]models.Combo, error) {
+	return mongoFind[models.Combo](ctx, r.collection, bson.M{"combo_id": bson.M{"$in": comboIds}})
+}
+
+func (r *mongoComboRepository) Insert(ctx context.Context, combo models.Combo) error {
+	_, err := r.collection.InsertOne(ctx, combo)
+	return err
+}
+
+func (r *mongoComboRepository) Update(ctx context.Context, comboId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"combo_id": comboId}, fields)
+}
+
+type memoryComboRepository struct {
+	combos *memoryCollection[models.Combo]
+}
+
+func (r *memoryComboRepository) List(ctx context.Context) ([]models.Combo, error) {
+	return r.combos.find(nil)
+}
+
+func (r *memoryComboRepository) FindByID(ctx context.Context, comboId string) (models.Combo, error) {
+	return r.combos.findOne(func(c models.Combo) bool { return c.Combo_id == comboId })
+}
+
+func (r *memoryComboRepository) FindByIDs(ctx context.Context, comboIds []string) ([]models.Combo, error) {
+	wanted := map[string]bool{}
+	for _, id := range comboIds {
+		wanted[id] = true
+	}
+	return r.combos.find(func(c models.Combo) bool { return wanted[c.Combo_id] })
+}
+
+func (r *memoryComboRepository) Insert(ctx context.Context, combo models.Combo) error {
+	return r.combos.insert(combo)
+}
+
+func (r *memoryComboRepository) Update(ctx context.Context, comboId string, fields primitive.D) error {
+	matched, err := r.combos.set(func(c models.Combo) bool { return c.Combo_id == comboId }, fields)
+	return notFoundIfNone(matched, err)
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CouponRepository interface {
+	List(ctx context.Context) ([]models.Coupon, error)
+	FindByID(ctx context.Context, couponId string) (models.Coupon, error)
+	FindByCode(ctx context.Context, code string) (models.Coupon, error)
+	Insert(ctx context.Context, coupon models.Coupon) error
+	Update(ctx context.Context, couponId string, fields primitive.D) error
+	// Redeem counts one use of the coupon, or returns ErrConflict when it
+	// reached its usage limit.
+	Redeem(ctx context.Context, couponId string) error
+	// Release gives back a use counted by Redeem.
+	Release(ctx context.Context, couponId string) error
+}
+
+type mongoCouponRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoCouponRepository) List(ctx context.Context) ([]models.Coupon, error) {
+	return mongoFind[models.Coupon](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoCouponRepository) FindByID(ctx context.Context, couponId string) (models.Coupon, error) {
+	return mongoFindOne[models.Coupon](ctx, r.collection, bson.M{"coupon_id": couponId})
+}
+
+func (r *mongoCouponRepository) FindByCode(ctx context.Context, code string) (models.Coupon, error) {
+	return mongoFindOne[models.Coupon](ctx, r.collection, bson.M{"code": code})
+}
+
+func (r *mongoCouponRepository) Insert(ctx context.Context, coupon models.Coupon) error {
+	_, err := r.collection.InsertOne(ctx, coupon)
+	return err
+}
+
+func (r *mongoCouponRepository) Update(ctx context.Context, couponId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"coupon_id": couponId}, fields)
+}
+
+func (r *mongoCouponRepository) Redeem(ctx context.Context, couponId string) error {
+	filter := bson.M{
+		"coupon_id": couponId,
+		"$or": bson.A{
+			bson.M{"max_uses": nil},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$used_count", "$max_uses"}}},
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"used_count": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoCouponRepository) Release(ctx context.Context, couponId string) error {
+	filter := bson.M{"coupon_id": couponId, "used_count": bson.M{"$gt": 0}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"used_count": -1}})
+	return err
+}
+
+type memoryCouponRepository struct {
+	coupons *memoryCollection[models.Coupon]
+}
+
+func (r *memoryCouponRepository) List(ctx context.Context) ([]models.Coupon, error) {
+	return r.coupons.find(nil)
+}
+
+func (r *memoryCouponRepository) FindByID(ctx context.Context, couponId string) (models.Coupon, error) {
+	return r.coupons.findOne(func(c models.Coupon) bool { return c.Coupon_id == couponId })
+}
+
+func (r *memoryCouponRepository) FindByCode(ctx context.Context, code string) (models.Coupon, error) {
+	return r.coupons.findOne(func(c models.Coupon) bool { return c.Code != nil && *c.Code == code })
+}
+
+func (r *memoryCouponRepository) Insert(ctx context.Context, coupon models.Coupon) error {
+	return r.coupons.insert(coupon)
+}
+
+func (r *memoryCouponRepository) Update(ctx context.Context, couponId string, fields primitive.D) error {
+	matched, err := r.coupons.set(func(c models.Coupon) bool { return c.Coupon_id == couponId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryCouponRepository) Redeem(ctx context.Context, couponId string) error {
+	match := func(c models.Coupon) bool { return c.Coupon_id == couponId && !c.UsedUp() }
+	matched, err := r.coupons.update(match, func(c *models.Coupon) error {
+		c.Used_count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *memoryCouponRepository) Release(ctx context.Context, couponId string) error {
+	match := func(c models.Coupon) bool { return c.Coupon_id == couponId && c.Used_count > 0 }
+	_, err := r.coupons.update(match, func(c *models.Coupon) error {
+		c.Used_count--
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type FoodRepository interface {
+	List(ctx context.Context, skip int, limit int) ([]models.Food, int64, error)
+	FindByID(ctx context.Context, foodId string) (models.Food, error)
+	FindByIDs(ctx context.Context, foodIds []string) ([]models.Food, error)
+	FindByMenu(ctx context.Context, menuId string) ([]models.Food, error)
+	Insert(ctx context.Context, food models.Food) error
+	Update(ctx context.Context, foodId string, fields primitive.D) error
+}
+
+type mongoFoodRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoFoodRepository) List(ctx context.Context, skip int, limit int) ([]models.Food, int64, error) {
+	return mongoPage[models.Food](ctx, r.collection, skip, limit)
+}
+
+func (r *mongoFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	return mongoFindOne[models.Food](ctx, r.collection, bson.M{"food_id": foodId})
+}
+
+func (r *mongoFoodRepository) FindByIDs(ctx context.Context, foodIds []string) ([]models.Food, error) {
+	return mongoFind[models.Food](ctx, r.collection, bson.M{"food_id": bson.M{"$in": foodIds}})
+}
+
+func (r *mongoFoodRepository) FindByMenu(ctx context.Context, menuId string) ([]models.Food, error) {
+	return mongoFind[models.Food](ctx, r.collection, bson.M{"menu_id": menuId})
+}
+
+func (r *mongoFoodRepository) Insert(ctx context.Context, food models.Food) error {
+	_, err := r.collection.InsertOne(ctx, food)
+	return err
+}
+
+func (r *mongoFoodRepository) Update(ctx context.Context, foodId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"food_id": foodId}, fields)
+}
+
+type memoryFoodRepository struct {
+	foods *memoryCollection[models.Food]
+}
+
+func (r *memoryFoodRepository) List(ctx context.Context, skip int, limit int) ([]models.Food, int64, error) {
+	return r.foods.page(nil, skip, limit)
+}
+
+func (r *memoryFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	return r.foods.findOne(func(f models.Food) bool { return f.Food_id == foodId })
+}
+
+func (r *memoryFoodRepository) FindByIDs(ctx context.Context, foodIds []string) ([]models.Food, error) {
+	wanted := map[string]bool{}
+	for _, id := range foodIds {
+		wanted[id] = true
+	}
+	return r.foods.find(func(f models.Food) bool { return wanted[f.Food_id] })
+}
+
+func (r *memoryFoodRepository) FindByMenu(ctx context.Context, menuId string) ([]models.Food, error) {
+	return r.foods.find(func(f models.Food) bool { return f.Menu_id != nil && *f.Menu_id == menuId })
+}
+
+func (r *memoryFoodRepository) Insert(ctx context.Context, food models.Food) error {
+	return r.foods.insert(food)
+}
+
+func (r *memoryFoodRepository) Update(ctx context.Context, foodId string, fields primitive.D) error {
+	matched, err := r.foods.set(func(f models.Food) bool { return f.Food_id == foodId }, fields)
+	return notFoundIfNone(matched, err)
+}
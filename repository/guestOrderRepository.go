@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GuestOrderRepository interface {
+	// List returns the guest orders having one of statuses, oldest first.
+	List(ctx context.Context, statuses []string) ([]models.GuestOrder, error)
+	FindByID(ctx context.Context, guestOrderId string) (models.GuestOrder, error)
+	// FindBySession returns the guest orders of a guest session, oldest first.
+	FindBySession(ctx context.Context, sessionId string) ([]models.GuestOrder, error)
+	Insert(ctx context.Context, guestOrder models.GuestOrder) error
+	// UpdateStatus sets fields only while the guest order still has status
+	// from, and returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, guestOrderId string, from string, fields primitive.D) error
+}
+
+type mongoGuestOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoGuestOrderRepository) List(ctx context.Context, statuses []string) ([]models.GuestOrder, error) {
+	filter := bson.M{"status": bson.M{"$in": statuses}}
+	return mongoFind[models.GuestOrder](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (r *mongoGuestOrderRepository) FindByID(ctx context.Context, guestOrderId string) (models.GuestOrder, error) {
+	return mongoFindOne[models.GuestOrder](ctx, r.collection, bson.M{"guest_order_id": guestOrderId})
+}
+
+func (r *mongoGuestOrderRepository) FindBySession(ctx context.Context, sessionId string) ([]models.GuestOrder, error) {
+	filter := bson.M{"session_id": sessionId}
+	return mongoFind[models.GuestOrder](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (r *mongoGuestOrderRepository) Insert(ctx context.Context, guestOrder models.GuestOrder) error {
+	_, err := r.collection.InsertOne(ctx, guestOrder)
+	return err
+}
+
+func (r *mongoGuestOrderRepository) UpdateStatus(ctx context.Context, guestOrderId string, from string, fields primitive.D) error {
+	err := mongoSet(ctx, r.collection, bson.M{"guest_order_id": guestOrderId, "status": from}, fields)
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryGuestOrderRepository struct {
+	guestOrders *memoryCollection[models.GuestOrder]
+}
+
+func (r *memoryGuestOrderRepository) List(ctx context.Context, statuses []string) ([]models.GuestOrder, error) {
+	return r.guestOrders.find(func(g models.GuestOrder) bool {
+		for _, status := range statuses {
+			if g.Status == status {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memoryGuestOrderRepository) FindByID(ctx context.Context, guestOrderId string) (models.GuestOrder, error) {
+	return r.guestOrders.findOne(func(g models.GuestOrder) bool { return g.Guest_order_id == guestOrderId })
+}
+
+func (r *memoryGuestOrderRepository) FindBySession(ctx context.Context, sessionId string) ([]models.GuestOrder, error) {
+	return r.guestOrders.find(func(g models.GuestOrder) bool { return g.Session_id == sessionId })
+}
+
+func (r *memoryGuestOrderRepository) Insert(ctx context.Context, guestOrder models.GuestOrder) error {
+	return r.guestOrders.insert(guestOrder)
+}
+
+func (r *memoryGuestOrderRepository) UpdateStatus(ctx context.Context, guestOrderId string, from string, fields primitive.D) error {
+	matched, err := r.guestOrders.set(func(g models.GuestOrder) bool {
+		return g.Guest_order_id == guestOrderId && g.Status == from
+	}, fields)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceRepository interface {
+	List(ctx context.Context) ([]models.Invoice, error)
+	FindByID(ctx context.Context, invoiceId string) (models.Invoice, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error)
+	Insert(ctx context.Context, invoice models.Invoice) error
+	Update(ctx context.Context, invoiceId string, fields primitive.D) error
+	// AddPayment stores the payment and moves the invoice from paidBefore to
+	// paidBefore plus the payment amount, with the given status. It returns
+	// ErrConflict when another payment was recorded since paidBefore was read.
+	AddPayment(ctx context.Context, payment models.Payment, paidBefore float64, status string) error
+	// SetPaymentStatus sets the status only while paid is still the amount
+	// paid on the invoice, and returns ErrConflict otherwise.
+	SetPaymentStatus(ctx context.Context, invoiceId string, paid float64, status string, updatedAt time.Time) error
+}
+
+type mongoInvoiceRepository struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+	payments   *mongo.Collection
+}
+
+func (r *mongoInvoiceRepository) List(ctx context.Context) ([]models.Invoice, error) {
+	return mongoFind[models.Invoice](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return mongoFindOne[models.Invoice](ctx, r.collection, bson.M{"invoice_id": invoiceId})
+}
+
+func (r *mongoInvoiceRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return mongoFind[models.Invoice](ctx, r.collection, bson.M{"order_id": orderId})
+}
+
+func (r *mongoInvoiceRepository) Insert(ctx context.Context, invoice models.Invoice) error {
+	_, err := r.collection.InsertOne(ctx, invoice)
+	return err
+}
+
+func (r *mongoInvoiceRepository) Update(ctx context.Context, invoiceId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"invoice_id": invoiceId}, fields)
+}
+
+// AddPayment uses a transaction where the server supports one, like
+// CreateWithItems, and otherwise undoes the invoice update by hand.
+func (r *mongoInvoiceRepository) AddPayment(ctx context.Context, payment models.Payment, paidBefore float64, status string) error {
+	paidAfter := math.Round((paidBefore+*payment.Amount)*100) / 100
+	filter := bson.M{"invoice_id": payment.Invoice_id, "amount_paid": paidBefore}
+	if paidBefore == 0 {
+		// invoices from before payments have no amount_paid
+		filter["amount_paid"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	updateInvoice := func(ctx context.Context) error {
+		err := mongoSet(ctx, r.collection, filter, bson.D{
+			{Key: "amount_paid", Value: paidAfter},
+			{Key: "payment_status", Value: status},
+			{Key: "updated_at", Value: payment.Created_at},
+		})
+		if err == ErrNotFound {
+			return ErrConflict
+		}
+		return err
+	}
+	write := func(ctx context.Context) error {
+		if err := updateInvoice(ctx); err != nil {
+			return err
+		}
+		_, err := r.payments.InsertOne(ctx, payment)
+		return err
+	}
+
+	err := database.WithTransaction(ctx, r.client, write)
+	if !database.IsTransactionNotSupported(err) {
+		return err
+	}
+
+	// the invoice as it was before the payment is kept for the undo
+	var before models.Invoice
+	err = r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.D{
+		{Key: "amount_paid", Value: paidAfter},
+		{Key: "payment_status", Value: status},
+		{Key: "updated_at", Value: payment.Created_at},
+	}}, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = r.payments.InsertOne(ctx, payment); err != nil {
+		undo := bson.M{"invoice_id": payment.Invoice_id, "amount_paid": paidAfter}
+		_, undoErr := r.collection.UpdateOne(ctx, undo, bson.M{
+			"$inc": bson.M{"amount_paid": -*payment.Amount},
+			"$set": bson.M{"payment_status": before.Payment_status, "updated_at": before.Updated_at},
+		})
+		if undoErr != nil {
+			log.Printf("rollback of payment on invoice %s failed: %v", payment.Invoice_id, undoErr)
+		}
+	}
+	return err
+}
+
+func (r *mongoInvoiceRepository) SetPaymentStatus(ctx context.Context, invoiceId string, paid float64, status string, updatedAt time.Time) error {
+	filter := bson.M{"invoice_id": invoiceId, "amount_paid": paid}
+	if paid == 0 {
+		filter["amount_paid"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	err := mongoSet(ctx, r.collection, filter, bson.D{
+		{Key: "payment_status", Value: status},
+		{Key: "updated_at", Value: updatedAt},
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryInvoiceRepository struct {
+	invoices *memoryCollection[models.Invoice]
+	payments *memoryCollection[models.Payment]
+}
+
+func (r *memoryInvoiceRepository) List(ctx context.Context) ([]models.Invoice, error) {
+	return r.invoices.find(nil)
+}
+
+func (r *memoryInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.invoices.findOne(func(i models.Invoice) bool { return i.Invoice_id == invoiceId })
+}
+
+func (r *memoryInvoiceRepository) FindByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return r.invoices.find(func(i models.Invoice) bool { return i.Order_id == orderId })
+}
+
+func (r *memoryInvoiceRepository) Insert(ctx context.Context, invoice models.Invoice) error {
+	return r.invoices.insert(invoice)
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, invoiceId string, fields primitive.D) error {
+	matched, err := r.invoices.set(func(i models.Invoice) bool { return i.Invoice_id == invoiceId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryInvoiceRepository) AddPayment(ctx context.Context, payment models.Payment, paidBefore float64, status string) error {
+	match := func(i models.Invoice) bool {
+		return i.Invoice_id == payment.Invoice_id && i.Amount_paid == paidBefore
+	}
+
+	matched, err := r.invoices.update(match, func(i *models.Invoice) error {
+		i.Amount_paid = math.Round((paidBefore+*payment.Amount)*100) / 100
+		i.Payment_status = &status
+		i.Updated_at = payment.Created_at
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return r.payments.insert(payment)
+}
+
+func (r *memoryInvoiceRepository) SetPaymentStatus(ctx context.Context, invoiceId string, paid float64, status string, updatedAt time.Time) error {
+	matched, err := r.invoices.set(func(i models.Invoice) bool {
+		return i.Invoice_id == invoiceId && i.Amount_paid == paid
+	}, bson.D{
+		{Key: "payment_status", Value: status},
+		{Key: "updated_at", Value: updatedAt},
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type KitchenTicketRepository interface {
+	// List returns the tickets of station ("" for all stations) having one of
+	// statuses, oldest first.
+	List(ctx context.Context, station string, statuses []string) ([]models.KitchenTicket, error)
+	FindByID(ctx context.Context, ticketId string) (models.KitchenTicket, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.KitchenTicket, error)
+	Insert(ctx context.Context, tickets ...models.KitchenTicket) error
+	// UpdateStatus sets fields only while the ticket still has status from,
+	// and returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, ticketId string, from string, fields primitive.D) error
+	// SetTableNumber shows the tickets of the order under another table.
+	SetTableNumber(ctx context.Context, orderId string, tableNumber *int) error
+}
+
+type mongoKitchenTicketRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoKitchenTicketRepository) List(ctx context.Context, station string, statuses []string) ([]models.KitchenTicket, error) {
+	filter := bson.M{"status": bson.M{"$in": statuses}}
+	if station != "" {
+		filter["station"] = station
+	}
+	return mongoFind[models.KitchenTicket](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+func (r *mongoKitchenTicketRepository) FindByID(ctx context.Context, ticketId string) (models.KitchenTicket, error) {
+	return mongoFindOne[models.KitchenTicket](ctx, r.collection, bson.M{"ticket_id": ticketId})
+}
+
+func (r *mongoKitchenTicketRepository) FindByOrder(ctx context.Context, orderId string) ([]models.KitchenTicket, error) {
+	return mongoFind[models.KitchenTicket](ctx, r.collection, bson.M{"order_id": orderId})
+}
+
+func (r *mongoKitchenTicketRepository) Insert(ctx context.Context, tickets ...models.KitchenTicket) error {
+	docs := make([]interface{}, len(tickets))
+	for i := range tickets {
+		docs[i] = tickets[i]
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoKitchenTicketRepository) UpdateStatus(ctx context.Context, ticketId string, from string, fields primitive.D) error {
+	err := mongoSet(ctx, r.collection, bson.M{"ticket_id": ticketId, "status": from}, fields)
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *mongoKitchenTicketRepository) SetTableNumber(ctx context.Context, orderId string, tableNumber *int) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"order_id": orderId}, bson.M{"$set": bson.M{"table_number": tableNumber}})
+	return err
+}
+
+type memoryKitchenTicketRepository struct {
+	tickets *memoryCollection[models.KitchenTicket]
+}
+
+func (r *memoryKitchenTicketRepository) List(ctx context.Context, station string, statuses []string) ([]models.KitchenTicket, error) {
+	return r.tickets.find(func(t models.KitchenTicket) bool {
+		if station != "" && t.Station != station {
+			return false
+		}
+		for _, status := range statuses {
+			if t.Status == status {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memoryKitchenTicketRepository) FindByID(ctx context.Context, ticketId string) (models.KitchenTicket, error) {
+	return r.tickets.findOne(func(t models.KitchenTicket) bool { return t.Ticket_id == ticketId })
+}
+
+func (r *memoryKitchenTicketRepository) FindByOrder(ctx context.Context, orderId string) ([]models.KitchenTicket, error) {
+	return r.tickets.find(func(t models.KitchenTicket) bool { return t.Order_id == orderId })
+}
+
+func (r *memoryKitchenTicketRepository) Insert(ctx context.Context, tickets ...models.KitchenTicket) error {
+	return r.tickets.insert(tickets...)
+}
+
+func (r *memoryKitchenTicketRepository) UpdateStatus(ctx context.Context, ticketId string, from string, fields primitive.D) error {
+	matched, err := r.tickets.set(func(t models.KitchenTicket) bool {
+		return t.Ticket_id == ticketId && t.Status == from
+	}, fields)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *memoryKitchenTicketRepository) SetTableNumber(ctx context.Context, orderId string, tableNumber *int) error {
+	_, err := r.tickets.set(func(t models.KitchenTicket) bool { return t.Order_id == orderId },
+		bson.D{{Key: "table_number", Value: tableNumber}})
+	return err
+}
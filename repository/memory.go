@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCollection holds documents in insertion order, like a collection
+// without indexes. Documents are copied in and out through BSON so callers
+// never share memory with the stored copy, and see the same field names and
+// time precision as with MongoDB.
+type memoryCollection[T any] struct {
+	mu   sync.RWMutex
+	docs []T
+}
+
+func (c *memoryCollection[T]) insert(docs ...T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, doc := range docs {
+		stored, err := clone(doc)
+		if err != nil {
+			return err
+		}
+		c.docs = append(c.docs, stored)
+	}
+	return nil
+}
+
+func (c *memoryCollection[T]) find(match func(T) bool) ([]T, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	found := []T{}
+	for _, doc := range c.docs {
+		if match == nil || match(doc) {
+			copied, err := clone(doc)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, copied)
+		}
+	}
+	return found, nil
+}
+
+func (c *memoryCollection[T]) findOne(match func(T) bool) (T, error) {
+	var zero T
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, doc := range c.docs {
+		if match(doc) {
+			return clone(doc)
+		}
+	}
+	return zero, ErrNotFound
+}
+
+// page returns docs[skip:skip+limit] of the matching documents and how many matched.
+func (c *memoryCollection[T]) page(match func(T) bool, skip int, limit int) ([]T, int64, error) {
+	all, err := c.find(match)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := int64(len(all))
+	if skip > len(all) {
+		skip = len(all)
+	}
+	end := skip + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[skip:end], total, nil
+}
+
+// update applies fn to every matching document and returns how many matched.
+func (c *memoryCollection[T]) update(match func(T) bool, fn func(*T) error) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched := 0
+	for i := range c.docs {
+		if !match(c.docs[i]) {
+			continue
+		}
+		updated, err := clone(c.docs[i])
+		if err != nil {
+			return matched, err
+		}
+		if err := fn(&updated); err != nil {
+			return matched, err
+		}
+		c.docs[i] = updated
+		matched++
+	}
+	return matched, nil
+}
+
+// set applies a $set document to every matching document.
+func (c *memoryCollection[T]) set(match func(T) bool, fields primitive.D) (int, error) {
+	return c.update(match, func(doc *T) error {
+		return applySet(doc, fields)
+	})
+}
+
+func (c *memoryCollection[T]) delete(match func(T) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.docs[:0]
+	deleted := 0
+	for _, doc := range c.docs {
+		if match(doc) {
+			deleted++
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	c.docs = kept
+	return deleted
+}
+
+func notFoundIfNone(matched int, err error) error {
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func clone[T any](doc T) (T, error) {
+	var copied T
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return copied, err
+	}
+	err = bson.Unmarshal(data, &copied)
+	return copied, err
+}
+
+// applySet works like a $set update: the fields are written over the BSON form
+// of the document, which is then decoded back into it.
+func applySet[T any](doc *T, fields primitive.D) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var raw bson.D
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		replaced := false
+		for i := range raw {
+			if raw[i].Key == field.Key {
+				raw[i].Value = field.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			raw = append(raw, field)
+		}
+	}
+
+	data, err = bson.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	var updated T
+	if err := bson.Unmarshal(data, &updated); err != nil {
+		return err
+	}
+	*doc = updated
+	return nil
+}
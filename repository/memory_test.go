@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryUpdateAppliesSet(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	guests, number := 4, 1
+	table := models.Table{ID: primitive.NewObjectID(), Table_id: "t1", Number_of_guests: &guests, Table_number: &number}
+	assert.NoError(t, store.Tables.Insert(ctx, table))
+
+	assert.NoError(t, store.Tables.Update(ctx, "t1", primitive.D{{Key: "number_of_guests", Value: 6}}))
+	assert.Equal(t, ErrNotFound, store.Tables.Update(ctx, "missing", primitive.D{{Key: "number_of_guests", Value: 6}}))
+
+	updated, err := store.Tables.FindByID(ctx, "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, *updated.Number_of_guests)
+	assert.Equal(t, 1, *updated.Table_number)
+}
+
+func TestMemoryReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	name := "Burger"
+	assert.NoError(t, store.Foods.Insert(ctx, models.Food{ID: primitive.NewObjectID(), Food_id: "f1", Name: &name}))
+
+	found, _ := store.Foods.FindByID(ctx, "f1")
+	*found.Name = "changed"
+
+	again, _ := store.Foods.FindByID(ctx, "f1")
+	assert.Equal(t, "Burger", *again.Name)
+}
+
+func TestMemoryListPages(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.Foods.Insert(ctx, models.Food{ID: primitive.NewObjectID(), Food_id: primitive.NewObjectID().Hex()}))
+	}
+
+	page, total, err := store.Foods.List(ctx, 3, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, page, 2)
+
+	page, _, _ = store.Foods.List(ctx, 10, 10)
+	assert.Empty(t, page)
+}
+
+func TestMemoryUpdateStatusIsConditional(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	assert.NoError(t, store.Orders.Insert(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "o1"}))
+
+	placed := models.OrderPlaced
+	change := models.OrderStatusChange{From: placed, To: models.OrderInKitchen}
+
+	// the stored order has no status yet, so expecting PLACED conflicts
+	assert.Equal(t, ErrConflict, store.Orders.UpdateStatus(ctx, "o1", &placed, change, primitive.D{{Key: "server_id", Value: "s1"}}))
+	assert.NoError(t, store.Orders.UpdateStatus(ctx, "o1", nil, change, nil))
+
+	order, _ := store.Orders.FindByID(ctx, "o1")
+	assert.Equal(t, models.OrderInKitchen, *order.Status)
+	assert.Len(t, order.Status_history, 1)
+	assert.Nil(t, order.Server_id, "the fields of a refused change are not set")
+
+	inKitchen := models.OrderInKitchen
+	change = models.OrderStatusChange{From: inKitchen, To: models.OrderReady}
+	assert.NoError(t, store.Orders.UpdateStatus(ctx, "o1", &inKitchen, change, primitive.D{{Key: "server_id", Value: "s1"}}))
+	order, _ = store.Orders.FindByID(ctx, "o1")
+	assert.Equal(t, "s1", *order.Server_id)
+}
+
+func TestMemorySeatChecksTheTable(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	table, other := "t1", "t2"
+	paid, draft := models.OrderPaid, models.OrderDraft
+	assert.NoError(t, store.Orders.Insert(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "left", Table_id: &table, Status: &paid}))
+
+	// a party that paid and left does not keep the table
+	assert.NoError(t, store.Orders.Seat(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "o1", Table_id: &table, Status: &draft}))
+	assert.Equal(t, ErrConflict, store.Orders.Seat(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "o2", Table_id: &table, Status: &draft}))
+	assert.NoError(t, store.Orders.Seat(ctx, models.Order{ID: primitive.NewObjectID(), Order_id: "o3", Table_id: &other, Status: &draft}))
+
+	_, err := store.Orders.FindByID(ctx, "o2")
+	assert.Equal(t, ErrNotFound, err)
+}
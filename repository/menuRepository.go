@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MenuRepository interface {
+	List(ctx context.Context) ([]models.Menu, error)
+	FindByID(ctx context.Context, menuId string) (models.Menu, error)
+	FindByIDs(ctx context.Context, menuIds []string) ([]models.Menu, error)
+	FindByCategory(ctx context.Context, category string) (models.Menu, error)
+	Insert(ctx context.Context, menu models.Menu) error
+	Update(ctx context.Context, menuId string, fields primitive.D) error
+	Delete(ctx context.Context, menuId string) error
+}
+
+type mongoMenuRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoMenuRepository) List(ctx context.Context) ([]models.Menu, error) {
+	return mongoFind[models.Menu](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	return mongoFindOne[models.Menu](ctx, r.collection, bson.M{"menu_id": menuId})
+}
+
+func (r *mongoMenuRepository) FindByIDs(ctx context.Context, menuIds []string) ([]models.Menu, error) {
+	return mongoFind[models.Menu](ctx, r.collection, bson.M{"menu_id": bson.M{"$in": menuIds}})
+}
+
+func (r *mongoMenuRepository) FindByCategory(ctx context.Context, category string) (models.Menu, error) {
+	return mongoFindOne[models.Menu](ctx, r.collection, bson.M{"category": category})
+}
+
+func (r *mongoMenuRepository) Insert(ctx context.Context, menu models.Menu) error {
+	_, err := r.collection.InsertOne(ctx, menu)
+	return err
+}
+
+func (r *mongoMenuRepository) Update(ctx context.Context, menuId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"menu_id": menuId}, fields)
+}
+
+func (r *mongoMenuRepository) Delete(ctx context.Context, menuId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"menu_id": menuId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type memoryMenuRepository struct {
+	menus *memoryCollection[models.Menu]
+}
+
+func (r *memoryMenuRepository) List(ctx context.Context) ([]models.Menu, error) {
+	return r.menus.find(nil)
+}
+
+func (r *memoryMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.menus.findOne(func(m models.Menu) bool { return m.Menu_id == menuId })
+}
+
+func (r *memoryMenuRepository) FindByIDs(ctx context.Context, menuIds []string) ([]models.Menu, error) {
+	wanted := map[string]bool{}
+	for _, id := range menuIds {
+		wanted[id] = true
+	}
+	return r.menus.find(func(m models.Menu) bool { return wanted[m.Menu_id] })
+}
+
+func (r *memoryMenuRepository) FindByCategory(ctx context.Context, category string) (models.Menu, error) {
+	return r.menus.findOne(func(m models.Menu) bool { return m.Category == category })
+}
+
+func (r *memoryMenuRepository) Insert(ctx context.Context, menu models.Menu) error {
+	return r.menus.insert(menu)
+}
+
+func (r *memoryMenuRepository) Update(ctx context.Context, menuId string, fields primitive.D) error {
+	matched, err := r.menus.set(func(m models.Menu) bool { return m.Menu_id == menuId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryMenuRepository) Delete(ctx context.Context, menuId string) error {
+	return notFoundIfNone(r.menus.delete(func(m models.Menu) bool { return m.Menu_id == menuId }), nil)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// helpers shared by the MongoDB repositories
+
+func mongoFind[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []T{}
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func mongoFindOne[T any](ctx context.Context, collection *mongo.Collection, filter interface{}) (T, error) {
+	var doc T
+
+	err := collection.FindOne(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return doc, ErrNotFound
+	}
+	return doc, err
+}
+
+func mongoPage[T any](ctx context.Context, collection *mongo.Collection, skip int, limit int) ([]T, int64, error) {
+	total, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit))
+	found, err := mongoFind[T](ctx, collection, bson.M{}, opts)
+	return found, total, err
+}
+
+func mongoSet(ctx context.Context, collection *mongo.Collection, filter interface{}, fields primitive.D) error {
+	result, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: fields}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// tableLease is how long a table stays locked by lockTable. It only matters
+// when the service dies while holding the lock.
+const tableLease = 10 * time.Second
+
+// lockTable waits for the lease kept in field of the table document, or for
+// ctx to end, and returns the expiry it wrote.
+func lockTable(ctx context.Context, tables *mongo.Collection, tableId string, field string) (time.Time, error) {
+	for {
+		now := time.Now()
+		// stored dates keep milliseconds, the lease must match its stored form
+		lease := now.Add(tableLease).Truncate(time.Millisecond)
+		result, err := tables.UpdateOne(ctx, bson.M{
+			"table_id": tableId,
+			"$or": bson.A{
+				bson.M{field: bson.M{"$exists": false}},
+				bson.M{field: bson.M{"$lt": now}},
+			},
+		}, bson.M{"$set": bson.M{field: lease}})
+		if err != nil {
+			return time.Time{}, err
+		}
+		if result.MatchedCount > 0 {
+			return lease, nil
+		}
+		if err := tables.FindOne(ctx, bson.M{"table_id": tableId}).Err(); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return time.Time{}, ErrNotFound
+			}
+			return time.Time{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+// unlockTable releases the lease only while it is the one lockTable wrote:
+// once it ran out, someone else may hold the table.
+func unlockTable(tables *mongo.Collection, tableId string, field string, lease time.Time) {
+	tables.UpdateOne(context.Background(), bson.M{"table_id": tableId, field: lease}, bson.M{"$unset": bson.M{field: ""}})
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderItemRepository interface {
+	List(ctx context.Context) ([]models.OrderItem, error)
+	FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error)
+	FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error)
+	Update(ctx context.Context, orderItemId string, fields primitive.D) error
+	// Move files the items of order from under order to. It returns
+	// ErrConflict, and moves nothing, unless every item is still in from.
+	Move(ctx context.Context, orderItemIds []string, from string, to string) error
+}
+
+type mongoOrderItemRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoOrderItemRepository) List(ctx context.Context) ([]models.OrderItem, error) {
+	return mongoFind[models.OrderItem](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return mongoFindOne[models.OrderItem](ctx, r.collection, bson.M{"order_item_id": orderItemId})
+}
+
+func (r *mongoOrderItemRepository) FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return mongoFind[models.OrderItem](ctx, r.collection, bson.M{"order_id": orderId})
+}
+
+func (r *mongoOrderItemRepository) Update(ctx context.Context, orderItemId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"order_item_id": orderItemId}, fields)
+}
+
+func (r *mongoOrderItemRepository) Move(ctx context.Context, orderItemIds []string, from string, to string) error {
+	move := func(from string, to string) (int, error) {
+		result, err := r.collection.UpdateMany(ctx,
+			bson.M{"order_item_id": bson.M{"$in": orderItemIds}, "order_id": from},
+			bson.M{"$set": bson.M{"order_id": to}})
+		if err != nil {
+			return 0, err
+		}
+		return int(result.MatchedCount), nil
+	}
+	moved, err := move(from, to)
+	if err != nil {
+		return err
+	}
+	if moved < len(orderItemIds) {
+		if _, err := move(to, from); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+type memoryOrderItemRepository struct {
+	orderItems *memoryCollection[models.OrderItem]
+}
+
+func (r *memoryOrderItemRepository) List(ctx context.Context) ([]models.OrderItem, error) {
+	return r.orderItems.find(nil)
+}
+
+func (r *memoryOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.orderItems.findOne(func(i models.OrderItem) bool { return i.Order_item_id == orderItemId })
+}
+
+func (r *memoryOrderItemRepository) FindByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return r.orderItems.find(func(i models.OrderItem) bool { return i.Order_id == orderId })
+}
+
+func (r *memoryOrderItemRepository) Update(ctx context.Context, orderItemId string, fields primitive.D) error {
+	matched, err := r.orderItems.set(func(i models.OrderItem) bool { return i.Order_item_id == orderItemId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryOrderItemRepository) Move(ctx context.Context, orderItemIds []string, from string, to string) error {
+	wanted := map[string]bool{}
+	for _, orderItemId := range orderItemIds {
+		wanted[orderItemId] = true
+	}
+	move := func(from string, to string) (int, error) {
+		return r.orderItems.set(func(i models.OrderItem) bool { return wanted[i.Order_item_id] && i.Order_id == from },
+			bson.D{{Key: "order_id", Value: to}})
+	}
+	moved, err := move(from, to)
+	if err != nil {
+		return err
+	}
+	if moved < len(orderItemIds) {
+		if _, err := move(to, from); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
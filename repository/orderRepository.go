@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/database"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderRepository interface {
+	List(ctx context.Context) ([]models.Order, error)
+	FindByID(ctx context.Context, orderId string) (models.Order, error)
+	FindByTable(ctx context.Context, tableId string) ([]models.Order, error)
+	Insert(ctx context.Context, order models.Order) error
+	Update(ctx context.Context, orderId string, fields primitive.D) error
+	// UpdateStatus records the status change, and sets fields with it, only
+	// while the order still has status from (nil for orders without one), and
+	// returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, orderId string, from *string, change models.OrderStatusChange, fields primitive.D) error
+	// SetCoupon replaces the coupon of the order only while it is still from
+	// (nil for none), and returns ErrConflict otherwise.
+	SetCoupon(ctx context.Context, orderId string, from *string, to *string) error
+	// CreateWithItems writes the order and all its items, or nothing at all.
+	CreateWithItems(ctx context.Context, order models.Order, orderItems []models.OrderItem) error
+	// Seat writes the order unless another party still has an open order at
+	// its table; then it returns ErrConflict.
+	Seat(ctx context.Context, order models.Order) error
+	// AddItems writes the items under the order while it is still open, and
+	// returns ErrConflict once it was paid or cancelled.
+	AddItems(ctx context.Context, orderId string, orderItems []models.OrderItem) error
+}
+
+type mongoOrderRepository struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+	orderItems *mongo.Collection
+	tables     *mongo.Collection
+}
+
+func (r *mongoOrderRepository) List(ctx context.Context) ([]models.Order, error) {
+	return mongoFind[models.Order](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	return mongoFindOne[models.Order](ctx, r.collection, bson.M{"order_id": orderId})
+}
+
+func (r *mongoOrderRepository) FindByTable(ctx context.Context, tableId string) ([]models.Order, error) {
+	return mongoFind[models.Order](ctx, r.collection, bson.M{"table_id": tableId})
+}
+
+func (r *mongoOrderRepository) Insert(ctx context.Context, order models.Order) error {
+	_, err := r.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (r *mongoOrderRepository) Update(ctx context.Context, orderId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"order_id": orderId}, fields)
+}
+
+func (r *mongoOrderRepository) UpdateStatus(ctx context.Context, orderId string, from *string, change models.OrderStatusChange, fields primitive.D) error {
+	filter := bson.M{"order_id": orderId, "status": from}
+	set := append(primitive.D{}, fields...)
+	set = append(set, bson.E{Key: "status", Value: change.To}, bson.E{Key: "updated_at", Value: change.Changed_at})
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$push", Value: bson.D{
+			{Key: "status_history", Value: change},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) SetCoupon(ctx context.Context, orderId string, from *string, to *string) error {
+	err := mongoSet(ctx, r.collection, bson.M{"order_id": orderId, "coupon_id": from}, bson.D{
+		{Key: "coupon_id", Value: to},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+// CreateWithItems uses a transaction. A standalone server cannot run
+// transactions, so there the writes are made one after the other and undone by
+// hand if any of them fails.
+func (r *mongoOrderRepository) CreateWithItems(ctx context.Context, order models.Order, orderItems []models.OrderItem) error {
+	docs := make([]interface{}, len(orderItems))
+	for i := range orderItems {
+		docs[i] = orderItems[i]
+	}
+
+	write := func(ctx context.Context) error {
+		if _, err := r.collection.InsertOne(ctx, order); err != nil {
+			return err
+		}
+		_, err := r.orderItems.InsertMany(ctx, docs)
+		return err
+	}
+
+	err := database.WithTransaction(ctx, r.client, write)
+	if !database.IsTransactionNotSupported(err) {
+		return err
+	}
+
+	if err = write(ctx); err != nil {
+		_, itemErr := r.orderItems.DeleteMany(ctx, bson.M{"order_id": order.Order_id})
+		_, orderErr := r.collection.DeleteOne(ctx, bson.M{"order_id": order.Order_id})
+		if itemErr != nil || orderErr != nil {
+			log.Printf("rollback of order %s failed: %v %v", order.Order_id, itemErr, orderErr)
+		}
+	}
+	return err
+}
+
+// Seat takes a short lease on the table document, like Book of the
+// reservations, so parties are seated at one table one at a time.
+func (r *mongoOrderRepository) Seat(ctx context.Context, order models.Order) error {
+	tableId := *order.Table_id
+	lease, err := lockTable(ctx, r.tables, tableId, "seating_lease")
+	if err != nil {
+		return err
+	}
+	defer unlockTable(r.tables, tableId, "seating_lease", lease)
+
+	seated, err := r.collection.CountDocuments(ctx, bson.M{
+		"table_id": tableId,
+		"status":   bson.M{"$nin": models.ClosedOrderStatuses},
+	})
+	if err != nil {
+		return err
+	}
+	if seated > 0 {
+		return ErrConflict
+	}
+	_, err = r.collection.InsertOne(ctx, order)
+	return err
+}
+
+// AddItems uses a transaction like CreateWithItems, and otherwise removes by
+// hand the items written when writing fails.
+func (r *mongoOrderRepository) AddItems(ctx context.Context, orderId string, orderItems []models.OrderItem) error {
+	docs := make([]interface{}, len(orderItems))
+	itemIds := make([]string, len(orderItems))
+	for i := range orderItems {
+		docs[i] = orderItems[i]
+		itemIds[i] = orderItems[i].Order_item_id
+	}
+
+	write := func(ctx context.Context) error {
+		open, err := r.collection.CountDocuments(ctx, bson.M{
+			"order_id": orderId,
+			"status":   bson.M{"$nin": models.ClosedOrderStatuses},
+		})
+		if err != nil {
+			return err
+		}
+		if open == 0 {
+			return ErrConflict
+		}
+		_, err = r.orderItems.InsertMany(ctx, docs)
+		return err
+	}
+
+	err := database.WithTransaction(ctx, r.client, write)
+	if !database.IsTransactionNotSupported(err) {
+		return err
+	}
+
+	if err = write(ctx); err != nil && err != ErrConflict {
+		if _, itemErr := r.orderItems.DeleteMany(ctx, bson.M{"order_item_id": bson.M{"$in": itemIds}}); itemErr != nil {
+			log.Printf("rollback of items for order %s failed: %v", orderId, itemErr)
+		}
+	}
+	return err
+}
+
+type memoryOrderRepository struct {
+	// seating makes the check and the write of Seat one step
+	seating    sync.Mutex
+	orders     *memoryCollection[models.Order]
+	orderItems *memoryCollection[models.OrderItem]
+}
+
+func (r *memoryOrderRepository) List(ctx context.Context) ([]models.Order, error) {
+	return r.orders.find(nil)
+}
+
+func (r *memoryOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	return r.orders.findOne(func(o models.Order) bool { return o.Order_id == orderId })
+}
+
+func (r *memoryOrderRepository) FindByTable(ctx context.Context, tableId string) ([]models.Order, error) {
+	return r.orders.find(func(o models.Order) bool { return o.Table_id != nil && *o.Table_id == tableId })
+}
+
+func (r *memoryOrderRepository) Insert(ctx context.Context, order models.Order) error {
+	return r.orders.insert(order)
+}
+
+func (r *memoryOrderRepository) Update(ctx context.Context, orderId string, fields primitive.D) error {
+	matched, err := r.orders.set(func(o models.Order) bool { return o.Order_id == orderId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryOrderRepository) UpdateStatus(ctx context.Context, orderId string, from *string, change models.OrderStatusChange, fields primitive.D) error {
+	match := func(o models.Order) bool {
+		return o.Order_id == orderId && sameString(o.Status, from)
+	}
+
+	matched, err := r.orders.update(match, func(o *models.Order) error {
+		if err := applySet(o, fields); err != nil {
+			return err
+		}
+		status := change.To
+		o.Status = &status
+		o.Updated_at = change.Changed_at
+		o.Status_history = append(o.Status_history, change)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) SetCoupon(ctx context.Context, orderId string, from *string, to *string) error {
+	match := func(o models.Order) bool {
+		return o.Order_id == orderId && sameString(o.Coupon_id, from)
+	}
+
+	matched, err := r.orders.update(match, func(o *models.Order) error {
+		o.Coupon_id = to
+		o.Updated_at = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) CreateWithItems(ctx context.Context, order models.Order, orderItems []models.OrderItem) error {
+	if err := r.orders.insert(order); err != nil {
+		return err
+	}
+	if err := r.orderItems.insert(orderItems...); err != nil {
+		r.orderItems.delete(func(i models.OrderItem) bool { return i.Order_id == order.Order_id })
+		r.orders.delete(func(o models.Order) bool { return o.Order_id == order.Order_id })
+		return err
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) AddItems(ctx context.Context, orderId string, orderItems []models.OrderItem) error {
+	order, err := r.FindByID(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(models.ClosedOrderStatuses, order.CurrentStatus()) {
+		return ErrConflict
+	}
+	if err := r.orderItems.insert(orderItems...); err != nil {
+		itemIds := map[string]bool{}
+		for _, item := range orderItems {
+			itemIds[item.Order_item_id] = true
+		}
+		r.orderItems.delete(func(i models.OrderItem) bool { return itemIds[i.Order_item_id] })
+		return err
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) Seat(ctx context.Context, order models.Order) error {
+	r.seating.Lock()
+	defer r.seating.Unlock()
+
+	seated, err := r.orders.find(func(o models.Order) bool {
+		if o.Table_id == nil || *o.Table_id != *order.Table_id {
+			return false
+		}
+		for _, closed := range models.ClosedOrderStatuses {
+			if o.CurrentStatus() == closed {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if len(seated) > 0 {
+		return ErrConflict
+	}
+	return r.orders.insert(order)
+}
+
+// sameString compares optional fields the way a MongoDB filter on them matches,
+// nil only matches nil.
+func sameString(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
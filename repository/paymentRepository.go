@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PaymentRepository reads payments, they are written with InvoiceRepository.AddPayment.
+type PaymentRepository interface {
+	FindByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error)
+	// FindBetween returns the payments made from from up to, not including, to.
+	FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Payment, error)
+}
+
+type mongoPaymentRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoPaymentRepository) FindByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return mongoFind[models.Payment](ctx, r.collection, bson.M{"invoice_id": invoiceId}, opts)
+}
+
+func (r *mongoPaymentRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Payment, error) {
+	filter := bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return mongoFind[models.Payment](ctx, r.collection, filter, opts)
+}
+
+type memoryPaymentRepository struct {
+	payments *memoryCollection[models.Payment]
+}
+
+func (r *memoryPaymentRepository) FindByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error) {
+	return r.payments.find(func(p models.Payment) bool { return p.Invoice_id == invoiceId })
+}
+
+func (r *memoryPaymentRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Payment, error) {
+	return r.payments.find(func(p models.Payment) bool {
+		return !p.Created_at.Before(from) && p.Created_at.Before(to)
+	})
+}
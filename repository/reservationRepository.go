@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReservationRepository interface {
+	FindByID(ctx context.Context, reservationId string) (models.Reservation, error)
+	// FindBetween returns the reservations starting from from up to, not
+	// including, to, earliest first.
+	FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error)
+	FindByPhone(ctx context.Context, phone string) ([]models.Reservation, error)
+	// Holding returns the BOOKED and SEATED reservations holding a table at
+	// some point between from and to.
+	Holding(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error)
+	// Book writes the reservation, new or changed, unless another reservation
+	// holds its table in the meantime; then it returns ErrConflict.
+	Book(ctx context.Context, reservation models.Reservation) error
+	// UpdateStatus sets fields only while the reservation still has status
+	// from, and returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, reservationId string, from string, fields primitive.D) error
+}
+
+type mongoReservationRepository struct {
+	collection *mongo.Collection
+	tables     *mongo.Collection
+}
+
+func (r *mongoReservationRepository) FindByID(ctx context.Context, reservationId string) (models.Reservation, error) {
+	return mongoFindOne[models.Reservation](ctx, r.collection, bson.M{"reservation_id": reservationId})
+}
+
+func (r *mongoReservationRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error) {
+	filter := bson.M{"starts_at": bson.M{"$gte": from, "$lt": to}}
+	return mongoFind[models.Reservation](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "starts_at", Value: 1}}))
+}
+
+func (r *mongoReservationRepository) FindByPhone(ctx context.Context, phone string) ([]models.Reservation, error) {
+	return mongoFind[models.Reservation](ctx, r.collection, bson.M{"phone": phone}, options.Find().SetSort(bson.D{{Key: "starts_at", Value: 1}}))
+}
+
+func (r *mongoReservationRepository) Holding(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error) {
+	return mongoFind[models.Reservation](ctx, r.collection, bson.M{
+		"status":        bson.M{"$in": models.HoldingReservationStatuses},
+		"starts_at":     bson.M{"$lt": to},
+		"blocked_until": bson.M{"$gt": from},
+	})
+}
+
+// Book takes a short lease on the table document, so bookings of one table
+// are made one at a time, then checks for overlaps and writes.
+func (r *mongoReservationRepository) Book(ctx context.Context, reservation models.Reservation) error {
+	if reservation.Table_id == nil {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"reservation_id": reservation.Reservation_id}, reservation, options.Replace().SetUpsert(true))
+		return err
+	}
+	tableId := *reservation.Table_id
+
+	lease, err := lockTable(ctx, r.tables, tableId, "booking_lease")
+	if err != nil {
+		return err
+	}
+	defer unlockTable(r.tables, tableId, "booking_lease", lease)
+
+	overlapping, err := r.collection.CountDocuments(ctx, bson.M{
+		"table_id":       tableId,
+		"reservation_id": bson.M{"$ne": reservation.Reservation_id},
+		"status":         bson.M{"$in": models.HoldingReservationStatuses},
+		"starts_at":      bson.M{"$lt": reservation.Blocked_until},
+		"blocked_until":  bson.M{"$gt": reservation.Starts_at},
+	})
+	if err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return ErrConflict
+	}
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"reservation_id": reservation.Reservation_id}, reservation, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoReservationRepository) UpdateStatus(ctx context.Context, reservationId string, from string, fields primitive.D) error {
+	err := mongoSet(ctx, r.collection, bson.M{"reservation_id": reservationId, "status": from}, fields)
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryReservationRepository struct {
+	// booking makes the overlap check and the write of Book one step
+	booking      sync.Mutex
+	reservations *memoryCollection[models.Reservation]
+}
+
+func (r *memoryReservationRepository) FindByID(ctx context.Context, reservationId string) (models.Reservation, error) {
+	return r.reservations.findOne(func(res models.Reservation) bool { return res.Reservation_id == reservationId })
+}
+
+func (r *memoryReservationRepository) FindBetween(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error) {
+	found, err := r.reservations.find(func(res models.Reservation) bool {
+		return res.Starts_at != nil && !res.Starts_at.Before(from) && res.Starts_at.Before(to)
+	})
+	sortByStart(found)
+	return found, err
+}
+
+func (r *memoryReservationRepository) FindByPhone(ctx context.Context, phone string) ([]models.Reservation, error) {
+	found, err := r.reservations.find(func(res models.Reservation) bool { return res.Phone != nil && *res.Phone == phone })
+	sortByStart(found)
+	return found, err
+}
+
+func (r *memoryReservationRepository) Holding(ctx context.Context, from time.Time, to time.Time) ([]models.Reservation, error) {
+	return r.reservations.find(func(res models.Reservation) bool { return res.Holds(from, to) })
+}
+
+func (r *memoryReservationRepository) Book(ctx context.Context, reservation models.Reservation) error {
+	r.booking.Lock()
+	defer r.booking.Unlock()
+
+	if reservation.Table_id != nil {
+		overlapping, err := r.reservations.find(func(res models.Reservation) bool {
+			return res.Reservation_id != reservation.Reservation_id && res.Table_id != nil && *res.Table_id == *reservation.Table_id &&
+				res.Holds(*reservation.Starts_at, reservation.Blocked_until)
+		})
+		if err != nil {
+			return err
+		}
+		if len(overlapping) > 0 {
+			return ErrConflict
+		}
+	}
+
+	matched, err := r.reservations.update(func(res models.Reservation) bool {
+		return res.Reservation_id == reservation.Reservation_id
+	}, func(res *models.Reservation) error {
+		*res = reservation
+		return nil
+	})
+	if err != nil || matched > 0 {
+		return err
+	}
+	return r.reservations.insert(reservation)
+}
+
+func (r *memoryReservationRepository) UpdateStatus(ctx context.Context, reservationId string, from string, fields primitive.D) error {
+	matched, err := r.reservations.set(func(res models.Reservation) bool {
+		return res.Reservation_id == reservationId && res.Status == from
+	}, fields)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func sortByStart(reservations []models.Reservation) {
+	sort.SliceStable(reservations, func(i, j int) bool { return reservations[i].Starts_at.Before(*reservations[j].Starts_at) })
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SessionRepository keeps the login sessions behind the issued tokens. It is the
+// revocation store ValidateToken consults: tokens of a revoked session are refused.
+type SessionRepository interface {
+	StartSession(ctx context.Context, session models.Session) error
+	FindSession(ctx context.Context, sessionId string) (models.Session, error)
+	// RotateRefreshToken swaps the current refresh token id of a session, only if
+	// it still is oldTokenId. It returns false when another refresh won the race.
+	RotateRefreshToken(ctx context.Context, sessionId string, oldTokenId string, newTokenId string, expiresAt time.Time) (bool, error)
+	RevokeSession(ctx context.Context, sessionId string) error
+	RevokeUserSessions(ctx context.Context, userId string) error
+	IsRevoked(ctx context.Context, sessionId string) (bool, error)
+}
+
+type mongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoSessionRepository) StartSession(ctx context.Context, session models.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *mongoSessionRepository) FindSession(ctx context.Context, sessionId string) (models.Session, error) {
+	return mongoFindOne[models.Session](ctx, r.collection, bson.M{"session_id": sessionId})
+}
+
+func (r *mongoSessionRepository) RotateRefreshToken(ctx context.Context, sessionId string, oldTokenId string, newTokenId string, expiresAt time.Time) (bool, error) {
+	filter := bson.M{"session_id": sessionId, "refresh_token_id": oldTokenId, "revoked": false}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "refresh_token_id", Value: newTokenId},
+		{Key: "expires_at", Value: expiresAt},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoSessionRepository) RevokeSession(ctx context.Context, sessionId string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"session_id": sessionId}, revokeUpdate())
+	return err
+}
+
+func (r *mongoSessionRepository) RevokeUserSessions(ctx context.Context, userId string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userId, "revoked": false}, revokeUpdate())
+	return err
+}
+
+func (r *mongoSessionRepository) IsRevoked(ctx context.Context, sessionId string) (bool, error) {
+	session, err := r.FindSession(ctx, sessionId)
+	if err == ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return session.Revoked, nil
+}
+
+func revokeUpdate() bson.D {
+	now := time.Now()
+	return bson.D{{Key: "$set", Value: bson.D{
+		{Key: "revoked", Value: true},
+		{Key: "revoked_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}}}
+}
+
+type memorySessionRepository struct {
+	sessions *memoryCollection[models.Session]
+}
+
+func (r *memorySessionRepository) StartSession(ctx context.Context, session models.Session) error {
+	return r.sessions.insert(session)
+}
+
+func (r *memorySessionRepository) FindSession(ctx context.Context, sessionId string) (models.Session, error) {
+	return r.sessions.findOne(func(s models.Session) bool { return s.Session_id == sessionId })
+}
+
+func (r *memorySessionRepository) RotateRefreshToken(ctx context.Context, sessionId string, oldTokenId string, newTokenId string, expiresAt time.Time) (bool, error) {
+	match := func(s models.Session) bool {
+		return s.Session_id == sessionId && s.Refresh_token_id == oldTokenId && !s.Revoked
+	}
+	matched, err := r.sessions.update(match, func(s *models.Session) error {
+		s.Refresh_token_id = newTokenId
+		s.Expires_at = expiresAt
+		s.Updated_at = time.Now()
+		return nil
+	})
+	return matched == 1, err
+}
+
+func (r *memorySessionRepository) RevokeSession(ctx context.Context, sessionId string) error {
+	_, err := r.sessions.update(func(s models.Session) bool { return s.Session_id == sessionId }, revoke)
+	return err
+}
+
+func (r *memorySessionRepository) RevokeUserSessions(ctx context.Context, userId string) error {
+	_, err := r.sessions.update(func(s models.Session) bool { return s.User_id == userId && !s.Revoked }, revoke)
+	return err
+}
+
+func (r *memorySessionRepository) IsRevoked(ctx context.Context, sessionId string) (bool, error) {
+	session, err := r.FindSession(ctx, sessionId)
+	if err == ErrNotFound {
+		return true, nil
+	}
+	return session.Revoked, err
+}
+
+func revoke(session *models.Session) error {
+	now := time.Now()
+	session.Revoked = true
+	session.Revoked_at = &now
+	session.Updated_at = now
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is returned when no document matches the id or filter.
+var ErrNotFound = errors.New("document not found")
+
+// ErrConflict is returned by conditional updates when the document was changed in the meantime.
+var ErrConflict = errors.New("document was changed concurrently")
+
+// Store bundles the repositories the handlers work with. Use NewMongoStore in
+// the service and NewMemoryStore in tests.
+type Store struct {
+	Users        UserRepository
+	Foods        FoodRepository
+	Menus        MenuRepository
+	Combos       ComboRepository
+	Tables       TableRepository
+	TableGroups  TableGroupRepository
+	Orders       OrderRepository
+	OrderItems   OrderItemRepository
+	Invoices     InvoiceRepository
+	Sessions     SessionRepository
+	Coupons      CouponRepository
+	Payments     PaymentRepository
+	Adjustments  AdjustmentRepository
+	Tickets      KitchenTicketRepository
+	Webhooks     WebhookRepository
+	Deliveries   WebhookDeliveryRepository
+	Reservations ReservationRepository
+	Waitlist     WaitlistRepository
+	GuestOrders  GuestOrderRepository
+}
+
+func NewMongoStore(client *mongo.Client, db *mongo.Database) *Store {
+	orderItems := db.Collection("orderItem")
+	payments := db.Collection("payment")
+	tables := db.Collection("table")
+
+	return &Store{
+		// accounts were always saved in users; see Upgrading in README.md
+		// about the user collection older versions wrote tokens to
+		Users:        &mongoUserRepository{collection: db.Collection("users")},
+		Foods:        &mongoFoodRepository{collection: db.Collection("food")},
+		Menus:        &mongoMenuRepository{collection: db.Collection("menu")},
+		Combos:       &mongoComboRepository{collection: db.Collection("combo")},
+		Tables:       &mongoTableRepository{collection: tables},
+		TableGroups:  &mongoTableGroupRepository{collection: db.Collection("tableGroup")},
+		Orders:       &mongoOrderRepository{client: client, collection: db.Collection("order"), orderItems: orderItems, tables: tables},
+		OrderItems:   &mongoOrderItemRepository{collection: orderItems},
+		Invoices:     &mongoInvoiceRepository{client: client, collection: db.Collection("invoice"), payments: payments},
+		Sessions:     &mongoSessionRepository{collection: db.Collection("session")},
+		Coupons:      &mongoCouponRepository{collection: db.Collection("coupon")},
+		Payments:     &mongoPaymentRepository{collection: payments},
+		Adjustments:  &mongoAdjustmentRepository{collection: db.Collection("adjustment")},
+		Tickets:      &mongoKitchenTicketRepository{collection: db.Collection("kitchenTicket")},
+		Webhooks:     &mongoWebhookRepository{collection: db.Collection("webhook")},
+		Deliveries:   &mongoWebhookDeliveryRepository{collection: db.Collection("webhookDelivery")},
+		Reservations: &mongoReservationRepository{collection: db.Collection("reservation"), tables: tables},
+		Waitlist:     &mongoWaitlistRepository{collection: db.Collection("waitlist")},
+		GuestOrders:  &mongoGuestOrderRepository{collection: db.Collection("guestOrder")},
+	}
+}
+
+// NewMemoryStore keeps everything in process memory, so tests can run the whole
+// API without a MongoDB server.
+func NewMemoryStore() *Store {
+	orderItems := &memoryCollection[models.OrderItem]{}
+	payments := &memoryCollection[models.Payment]{}
+
+	return &Store{
+		Users:        &memoryUserRepository{users: &memoryCollection[models.User]{}},
+		Foods:        &memoryFoodRepository{foods: &memoryCollection[models.Food]{}},
+		Menus:        &memoryMenuRepository{menus: &memoryCollection[models.Menu]{}},
+		Combos:       &memoryComboRepository{combos: &memoryCollection[models.Combo]{}},
+		Tables:       &memoryTableRepository{tables: &memoryCollection[models.Table]{}},
+		TableGroups:  &memoryTableGroupRepository{groups: &memoryCollection[models.TableGroup]{}},
+		Orders:       &memoryOrderRepository{orders: &memoryCollection[models.Order]{}, orderItems: orderItems},
+		OrderItems:   &memoryOrderItemRepository{orderItems: orderItems},
+		Invoices:     &memoryInvoiceRepository{invoices: &memoryCollection[models.Invoice]{}, payments: payments},
+		Sessions:     &memorySessionRepository{sessions: &memoryCollection[models.Session]{}},
+		Coupons:      &memoryCouponRepository{coupons: &memoryCollection[models.Coupon]{}},
+		Payments:     &memoryPaymentRepository{payments: payments},
+		Adjustments:  &memoryAdjustmentRepository{adjustments: &memoryCollection[models.Adjustment]{}},
+		Tickets:      &memoryKitchenTicketRepository{tickets: &memoryCollection[models.KitchenTicket]{}},
+		Webhooks:     &memoryWebhookRepository{webhooks: &memoryCollection[models.Webhook]{}},
+		Deliveries:   &memoryWebhookDeliveryRepository{deliveries: &memoryCollection[models.WebhookDelivery]{}},
+		Reservations: &memoryReservationRepository{reservations: &memoryCollection[models.Reservation]{}},
+		Waitlist:     &memoryWaitlistRepository{entries: &memoryCollection[models.WaitlistEntry]{}},
+		GuestOrders:  &memoryGuestOrderRepository{guestOrders: &memoryCollection[models.GuestOrder]{}},
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TableGroupRepository interface {
+	List(ctx context.Context) ([]models.TableGroup, error)
+	FindByID(ctx context.Context, groupId string) (models.TableGroup, error)
+	Insert(ctx context.Context, group models.TableGroup) error
+	Delete(ctx context.Context, groupId string) error
+}
+
+type mongoTableGroupRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoTableGroupRepository) List(ctx context.Context) ([]models.TableGroup, error) {
+	return mongoFind[models.TableGroup](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoTableGroupRepository) FindByID(ctx context.Context, groupId string) (models.TableGroup, error) {
+	return mongoFindOne[models.TableGroup](ctx, r.collection, bson.M{"group_id": groupId})
+}
+
+func (r *mongoTableGroupRepository) Insert(ctx context.Context, group models.TableGroup) error {
+	_, err := r.collection.InsertOne(ctx, group)
+	return err
+}
+
+func (r *mongoTableGroupRepository) Delete(ctx context.Context, groupId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"group_id": groupId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type memoryTableGroupRepository struct {
+	groups *memoryCollection[models.TableGroup]
+}
+
+func (r *memoryTableGroupRepository) List(ctx context.Context) ([]models.TableGroup, error) {
+	return r.groups.find(nil)
+}
+
+func (r *memoryTableGroupRepository) FindByID(ctx context.Context, groupId string) (models.TableGroup, error) {
+	return r.groups.findOne(func(g models.TableGroup) bool { return g.Group_id == groupId })
+}
+
+func (r *memoryTableGroupRepository) Insert(ctx context.Context, group models.TableGroup) error {
+	return r.groups.insert(group)
+}
+
+func (r *memoryTableGroupRepository) Delete(ctx context.Context, groupId string) error {
+	return notFoundIfNone(r.groups.delete(func(g models.TableGroup) bool { return g.Group_id == groupId }), nil)
+}
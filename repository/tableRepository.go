@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TableRepository interface {
+	List(ctx context.Context) ([]models.Table, error)
+	FindByID(ctx context.Context, tableId string) (models.Table, error)
+	Insert(ctx context.Context, table models.Table) error
+	Update(ctx context.Context, tableId string, fields primitive.D) error
+	// Join puts the tables in the group. It returns ErrConflict, and leaves
+	// the tables as they were, when one of them is already in a group.
+	Join(ctx context.Context, tableIds []string, groupId string) error
+	// Leave takes every table out of the group.
+	Leave(ctx context.Context, groupId string) error
+}
+
+type mongoTableRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoTableRepository) List(ctx context.Context) ([]models.Table, error) {
+	return mongoFind[models.Table](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	return mongoFindOne[models.Table](ctx, r.collection, bson.M{"table_id": tableId})
+}
+
+func (r *mongoTableRepository) Insert(ctx context.Context, table models.Table) error {
+	_, err := r.collection.InsertOne(ctx, table)
+	return err
+}
+
+func (r *mongoTableRepository) Update(ctx context.Context, tableId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"table_id": tableId}, fields)
+}
+
+func (r *mongoTableRepository) Join(ctx context.Context, tableIds []string, groupId string) error {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"table_id": bson.M{"$in": tableIds}, "group_id": nil},
+		bson.M{"$set": bson.M{"group_id": groupId}})
+	if err != nil {
+		return err
+	}
+	if int(result.MatchedCount) < len(tableIds) {
+		if err := r.Leave(ctx, groupId); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoTableRepository) Leave(ctx context.Context, groupId string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"group_id": groupId}, bson.M{"$set": bson.M{"group_id": nil}})
+	return err
+}
+
+type memoryTableRepository struct {
+	tables *memoryCollection[models.Table]
+}
+
+func (r *memoryTableRepository) List(ctx context.Context) ([]models.Table, error) {
+	return r.tables.find(nil)
+}
+
+func (r *memoryTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	return r.tables.findOne(func(t models.Table) bool { return t.Table_id == tableId })
+}
+
+func (r *memoryTableRepository) Insert(ctx context.Context, table models.Table) error {
+	return r.tables.insert(table)
+}
+
+func (r *memoryTableRepository) Update(ctx context.Context, tableId string, fields primitive.D) error {
+	matched, err := r.tables.set(func(t models.Table) bool { return t.Table_id == tableId }, fields)
+	return notFoundIfNone(matched, err)
+}
+
+func (r *memoryTableRepository) Join(ctx context.Context, tableIds []string, groupId string) error {
+	wanted := map[string]bool{}
+	for _, tableId := range tableIds {
+		wanted[tableId] = true
+	}
+	matched, err := r.tables.set(func(t models.Table) bool { return wanted[t.Table_id] && t.Group_id == nil },
+		bson.D{{Key: "group_id", Value: groupId}})
+	if err != nil {
+		return err
+	}
+	if matched < len(tableIds) {
+		if err := r.Leave(ctx, groupId); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *memoryTableRepository) Leave(ctx context.Context, groupId string) error {
+	_, err := r.tables.set(func(t models.Table) bool { return t.Group_id != nil && *t.Group_id == groupId },
+		bson.D{{Key: "group_id", Value: nil}})
+	return err
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserRepository interface {
+	List(ctx context.Context, skip int, limit int) ([]models.User, int64, error)
+	FindByID(ctx context.Context, userId string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	CountByEmail(ctx context.Context, email string) (int64, error)
+	CountByPhone(ctx context.Context, phone string) (int64, error)
+	Insert(ctx context.Context, user models.User) error
+	UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error
+}
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, skip int, limit int) ([]models.User, int64, error) {
+	return mongoPage[models.User](ctx, r.collection, skip, limit)
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return mongoFindOne[models.User](ctx, r.collection, bson.M{"user_id": userId})
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return mongoFindOne[models.User](ctx, r.collection, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"phone": phone})
+}
+
+func (r *mongoUserRepository) Insert(ctx context.Context, user models.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUserRepository) UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error {
+	return mongoSet(ctx, r.collection, bson.M{"user_id": userId}, tokenFields(token, refreshToken))
+}
+
+func tokenFields(token string, refreshToken string) primitive.D {
+	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return primitive.D{
+		{Key: "token", Value: token},
+		{Key: "refresh_token", Value: refreshToken},
+		{Key: "updated_at", Value: Updated_at},
+	}
+}
+
+type memoryUserRepository struct {
+	users *memoryCollection[models.User]
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, skip int, limit int) ([]models.User, int64, error) {
+	return r.users.page(nil, skip, limit)
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return r.users.findOne(func(u models.User) bool { return u.User_id == userId })
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.users.findOne(func(u models.User) bool { return u.Email != nil && *u.Email == email })
+}
+
+func (r *memoryUserRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	found, err := r.users.find(func(u models.User) bool { return u.Email != nil && *u.Email == email })
+	return int64(len(found)), err
+}
+
+func (r *memoryUserRepository) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	found, err := r.users.find(func(u models.User) bool { return u.Phone != nil && *u.Phone == phone })
+	return int64(len(found)), err
+}
+
+func (r *memoryUserRepository) Insert(ctx context.Context, user models.User) error {
+	return r.users.insert(user)
+}
+
+func (r *memoryUserRepository) UpdateTokens(ctx context.Context, userId string, token string, refreshToken string) error {
+	matched, err := r.users.set(func(u models.User) bool { return u.User_id == userId }, tokenFields(token, refreshToken))
+	return notFoundIfNone(matched, err)
+}
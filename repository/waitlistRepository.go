@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WaitlistRepository interface {
+	// List returns the entries having one of statuses, in the order the
+	// parties arrived.
+	List(ctx context.Context, statuses []string) ([]models.WaitlistEntry, error)
+	FindByID(ctx context.Context, waitlistId string) (models.WaitlistEntry, error)
+	Insert(ctx context.Context, entry models.WaitlistEntry) error
+	// UpdateStatus sets fields only while the entry still has status from,
+	// and returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, waitlistId string, from string, fields primitive.D) error
+}
+
+type mongoWaitlistRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoWaitlistRepository) List(ctx context.Context, statuses []string) ([]models.WaitlistEntry, error) {
+	filter := bson.M{"status": bson.M{"$in": statuses}}
+	return mongoFind[models.WaitlistEntry](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (r *mongoWaitlistRepository) FindByID(ctx context.Context, waitlistId string) (models.WaitlistEntry, error) {
+	return mongoFindOne[models.WaitlistEntry](ctx, r.collection, bson.M{"waitlist_id": waitlistId})
+}
+
+func (r *mongoWaitlistRepository) Insert(ctx context.Context, entry models.WaitlistEntry) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoWaitlistRepository) UpdateStatus(ctx context.Context, waitlistId string, from string, fields primitive.D) error {
+	err := mongoSet(ctx, r.collection, bson.M{"waitlist_id": waitlistId, "status": from}, fields)
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryWaitlistRepository struct {
+	entries *memoryCollection[models.WaitlistEntry]
+}
+
+func (r *memoryWaitlistRepository) List(ctx context.Context, statuses []string) ([]models.WaitlistEntry, error) {
+	return r.entries.find(func(e models.WaitlistEntry) bool {
+		for _, status := range statuses {
+			if e.Status == status {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memoryWaitlistRepository) FindByID(ctx context.Context, waitlistId string) (models.WaitlistEntry, error) {
+	return r.entries.findOne(func(e models.WaitlistEntry) bool { return e.Waitlist_id == waitlistId })
+}
+
+func (r *memoryWaitlistRepository) Insert(ctx context.Context, entry models.WaitlistEntry) error {
+	return r.entries.insert(entry)
+}
+
+func (r *memoryWaitlistRepository) UpdateStatus(ctx context.Context, waitlistId string, from string, fields primitive.D) error {
+	matched, err := r.entries.set(func(e models.WaitlistEntry) bool {
+		return e.Waitlist_id == waitlistId && e.Status == from
+	}, fields)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
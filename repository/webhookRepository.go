@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository interface {
+	List(ctx context.Context) ([]models.Webhook, error)
+	FindByID(ctx context.Context, webhookId string) (models.Webhook, error)
+	Insert(ctx context.Context, webhook models.Webhook) error
+	Update(ctx context.Context, webhookId string, fields primitive.D) error
+	Delete(ctx context.Context, webhookId string) error
+}
+
+type WebhookDeliveryRepository interface {
+	// List returns the deliveries of webhookId ("" for every webhook) with the
+	// status ("" for all), newest first.
+	List(ctx context.Context, webhookId string, status string) ([]models.WebhookDelivery, error)
+	FindByID(ctx context.Context, deliveryId string) (models.WebhookDelivery, error)
+	// Due returns up to limit PENDING deliveries whose next attempt is not
+	// after now, the longest waiting first.
+	Due(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	Insert(ctx context.Context, deliveries ...models.WebhookDelivery) error
+	// UpdateStatus sets fields only while the delivery still has status from,
+	// and returns ErrConflict otherwise.
+	UpdateStatus(ctx context.Context, deliveryId string, from string, fields primitive.D) error
+}
+
+type mongoWebhookRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoWebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	return mongoFind[models.Webhook](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoWebhookRepository) FindByID(ctx context.Context, webhookId string) (models.Webhook, error) {
+	return mongoFindOne[models.Webhook](ctx, r.collection, bson.M{"webhook_id": webhookId})
+}
+
+func (r *mongoWebhookRepository) Insert(ctx context.Context, webhook models.Webhook) error {
+	_, err := r.collection.InsertOne(ctx, webhook)
+	return err
+}
+
+func (r *mongoWebhookRepository) Update(ctx context.Context, webhookId string, fields primitive.D) error {
+	return mongoSet(ctx, r.collection, bson.M{"webhook_id": webhookId}, fields)
+}
+
+func (r *mongoWebhookRepository) Delete(ctx context.Context, webhookId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"webhook_id": webhookId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type memoryWebhookRepository struct {
+	webhooks *memoryCollection[models.Webhook]
+}
+
+func (r *memoryWebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	return r.webhooks.find(nil)
+}
+
+func (r *memoryWebhookRepository) FindByID(ctx context.Context, webhookId string) (models.Webhook, error) {
+	return r.webhooks.findOne(func(w models.Webhook) bool { return w.Webhook_id == webhookId })
+}
+
+func (r *memoryWebhookRepository) Insert(ctx context.Context, webhook models.Webhook) error {
+	return r.webhooks.insert(webhook)
+}
+
+func (r *memoryWebhookRepository) Update(ctx context.Context, webhookId string, fields primitive.D) error {
+	return notFoundIfNone(r.webhooks.set(func(w models.Webhook) bool { return w.Webhook_id == webhookId }, fields))
+}
+
+func (r *memoryWebhookRepository) Delete(ctx context.Context, webhookId string) error {
+	return notFoundIfNone(r.webhooks.delete(func(w models.Webhook) bool { return w.Webhook_id == webhookId }), nil)
+}
+
+type mongoWebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoWebhookDeliveryRepository) List(ctx context.Context, webhookId string, status string) ([]models.WebhookDelivery, error) {
+	filter := bson.M{}
+	if webhookId != "" {
+		filter["webhook_id"] = webhookId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	return mongoFind[models.WebhookDelivery](ctx, r.collection, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+}
+
+func (r *mongoWebhookDeliveryRepository) FindByID(ctx context.Context, deliveryId string) (models.WebhookDelivery, error) {
+	return mongoFindOne[models.WebhookDelivery](ctx, r.collection, bson.M{"delivery_id": deliveryId})
+}
+
+func (r *mongoWebhookDeliveryRepository) Due(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	filter := bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).SetLimit(int64(limit))
+	return mongoFind[models.WebhookDelivery](ctx, r.collection, filter, opts)
+}
+
+func (r *mongoWebhookDeliveryRepository) Insert(ctx context.Context, deliveries ...models.WebhookDelivery) error {
+	docs := make([]interface{}, len(deliveries))
+	for i := range deliveries {
+		docs[i] = deliveries[i]
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoWebhookDeliveryRepository) UpdateStatus(ctx context.Context, deliveryId string, from string, fields primitive.D) error {
+	err := mongoSet(ctx, r.collection, bson.M{"delivery_id": deliveryId, "status": from}, fields)
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+type memoryWebhookDeliveryRepository struct {
+	deliveries *memoryCollection[models.WebhookDelivery]
+}
+
+func (r *memoryWebhookDeliveryRepository) List(ctx context.Context, webhookId string, status string) ([]models.WebhookDelivery, error) {
+	found, err := r.deliveries.find(func(d models.WebhookDelivery) bool {
+		return (webhookId == "" || d.Webhook_id == webhookId) && (status == "" || d.Status == status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Created_at.After(found[j].Created_at) })
+	return found, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) FindByID(ctx context.Context, deliveryId string) (models.WebhookDelivery, error) {
+	return r.deliveries.findOne(func(d models.WebhookDelivery) bool { return d.Delivery_id == deliveryId })
+}
+
+func (r *memoryWebhookDeliveryRepository) Due(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	due, err := r.deliveries.find(func(d models.WebhookDelivery) bool {
+		return d.Status == models.DeliveryPending && d.Next_attempt_at != nil && !d.Next_attempt_at.After(now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Next_attempt_at.Before(*due[j].Next_attempt_at) })
+	return due[:min(limit, len(due))], nil
+}
+
+func (r *memoryWebhookDeliveryRepository) Insert(ctx context.Context, deliveries ...models.WebhookDelivery) error {
+	return r.deliveries.insert(deliveries...)
+}
+
+func (r *memoryWebhookDeliveryRepository) UpdateStatus(ctx context.Context, deliveryId string, from string, fields primitive.D) error {
+	matched, err := r.deliveries.set(func(d models.WebhookDelivery) bool {
+		return d.Delivery_id == deliveryId && d.Status == from
+	}, fields)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrConflict
+	}
+	return nil
+}
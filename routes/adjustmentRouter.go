@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+var adjustmentPolicy = middleware.Policy{
+	"GET /adjustments":                         billingRoles,
+	"GET /adjustments/:adjustment_id":          billingRoles,
+	"POST /adjustments":                        billingRoles,
+	"POST /adjustments/:adjustment_id/approve": managerRoles,
+	"POST /adjustments/:adjustment_id/reject":  managerRoles,
+}
+
+func AdjustmentRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(adjustmentPolicy))
+
+	authorized.GET("/adjustments", controller.GetAdjustments(store))
+	authorized.GET("/adjustments/:adjustment_id", controller.GetAdjustment(store))
+	authorized.POST("/adjustments", controller.CreateAdjustment(store))
+	authorized.POST("/adjustments/:adjustment_id/approve", controller.ApproveAdjustment(store))
+	authorized.POST("/adjustments/:adjustment_id/reject", controller.RejectAdjustment(store))
+}
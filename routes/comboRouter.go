@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+var comboPolicy = middleware.Policy{
+	"GET /combos":             allRoles,
+	"GET /combos/:combo_id":   allRoles,
+	"POST /combos":            managerRoles,
+	"PATCH /combos/:combo_id": managerRoles,
+}
+
+func ComboRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(comboPolicy))
+
+	authorized.GET("/combos", controller.GetCombos(store))
+	authorized.GET("/combos/:combo_id", controller.GetCombo(store))
+	authorized.POST("/combos", controller.CreateCombo(store))
+	authorized.PATCH("/combos/:combo_id", controller.UpdateCombo(store))
+}
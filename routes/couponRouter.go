@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+var couponPolicy = middleware.Policy{
+	"GET /coupons":              billingRoles,
+	"GET /coupons/:coupon_id":   billingRoles,
+	"POST /coupons":             managerRoles,
+	"PATCH /coupons/:coupon_id": managerRoles,
+}
+
+func CouponRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(couponPolicy))
+
+	authorized.GET("/coupons", controller.GetCoupons(store))
+	authorized.GET("/coupons/:coupon_id", controller.GetCoupon(store))
+	authorized.POST("/coupons", controller.CreateCoupon(store))
+	authorized.PATCH("/coupons/:coupon_id", controller.UpdateCoupon(store))
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+)
+
+var eventPolicy = middleware.Policy{
+	"GET /events": staffRoles,
+}
+
+func EventRoutes(incomingRoutes *gin.Engine) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(eventPolicy))
+
+	authorized.GET("/events", controller.StreamEvents())
+}
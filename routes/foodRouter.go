@@ -3,13 +3,25 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func FoodRoutes(incomingRoutes *gin.Engine) {
+var foodPolicy = middleware.Policy{
+	"GET /foods":            allRoles,
+	"GET /foods/:food_id":   allRoles,
+	"POST /foods":           managerRoles,
+	"PATCH /foods":          managerRoles,
+	"PATCH /foods/:food_id": managerRoles,
+}
+
+func FoodRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(foodPolicy))
 
-	incomingRoutes.GET("/foods", controller.GetFoods())
-	incomingRoutes.GET("/foods/:food_id", controller.GetFood())
-	incomingRoutes.POST("/foods", controller.CreateFood())
-	incomingRoutes.PATCH("/foods", controller.UpdateFood())
+	authorized.GET("/foods", controller.GetFoods(store))
+	authorized.GET("/foods/:food_id", controller.GetFood(store))
+	authorized.POST("/foods", controller.CreateFood(store))
+	authorized.PATCH("/foods", controller.UpdateFood(store))
+	authorized.PATCH("/foods/:food_id", controller.UpdateFood(store))
 
 }
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// guestOrderPolicy lets managers print the table codes and the floor staff
+// review what guests ordered with them
+var guestOrderPolicy = middleware.Policy{
+	"GET /tables/:table_id/qr":                   managerRoles,
+	"GET /tables/:table_id/guest-link":           managerRoles,
+	"GET /guest-orders":                          floorRoles,
+	"POST /guest-orders/:guest_order_id/confirm": floorRoles,
+	"POST /guest-orders/:guest_order_id/reject":  floorRoles,
+}
+
+// GuestRoutes are the routes guests order with from their table. They are
+// registered before the global Authentication middleware: guests have a guest
+// token, which staff routes do not accept, and staff tokens do not open these.
+func GuestRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	incomingRoutes.POST("/guest/sessions", controller.CreateGuestSession(store))
+
+	guest := incomingRoutes.Group("/guest", middleware.GuestAuthentication())
+	guest.GET("/menu", controller.GetGuestMenu(store))
+	guest.GET("/cart", controller.GetGuestCart(store))
+	guest.POST("/cart/items", controller.AddGuestCartItem(store))
+	guest.DELETE("/cart/items/:item_id", controller.RemoveGuestCartItem(store))
+	guest.POST("/cart/submit", controller.SubmitGuestCart(store))
+	guest.GET("/orders", controller.GetGuestOrders(store))
+}
+
+// GuestOrderRoutes are the staff side of guest ordering.
+func GuestOrderRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(guestOrderPolicy))
+
+	authorized.GET("/tables/:table_id/qr", controller.GetTableQRCode(store))
+	authorized.GET("/tables/:table_id/guest-link", controller.GetTableGuestLink(store))
+	authorized.GET("/guest-orders", controller.GetGuestOrderQueue(store))
+	authorized.POST("/guest-orders/:guest_order_id/confirm", controller.ConfirmGuestOrder(store))
+	authorized.POST("/guest-orders/:guest_order_id/reject", controller.RejectGuestOrder(store))
+}
@@ -3,13 +3,35 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func InvoiceRoutes(incomingRoutes *gin.Engine) {
+var invoicePolicy = middleware.Policy{
+	"GET /invoices":               billingRoles,
+	"GET /invoices/:invoice_id":   billingRoles,
+	"POST /invoices":              billingRoles,
+	"PATCH /invoices/:invoice_id": cashierRoles,
 
-	incomingRoutes.GET("/invoices", controller.GetInvoices())
-	incomingRoutes.GET("/invoices/:invoice_id", controller.GetInvoice())
-	incomingRoutes.POST("/invoices", controller.CreateInvoice())
-	incomingRoutes.PATCH("/invoices/:invoice_id", controller.UpdateInvoice())
+	"GET /invoices/:invoice_id/payments":  billingRoles,
+	"POST /invoices/:invoice_id/payments": billingRoles,
+	"GET /invoices/:invoice_id/split":     billingRoles,
+
+	"GET /invoices/:invoice_id/pdf":  billingRoles,
+	"GET /invoices/:invoice_id/html": billingRoles,
+}
+
+func InvoiceRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(invoicePolicy))
+
+	authorized.GET("/invoices", controller.GetInvoices(store))
+	authorized.GET("/invoices/:invoice_id", controller.GetInvoice(store))
+	authorized.POST("/invoices", controller.CreateInvoice(store))
+	authorized.PATCH("/invoices/:invoice_id", controller.UpdateInvoice(store))
+	authorized.GET("/invoices/:invoice_id/payments", controller.GetInvoicePayments(store))
+	authorized.POST("/invoices/:invoice_id/payments", controller.CreatePayment(store))
+	authorized.GET("/invoices/:invoice_id/split", controller.SplitInvoice(store))
+	authorized.GET("/invoices/:invoice_id/pdf", controller.GetInvoicePDF(store))
+	authorized.GET("/invoices/:invoice_id/html", controller.GetInvoiceHTML(store))
 
 }
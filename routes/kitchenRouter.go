@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// kitchenPolicy lets the whole staff watch the tickets, servers wait for READY
+// ones, while only the kitchen works them
+var kitchenPolicy = middleware.Policy{
+	"GET /kitchen/tickets":                    staffRoles,
+	"GET /kitchen/tickets/:ticket_id":         staffRoles,
+	"POST /kitchen/tickets/:ticket_id/start":  kitchenRoles,
+	"POST /kitchen/tickets/:ticket_id/ready":  kitchenRoles,
+	"POST /kitchen/tickets/:ticket_id/bump":   kitchenRoles,
+	"POST /kitchen/tickets/:ticket_id/recall": kitchenRoles,
+	"GET /kitchen/stream":                     staffRoles,
+}
+
+func KitchenRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(kitchenPolicy))
+
+	authorized.GET("/kitchen/tickets", controller.GetKitchenTickets(store))
+	authorized.GET("/kitchen/tickets/:ticket_id", controller.GetKitchenTicket(store))
+	authorized.POST("/kitchen/tickets/:ticket_id/start", controller.StartKitchenTicket(store))
+	authorized.POST("/kitchen/tickets/:ticket_id/ready", controller.ReadyKitchenTicket(store))
+	authorized.POST("/kitchen/tickets/:ticket_id/bump", controller.BumpKitchenTicket(store))
+	authorized.POST("/kitchen/tickets/:ticket_id/recall", controller.RecallKitchenTicket(store))
+	authorized.GET("/kitchen/stream", controller.KitchenStream(store))
+}
@@ -3,12 +3,28 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func MenuRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/menus", controller.GetMenus())
-	incomingRoutes.GET("/menus/:menu_id", controller.GetMenuByID())
-	incomingRoutes.POST("menus", controller.CreateMenu())
-	incomingRoutes.PATCH("/menus", controller.UpdateMenu())
-	incomingRoutes.DELETE("/menus/:menu_id", controller.DeleteMenu())
+var menuPolicy = middleware.Policy{
+	"GET /menus":             allRoles,
+	"GET /menus/active":      allRoles,
+	"GET /menus/:menu_id":    allRoles,
+	"POST /menus":            managerRoles,
+	"PATCH /menus":           managerRoles,
+	"PATCH /menus/:menu_id":  managerRoles,
+	"DELETE /menus/:menu_id": managerRoles,
+}
+
+func MenuRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(menuPolicy))
+
+	authorized.GET("/menus", controller.GetMenus(store))
+	authorized.GET("/menus/active", controller.GetActiveMenus(store))
+	authorized.GET("/menus/:menu_id", controller.GetMenuByID(store))
+	authorized.POST("menus", controller.CreateMenu(store))
+	authorized.PATCH("/menus", controller.UpdateMenu(store))
+	authorized.PATCH("/menus/:menu_id", controller.UpdateMenu(store))
+	authorized.DELETE("/menus/:menu_id", controller.DeleteMenu(store))
 }
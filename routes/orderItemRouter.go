@@ -3,13 +3,25 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func OrderItemRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/ordersItems", controller.GetOrderItems())
-	incomingRoutes.GET("/orderItemss/:orderItem_id", controller.GetOrderItem())
-	incomingRoutes.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder())
-	incomingRoutes.POST("orderItems", controller.CreateOrderItem())
-	incomingRoutes.PATCH("/orderItems/:orderItem_id", controller.UpdateOrderItem())
+var orderItemPolicy = middleware.Policy{
+	"GET /ordersItems":                staffRoles,
+	"GET /orderItemss/:orderItem_id":  staffRoles,
+	"GET /orderItems-order/:order_id": staffRoles,
+	"POST /orderItems":                floorRoles,
+	"PATCH /orderItems/:orderItem_id": floorRoles,
+}
+
+func OrderItemRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(orderItemPolicy))
+
+	authorized.GET("/ordersItems", controller.GetOrderItems(store))
+	authorized.GET("/orderItemss/:orderItem_id", controller.GetOrderItem(store))
+	authorized.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder(store))
+	authorized.POST("orderItems", controller.CreateOrderItem(store))
+	authorized.PATCH("/orderItems/:orderItem_id", controller.UpdateOrderItem(store))
 
 }
@@ -3,11 +3,34 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func OrderRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/orders", controller.GetOrders())
-	incomingRoutes.GET("/orders/:order_id", controller.GetOrder())
-	incomingRoutes.POST("orders", controller.CreateOrder())
-	incomingRoutes.PATCH("/orders", controller.UpdateOrder())
+var orderPolicy = middleware.Policy{
+	"GET /orders":                           staffRoles,
+	"GET /orders/:order_id":                 staffRoles,
+	"POST /orders":                          floorRoles,
+	"PATCH /orders":                         floorRoles,
+	"PATCH /orders/:order_id":               floorRoles,
+	"POST /orders/:order_id/transition":     staffRoles,
+	"POST /orders/:order_id/coupon":         billingRoles,
+	"DELETE /orders/:order_id/coupon":       billingRoles,
+	"POST /orders/:order_id/transfer":       floorRoles,
+	"POST /orders/:order_id/transfer-items": floorRoles,
+}
+
+func OrderRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(orderPolicy))
+
+	authorized.GET("/orders", controller.GetOrders(store))
+	authorized.GET("/orders/:order_id", controller.GetOrder(store))
+	authorized.POST("orders", controller.CreateOrder(store))
+	authorized.PATCH("/orders", controller.UpdateOrder(store))
+	authorized.PATCH("/orders/:order_id", controller.UpdateOrder(store))
+	authorized.POST("/orders/:order_id/transition", controller.TransitionOrder(store))
+	authorized.POST("/orders/:order_id/coupon", controller.ApplyCoupon(store))
+	authorized.DELETE("/orders/:order_id/coupon", controller.RemoveCoupon(store))
+	authorized.POST("/orders/:order_id/transfer", controller.TransferOrder(store))
+	authorized.POST("/orders/:order_id/transfer-items", controller.TransferOrderItems(store))
 }
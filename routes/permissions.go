@@ -0,0 +1,15 @@
+package routes
+
+import "github.com/rkmangalp/Restaurant_Management/models"
+
+// role groups shared by the route policies
+var (
+	allRoles     = models.AllRoles
+	adminRoles   = []string{models.RoleAdmin}
+	staffRoles   = []string{models.RoleAdmin, models.RoleManager, models.RoleServer, models.RoleKitchen, models.RoleCashier}
+	managerRoles = []string{models.RoleAdmin, models.RoleManager}
+	floorRoles   = []string{models.RoleAdmin, models.RoleManager, models.RoleServer}
+	billingRoles = []string{models.RoleAdmin, models.RoleManager, models.RoleServer, models.RoleCashier}
+	cashierRoles = []string{models.RoleAdmin, models.RoleManager, models.RoleCashier}
+	kitchenRoles = []string{models.RoleAdmin, models.RoleManager, models.RoleKitchen}
+)
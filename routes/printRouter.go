@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/printing"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+var printPolicy = middleware.Policy{
+	"POST /orders/:order_id/tickets":     staffRoles,
+	"POST /invoices/:invoice_id/receipt": billingRoles,
+	"GET /print-jobs":                    staffRoles,
+	"GET /print-jobs/:job_id":            staffRoles,
+	"POST /print-jobs/:job_id/retry":     staffRoles,
+}
+
+func PrintRoutes(incomingRoutes *gin.Engine, store *repository.Store, spooler *printing.Spooler) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(printPolicy))
+
+	authorized.POST("/orders/:order_id/tickets", controller.PrintKitchenTickets(store, spooler))
+	authorized.POST("/invoices/:invoice_id/receipt", controller.PrintInvoiceReceipt(store, spooler))
+	authorized.GET("/print-jobs", controller.GetPrintJobs(spooler))
+	authorized.GET("/print-jobs/:job_id", controller.GetPrintJob(spooler))
+	authorized.POST("/print-jobs/:job_id/retry", controller.RetryPrintJob(spooler))
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+var reportPolicy = middleware.Policy{
+	"GET /reports/tips":        managerRoles,
+	"GET /reports/adjustments": managerRoles,
+}
+
+func ReportRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(reportPolicy))
+
+	authorized.GET("/reports/tips", controller.GetTipReport(store))
+	authorized.GET("/reports/adjustments", controller.GetAdjustmentReport(store))
+}
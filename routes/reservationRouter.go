@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// reservationPolicy leaves the bookings to the floor staff, who take the calls
+// and greet the guests
+var reservationPolicy = middleware.Policy{
+	"GET /reservations":                          floorRoles,
+	"GET /reservations/availability":             floorRoles,
+	"GET /reservations/:reservation_id":          floorRoles,
+	"POST /reservations":                         floorRoles,
+	"PATCH /reservations/:reservation_id":        floorRoles,
+	"POST /reservations/:reservation_id/cancel":  floorRoles,
+	"POST /reservations/:reservation_id/seat":    floorRoles,
+	"POST /reservations/:reservation_id/no-show": floorRoles,
+}
+
+func ReservationRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(reservationPolicy))
+
+	authorized.GET("/reservations", controller.GetReservations(store))
+	authorized.GET("/reservations/availability", controller.GetAvailability(store))
+	authorized.GET("/reservations/:reservation_id", controller.GetReservation(store))
+	authorized.POST("/reservations", controller.CreateReservation(store))
+	authorized.PATCH("/reservations/:reservation_id", controller.UpdateReservation(store))
+	authorized.POST("/reservations/:reservation_id/cancel", controller.CancelReservation(store))
+	authorized.POST("/reservations/:reservation_id/seat", controller.SeatReservation(store))
+	authorized.POST("/reservations/:reservation_id/no-show", controller.MarkNoShow(store))
+}
@@ -3,11 +3,30 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func TableRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/tables", controller.GetTabels())
-	incomingRoutes.GET("/tables/:table_id", controller.GetTabel())
-	incomingRoutes.POST("tables", controller.CreateTable())
-	incomingRoutes.PATCH("/tables/:table_id", controller.UpdateTable())
+var tablePolicy = middleware.Policy{
+	"GET /tables":                        staffRoles,
+	"GET /tables/:table_id":              staffRoles,
+	"POST /tables":                       managerRoles,
+	"PATCH /tables/:table_id":            managerRoles,
+	"GET /floor":                         staffRoles,
+	"POST /tables/merge":                 floorRoles,
+	"GET /table-groups":                  staffRoles,
+	"POST /table-groups/:group_id/split": floorRoles,
+}
+
+func TableRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(tablePolicy))
+
+	authorized.GET("/tables", controller.GetTabels(store))
+	authorized.GET("/tables/:table_id", controller.GetTabel(store))
+	authorized.POST("tables", controller.CreateTable(store))
+	authorized.PATCH("/tables/:table_id", controller.UpdateTable(store))
+	authorized.GET("/floor", controller.GetFloor(store))
+	authorized.POST("/tables/merge", controller.MergeTables(store))
+	authorized.GET("/table-groups", controller.GetTableGroups(store))
+	authorized.POST("/table-groups/:group_id/split", controller.SplitTableGroup(store))
 }
@@ -3,11 +3,28 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/users", controllers.GetUsers())
-	incomingRoutes.GET("/users/:user_id", controllers.GetUser())
-	incomingRoutes.POST("/users/signup", controllers.SignUp())
-	incomingRoutes.POST("/users/login", controllers.Login())
+var userPolicy = middleware.Policy{
+	"GET /users":                  managerRoles,
+	"GET /users/:user_id":         managerRoles,
+	"POST /users/logout":          allRoles,
+	"POST /users/:user_id/revoke": {models.RoleAdmin},
+}
+
+// UserRoutes is registered before the global Authentication middleware, so the
+// protected user routes authenticate themselves.
+func UserRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authentication(store.Sessions), middleware.Authorize(userPolicy))
+
+	authorized.GET("/users", controllers.GetUsers(store))
+	authorized.GET("/users/:user_id", controllers.GetUser(store))
+	authorized.POST("/users/logout", controllers.Logout(store))
+	authorized.POST("/users/:user_id/revoke", controllers.RevokeUserSessions(store))
+	incomingRoutes.POST("/users/signup", middleware.OptionalAuthentication(store.Sessions), controllers.SignUp(store))
+	incomingRoutes.POST("/users/login", controllers.Login(store))
+	incomingRoutes.POST("/users/refresh", controllers.RefreshToken(store))
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+)
+
+// waitlistPolicy leaves the walk-ins to the floor staff at the door
+var waitlistPolicy = middleware.Policy{
+	"GET /waitlist":                     floorRoles,
+	"GET /waitlist/estimate":            floorRoles,
+	"GET /waitlist/:waitlist_id":        floorRoles,
+	"POST /waitlist":                    floorRoles,
+	"POST /waitlist/:waitlist_id/seat":  floorRoles,
+	"POST /waitlist/:waitlist_id/leave": floorRoles,
+}
+
+func WaitlistRoutes(incomingRoutes *gin.Engine, store *repository.Store) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(waitlistPolicy))
+
+	authorized.GET("/waitlist", controller.GetWaitlist(store))
+	authorized.GET("/waitlist/estimate", controller.GetWaitEstimate(store))
+	authorized.GET("/waitlist/:waitlist_id", controller.GetWaitlistEntry(store))
+	authorized.POST("/waitlist", controller.AddToWaitlist(store))
+	authorized.POST("/waitlist/:waitlist_id/seat", controller.SeatWaitlistEntry(store))
+	authorized.POST("/waitlist/:waitlist_id/leave", controller.LeaveWaitlist(store))
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	controller "github.com/rkmangalp/Restaurant_Management/controllers"
+	"github.com/rkmangalp/Restaurant_Management/middleware"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/rkmangalp/Restaurant_Management/webhooks"
+)
+
+// webhookPolicy leaves webhooks to admins, they send data out of the service
+var webhookPolicy = middleware.Policy{
+	"GET /webhooks":                                   adminRoles,
+	"GET /webhooks/:webhook_id":                       adminRoles,
+	"POST /webhooks":                                  adminRoles,
+	"PATCH /webhooks/:webhook_id":                     adminRoles,
+	"DELETE /webhooks/:webhook_id":                    adminRoles,
+	"GET /webhook-deliveries":                         adminRoles,
+	"GET /webhook-deliveries/:delivery_id":            adminRoles,
+	"POST /webhook-deliveries/:delivery_id/redeliver": adminRoles,
+}
+
+func WebhookRoutes(incomingRoutes *gin.Engine, store *repository.Store, dispatcher *webhooks.Dispatcher) {
+	authorized := incomingRoutes.Group("", middleware.Authorize(webhookPolicy))
+
+	authorized.GET("/webhooks", controller.GetWebhooks(store))
+	authorized.GET("/webhooks/:webhook_id", controller.GetWebhook(store))
+	authorized.POST("/webhooks", controller.CreateWebhook(store))
+	authorized.PATCH("/webhooks/:webhook_id", controller.UpdateWebhook(store))
+	authorized.DELETE("/webhooks/:webhook_id", controller.DeleteWebhook(store))
+	authorized.GET("/webhook-deliveries", controller.GetWebhookDeliveries(store))
+	authorized.GET("/webhook-deliveries/:delivery_id", controller.GetWebhookDelivery(store))
+	authorized.POST("/webhook-deliveries/:delivery_id/redeliver", controller.RedeliverWebhookDelivery(dispatcher))
+}
@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryPending  = errors.New("the delivery is still being tried")
+)
+
+// headers sent with every delivery
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// batchSize is how many due deliveries are loaded at a time
+	batchSize = 50
+	// maxPollInterval is the longest the worker sleeps before looking for
+	// retries that became due
+	maxPollInterval = 5 * time.Second
+	// storeTimeout limits the database work around one event or delivery
+	storeTimeout = 10 * time.Second
+)
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the secret of the webhook.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery, for receivers written in Go.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Dispatcher records a delivery for every webhook subscribed to a published
+// event and sends them. Deliveries are kept in the store, so retries survive
+// a restart of the service.
+type Dispatcher struct {
+	cfg    config.WebhookConfig
+	store  *repository.Store
+	broker *events.Broker
+	client *http.Client
+	poll   time.Duration
+
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDispatcher starts listening to the broker. Call Close to stop it.
+func NewDispatcher(cfg config.WebhookConfig, store *repository.Store, broker *events.Broker) *Dispatcher {
+	d := &Dispatcher{
+		cfg:    cfg,
+		store:  store,
+		broker: broker,
+		client: &http.Client{Timeout: cfg.Timeout},
+		poll:   min(cfg.RetryDelay, maxPollInterval),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if d.cfg.MaxAttempts < 1 {
+		d.cfg.MaxAttempts = 1
+	}
+	if d.poll <= 0 {
+		d.poll = maxPollInterval
+	}
+	// subscribe before returning so no event published afterwards is missed
+	sub := broker.Subscribe()
+	d.wg.Add(2)
+	go d.listen(sub)
+	go d.work()
+	return d
+}
+
+// Close stops the dispatcher. Deliveries that were not sent yet stay PENDING.
+func (d *Dispatcher) Close() {
+	close(d.done)
+	d.wg.Wait()
+}
+
+// Redeliver sends a failed or delivered delivery again, with a fresh set of
+// attempts.
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryId string) (models.WebhookDelivery, error) {
+	delivery, err := d.store.Deliveries.FindByID(ctx, deliveryId)
+	if err == repository.ErrNotFound {
+		return delivery, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return delivery, err
+	}
+	if delivery.Status == models.DeliveryPending {
+		return delivery, ErrDeliveryPending
+	}
+
+	now := time.Now().UTC()
+	err = d.store.Deliveries.UpdateStatus(ctx, deliveryId, delivery.Status, bson.D{
+		{Key: "status", Value: models.DeliveryPending},
+		{Key: "attempts", Value: 0},
+		{Key: "next_attempt_at", Value: now},
+		{Key: "updated_at", Value: now},
+	})
+	if err == repository.ErrConflict {
+		return delivery, ErrDeliveryPending
+	}
+	if err != nil {
+		return delivery, err
+	}
+	d.notify()
+	return d.store.Deliveries.FindByID(ctx, deliveryId)
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// listen records the events of sub. When the dispatcher falls behind the
+// broker drops it; it then catches up on the events the broker still keeps.
+func (d *Dispatcher) listen(sub *events.Subscription) {
+	defer d.wg.Done()
+	var lastID uint64
+	for {
+		select {
+		case <-d.done:
+			sub.Close()
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				var missed []events.Event
+				var complete bool
+				sub, missed, complete = d.broker.SubscribeSince(lastID)
+				if !complete {
+					log.Printf("webhooks: events after %d were lost", lastID)
+				}
+				for _, event := range missed {
+					d.record(event)
+					lastID = event.ID
+				}
+				continue
+			}
+			d.record(event)
+			lastID = event.ID
+		}
+	}
+}
+
+// record writes a PENDING delivery of the event for every subscribed webhook.
+func (d *Dispatcher) record(event events.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	webhooks, err := d.store.Webhooks.List(ctx)
+	if err != nil {
+		log.Printf("webhooks: event %d: %v", event.ID, err)
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: event %d: %v", event.ID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			ID:              primitive.NewObjectID(),
+			Webhook_id:      webhook.Webhook_id,
+			Event_id:        event.ID,
+			Event_type:      event.Type,
+			Payload:         string(payload),
+			Status:          models.DeliveryPending,
+			Next_attempt_at: &now,
+			Created_at:      now,
+			Updated_at:      now,
+		}
+		delivery.Delivery_id = delivery.ID.Hex()
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.store.Deliveries.Insert(ctx, deliveries...); err != nil {
+		log.Printf("webhooks: event %d: %v", event.ID, err)
+		return
+	}
+	d.notify()
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		d.sendDue()
+		select {
+		case <-d.done:
+			return
+		case <-d.wake:
+		case <-time.After(d.poll):
+		}
+	}
+}
+
+func (d *Dispatcher) sendDue() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		due, err := d.store.Deliveries.Due(ctx, time.Now().UTC(), batchSize)
+		cancel()
+		if err != nil {
+			log.Printf("webhooks: %v", err)
+			return
+		}
+		for _, delivery := range due {
+			select {
+			case <-d.done:
+				return
+			default:
+			}
+			d.send(delivery)
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// send makes one attempt at the delivery and schedules the next one, or puts
+// it on the dead letter list once MaxAttempts is reached.
+func (d *Dispatcher) send(delivery models.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout+d.cfg.Timeout)
+	defer cancel()
+
+	attempts := delivery.Attempts + 1
+	var code *int
+	var sendErr error
+
+	webhook, err := d.store.Webhooks.FindByID(ctx, delivery.Webhook_id)
+	switch {
+	case err == repository.ErrNotFound:
+		// nobody is listening any more, retrying will not help
+		attempts = d.cfg.MaxAttempts
+		sendErr = errors.New("the webhook was deleted")
+	case err != nil:
+		sendErr = err
+	default:
+		code, sendErr = d.post(ctx, webhook, delivery)
+	}
+
+	now := time.Now().UTC()
+	fields := bson.D{
+		{Key: "attempts", Value: attempts},
+		{Key: "response_code", Value: code},
+		{Key: "updated_at", Value: now},
+	}
+	switch {
+	case sendErr == nil:
+		fields = append(fields,
+			bson.E{Key: "status", Value: models.DeliveryDelivered},
+			bson.E{Key: "last_error", Value: nil},
+			bson.E{Key: "next_attempt_at", Value: nil},
+			bson.E{Key: "delivered_at", Value: now},
+		)
+	case attempts >= d.cfg.MaxAttempts:
+		fields = append(fields,
+			bson.E{Key: "status", Value: models.DeliveryFailed},
+			bson.E{Key: "last_error", Value: sendErr.Error()},
+			bson.E{Key: "next_attempt_at", Value: nil},
+		)
+	default:
+		fields = append(fields,
+			bson.E{Key: "last_error", Value: sendErr.Error()},
+			bson.E{Key: "next_attempt_at", Value: now.Add(d.backoff(attempts))},
+		)
+	}
+
+	if err := d.store.Deliveries.UpdateStatus(ctx, delivery.Delivery_id, models.DeliveryPending, fields); err != nil {
+		log.Printf("webhooks: delivery %s: %v", delivery.Delivery_id, err)
+	}
+}
+
+// post sends the payload, signed, and returns the status code of the answer.
+// Any answer but a 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (*int, error) {
+	if webhook.Url == nil {
+		return nil, errors.New("the webhook has no url")
+	}
+	var secret string
+	if webhook.Secret != nil {
+		secret = *webhook.Secret
+	}
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event_type)
+	req.Header.Set(DeliveryHeader, delivery.Delivery_id)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	code := resp.StatusCode
+	if code < 200 || code > 299 {
+		return &code, fmt.Errorf("the receiver answered %s", resp.Status)
+	}
+	return &code, nil
+}
+
+// backoff is the wait after the given number of failed attempts: RetryDelay,
+// doubled for every further attempt, at most MaxRetryDelay.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryDelay
+	for i := 1; i < attempts && delay < d.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if d.cfg.MaxRetryDelay > 0 {
+		delay = min(delay, d.cfg.MaxRetryDelay)
+	}
+	return delay
+}
@@ -0,0 +1,152 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rkmangalp/Restaurant_Management/config"
+	"github.com/rkmangalp/Restaurant_Management/events"
+	"github.com/rkmangalp/Restaurant_Management/models"
+	"github.com/rkmangalp/Restaurant_Management/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testSecret = "0123456789abcdef"
+
+var testConfig = config.WebhookConfig{
+	MaxAttempts:   3,
+	RetryDelay:    5 * time.Millisecond,
+	MaxRetryDelay: 20 * time.Millisecond,
+	Timeout:       time.Second,
+}
+
+// receiver is a webhook endpoint that answers with status and keeps the
+// requests it got.
+type receiver struct {
+	*httptest.Server
+	status   atomic.Int32
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{}
+	r.status.Store(http.StatusOK)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		r.mu.Unlock()
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func addWebhook(t *testing.T, store *repository.Store, url string, eventTypes ...string) models.Webhook {
+	secret := testSecret
+	webhook := models.Webhook{ID: primitive.NewObjectID(), Url: &url, Event_types: eventTypes, Secret: &secret}
+	webhook.Webhook_id = webhook.ID.Hex()
+	require.NoError(t, store.Webhooks.Insert(context.Background(), webhook))
+	return webhook
+}
+
+func waitForDelivery(t *testing.T, store *repository.Store, webhookId string, status string) models.WebhookDelivery {
+	var delivery models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, _ := store.Deliveries.List(context.Background(), webhookId, status)
+		if len(deliveries) == 0 {
+			return false
+		}
+		delivery = deliveries[0]
+		return true
+	}, 2*time.Second, 5*time.Millisecond, "no %s delivery", status)
+	return delivery
+}
+
+func TestDeliverSignedEvents(t *testing.T) {
+	store := repository.NewMemoryStore()
+	broker := events.NewBroker()
+	dispatcher := NewDispatcher(testConfig, store, broker)
+	defer dispatcher.Close()
+
+	receiver := newReceiver(t)
+	webhook := addWebhook(t, store, receiver.URL, events.InvoicePaid)
+
+	broker.Publish(events.TopicOrders, events.OrderCreated, map[string]string{"order_id": "order1"})
+	paid := broker.Publish(events.TopicInvoices, events.InvoicePaid, map[string]string{"invoice_id": "inv1"})
+
+	delivery := waitForDelivery(t, store, webhook.Webhook_id, models.DeliveryDelivered)
+	assert.Equal(t, paid.ID, delivery.Event_id)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, *delivery.Response_code)
+	require.Equal(t, 1, receiver.received(), "order.created is not subscribed to")
+
+	req, body := receiver.requests[0], receiver.bodies[0]
+	assert.Equal(t, events.InvoicePaid, req.Header.Get(EventHeader))
+	assert.Equal(t, delivery.Delivery_id, req.Header.Get(DeliveryHeader))
+	assert.True(t, Verify(testSecret, req.Header.Get(TimestampHeader), body, req.Header.Get(SignatureHeader)))
+	assert.False(t, Verify("another secret!!", req.Header.Get(TimestampHeader), body, req.Header.Get(SignatureHeader)))
+
+	var event events.Event
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, events.InvoicePaid, event.Type)
+	assert.Equal(t, map[string]interface{}{"invoice_id": "inv1"}, event.Data)
+}
+
+func TestFailedDeliveriesAreRetried(t *testing.T) {
+	store := repository.NewMemoryStore()
+	broker := events.NewBroker()
+	dispatcher := NewDispatcher(testConfig, store, broker)
+	defer dispatcher.Close()
+
+	receiver := newReceiver(t)
+	receiver.status.Store(http.StatusServiceUnavailable)
+	webhook := addWebhook(t, store, receiver.URL, events.OrderCreated)
+
+	broker.Publish(events.TopicOrders, events.OrderCreated, nil)
+
+	dead := waitForDelivery(t, store, webhook.Webhook_id, models.DeliveryFailed)
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, *dead.Response_code)
+	assert.Contains(t, *dead.Last_error, "503")
+	assert.Equal(t, 3, receiver.received())
+
+	// the receiver is fixed
+	receiver.status.Store(http.StatusNoContent)
+	_, err := dispatcher.Redeliver(context.Background(), dead.Delivery_id)
+	require.NoError(t, err)
+	delivered := waitForDelivery(t, store, webhook.Webhook_id, models.DeliveryDelivered)
+	assert.Equal(t, dead.Delivery_id, delivered.Delivery_id)
+	assert.Equal(t, 1, delivered.Attempts)
+	assert.Equal(t, string(receiver.bodies[0]), string(receiver.bodies[3]), "a redelivery sends the same payload")
+
+	_, err = dispatcher.Redeliver(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: config.WebhookConfig{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second}}
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(4))
+	assert.Equal(t, 5*time.Second, d.backoff(60))
+}